@@ -3,6 +3,7 @@ package accountstore
 import (
 	"context"
 	"errors"
+	"time"
 
 	"coffee-chain-api/account"
 )
@@ -10,17 +11,104 @@ import (
 var ErrNotFound = errors.New("account not found")
 var ErrDuplicateEntry = errors.New("duplicate account entry")
 
+// DefaultDeletionGracePeriod is how long a deleted account keeps its personal data before it's anonymised.
+const DefaultDeletionGracePeriod = time.Hour * 24 * 30
+
 type RawAccount struct {
-	Name          string
-	Email         string
+	Name  string
+	Email string
+	// PlainPassword may be empty for invited accounts. They can not login until a password is set.
 	PlainPassword string
 	Type          account.Type
+	// StoreID is the store a merchant cashier is assigned to, zero for other account types.
+	StoreID int64
+}
+
+// UpdateField is a bit mask of profile fields to be updated by AccountStore.UpdatePartial.
+type UpdateField uint8
+
+const (
+	UpdateFieldName UpdateField = 1 << iota
+	UpdateFieldGender
+	UpdateFieldNotifyPushPromotional
+	UpdateFieldNotifyPushTransactional
+	UpdateFieldNotifyEmailPromotional
+	UpdateFieldNotifyEmailTransactional
+)
+
+// PartialUpdate holds the new profile values. Only the fields set in Fields are updated, the rest
+// are ignored, so a field can be updated into its zero value.
+type PartialUpdate struct {
+	Fields UpdateField
+	Name   string
+	Gender account.Gender
+	// NotificationPreferences is updated per channel and category, see the UpdateFieldNotify* fields.
+	NotificationPreferences account.NotificationPreferences
+}
+
+const (
+	// DefaultListLimit is the page size of AccountStore.List if ListFilter.Limit is not set.
+	DefaultListLimit = 50
+	// MaximumListLimit is the largest page size of AccountStore.List.
+	MaximumListLimit = 200
+)
+
+// ListFilter narrows down the accounts returned by AccountStore.List. Zero values are not filtered on.
+type ListFilter struct {
+	Type           account.Type
+	StoreID        int64
+	EmailValidated *bool
+	CreatedAfter   time.Time
+	CreatedBefore  time.Time
+	// Name is matched case-insensitively against any part of the account's name.
+	Name string
+	// Cursor is the NextCursor of the previous page, or zero for the first page.
+	Cursor int64
+	Limit  int
+}
+
+// Record is an account along with its bookkeeping data, for management purposes.
+type Record struct {
+	Account        account.Account
+	EmailValidated bool
+	CreatedAt      time.Time
+	CreatedBy      string
+	UpdatedAt      time.Time
+	UpdatedBy      string
+	// DeactivatedAt is zero if the account is active.
+	DeactivatedAt time.Time
+}
+
+type ListResult struct {
+	Records []Record
+	// NextCursor is zero if there is no next page.
+	NextCursor int64
 }
 
 type AccountStore interface {
+	GetByID(ctx context.Context, id int64) (account.Account, error)
 	GetByEmail(ctx context.Context, email string) (account.Account, error)
+	// List returns accounts ordered by their ID, paginated with the ID as the cursor.
+	List(ctx context.Context, filter ListFilter) (ListResult, error)
 	Insert(ctx context.Context, rawAccount RawAccount) error
+	// DeleteByEmail soft-deletes the account, it can no longer be acquired nor login. The name and email
+	// are kept until the account is anonymised by AnonymiseDeleted after the grace period.
 	DeleteByEmail(ctx context.Context, email string) error
+	// AnonymiseDeleted anonymises the personal data of accounts deleted before deletedBefore, and returns
+	// the number of anonymised accounts.
+	AnonymiseDeleted(ctx context.Context, deletedBefore time.Time) (int64, error)
 	ValidatePassword(ctx context.Context, account2 account.Account, plainPassword string) (bool, error)
-	UpdatePartial(ctx context.Context, account2 account.Account) error
+	// UpdatePartial updates the account's profile fields that are set in the update's field mask, and
+	// returns the updated account.
+	UpdatePartial(ctx context.Context, account2 account.Account, update PartialUpdate) (account.Account, error)
+	// UpdatePassword hashes the plain password and sets it as the account's new password.
+	UpdatePassword(ctx context.Context, account2 account.Account, plainPassword string) error
+	// UpdateEmail replaces the account's email address with an already verified one. It returns
+	// ErrDuplicateEntry if the email address belongs to another account.
+	UpdateEmail(ctx context.Context, account2 account.Account, email string) error
+	// AssignStore assigns the account to another store.
+	AssignStore(ctx context.Context, account2 account.Account, storeID int64) error
+	// Deactivate disables the account without deleting it. Deactivated accounts can not be acquired by
+	// GetByID and GetByEmail nor login, but they are still listed by List.
+	Deactivate(ctx context.Context, account2 account.Account) error
 }
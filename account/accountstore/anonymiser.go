@@ -0,0 +1,30 @@
+package accountstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// RunAnonymiser anonymises accounts that have been deleted for longer than gracePeriod, once every
+// interval, until ctx is done.
+func RunAnonymiser(ctx context.Context, accountStore AccountStore, gracePeriod time.Duration, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		affected, err := accountStore.AnonymiseDeleted(ctx, time.Now().Add(-gracePeriod))
+		if err != nil {
+			log.Error().Err(err).Msg("anonymising deleted accounts")
+		} else if affected > 0 {
+			log.Info().Int64("accounts", affected).Msg("anonymised deleted accounts")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
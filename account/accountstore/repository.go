@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"coffee-chain-api/account"
@@ -25,6 +27,56 @@ type userAccountsTable struct {
 	CreatedBy      string
 	UpdatedAt      time.Time
 	UpdatedBy      string
+
+	NotifyPushPromotional    bool
+	NotifyPushTransactional  bool
+	NotifyEmailPromotional   bool
+	NotifyEmailTransactional bool
+
+	StoreID       sql.NullInt64
+	DeactivatedAt sql.NullTime
+}
+
+// userAccountsColumns is the column list for selecting userAccountsTable, in the same order as
+// userAccountsTable.scanDestinations.
+const userAccountsColumns = `id, 
+				name, 
+				email, 
+				hashed_password, 
+				gender, 
+				type, 
+				email_validated, 
+				created_at, 
+				created_by, 
+				updated_at, 
+				updated_by,
+				notify_push_promotional,
+				notify_push_transactional,
+				notify_email_promotional,
+				notify_email_transactional,
+				store_id,
+				deactivated_at`
+
+func (u *userAccountsTable) scanDestinations() []any {
+	return []any{
+		&u.ID,
+		&u.Name,
+		&u.Email,
+		&u.HashedPassword,
+		&u.Gender,
+		&u.Type,
+		&u.EmailValidated,
+		&u.CreatedAt,
+		&u.CreatedBy,
+		&u.UpdatedAt,
+		&u.UpdatedBy,
+		&u.NotifyPushPromotional,
+		&u.NotifyPushTransactional,
+		&u.NotifyEmailPromotional,
+		&u.NotifyEmailTransactional,
+		&u.StoreID,
+		&u.DeactivatedAt,
+	}
 }
 
 func (u *userAccountsTable) GetProfile() account.Profile {
@@ -43,6 +95,16 @@ func (u *userAccountsTable) GetProfile() account.Profile {
 		Name:   u.Name,
 		Email:  u.Email,
 		Gender: gender,
+		NotificationPreferences: account.NotificationPreferences{
+			Push: account.NotificationCategories{
+				Promotional:   u.NotifyPushPromotional,
+				Transactional: u.NotifyPushTransactional,
+			},
+			Email: account.NotificationCategories{
+				Promotional:   u.NotifyEmailPromotional,
+				Transactional: u.NotifyEmailTransactional,
+			},
+		},
 	}
 }
 
@@ -60,22 +122,41 @@ func (u *userAccountsTable) GetType() account.Type {
 }
 
 func (u *userAccountsTable) StoreIdentifier() int64 {
-	return 0
+	if !u.StoreID.Valid {
+		return 0
+	}
+
+	return u.StoreID.Int64
+}
+
+func (u *userAccountsTable) record() Record {
+	return Record{
+		Account:        u,
+		EmailValidated: u.EmailValidated,
+		CreatedAt:      u.CreatedAt,
+		CreatedBy:      u.CreatedBy,
+		UpdatedAt:      u.UpdatedAt,
+		UpdatedBy:      u.UpdatedBy,
+		DeactivatedAt:  u.DeactivatedAt.Time,
+	}
 }
 
+// likeEscaper escapes the wildcard characters of a LIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 type repository struct {
 	db             *sql.DB
 	passwordHasher password.Hasher
 }
 
-func (r *repository) UpdatePartial(ctx context.Context, account2 account.Account) error {
-	if account2.GetProfile().Email == "" {
-		return nil
+func (r *repository) UpdatePartial(ctx context.Context, account2 account.Account, update PartialUpdate) (account.Account, error) {
+	if account2 == nil {
+		return nil, ErrNotFound
 	}
 
 	conn, err := r.db.Conn(ctx)
 	if err != nil {
-		return fmt.Errorf("acquiring connection from pool: %w", err)
+		return nil, fmt.Errorf("acquiring connection from pool: %w", err)
 	}
 	defer func() {
 		err := conn.Close()
@@ -89,81 +170,112 @@ func (r *repository) UpdatePartial(ctx context.Context, account2 account.Account
 		ReadOnly:  false,
 	})
 	if err != nil {
-		return fmt.Errorf("creating transaction: %w", err)
+		return nil, fmt.Errorf("creating transaction: %w", err)
 	}
 
 	var userAccount userAccountsTable
-	err = conn.QueryRowContext(
+	err = tx.QueryRowContext(
 		ctx,
 		`SELECT 
-				id, 
-				name, 
-				email, 
-				hashed_password, 
-				gender, 
-				type, 
-				email_validated, 
-				created_at, 
-				created_by, 
-				updated_at, 
-				updated_by
+				`+userAccountsColumns+`
 			FROM 
 				user_accounts
 			WHERE
-				email = $1
-			LIMIT 1`,
-		account2.GetProfile().Email,
-	).Scan(
-		&userAccount.ID,
-		&userAccount.Name,
-		&userAccount.Email,
-		&userAccount.HashedPassword,
-		&userAccount.Gender,
-		&userAccount.Type,
-		&userAccount.EmailValidated,
-		&userAccount.CreatedAt,
-		&userAccount.CreatedBy,
-		&userAccount.UpdatedAt,
-		&userAccount.UpdatedBy,
-	)
+				id = $1
+				AND deleted_at IS NULL
+			LIMIT 1
+			FOR UPDATE`,
+		account2.GetProfile().ID,
+	).Scan(userAccount.scanDestinations()...)
 	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return nil, fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
 		if errors.Is(err, sql.ErrNoRows) {
-			return ErrNotFound
+			return nil, ErrNotFound
 		}
 
-		return fmt.Errorf("getting user account by email: %w", err)
+		return nil, fmt.Errorf("getting user account by id: %w", err)
 	}
 
-	var updatedName string
-	if account2.GetProfile().Name != "" && userAccount.Name != account2.GetProfile().Name {
-		updatedName = account2.GetProfile().Name
+	changed := false
+	if update.Fields&UpdateFieldName != 0 && update.Name != userAccount.Name {
+		userAccount.Name = update.Name
+		changed = true
 	}
+	if update.Fields&UpdateFieldGender != 0 && int8(update.Gender) != userAccount.Gender {
+		userAccount.Gender = int8(update.Gender)
+		changed = true
+	}
+	preferences := update.NotificationPreferences
+	for _, field := range []struct {
+		mask    UpdateField
+		current *bool
+		value   bool
+	}{
+		{UpdateFieldNotifyPushPromotional, &userAccount.NotifyPushPromotional, preferences.Push.Promotional},
+		{UpdateFieldNotifyPushTransactional, &userAccount.NotifyPushTransactional, preferences.Push.Transactional},
+		{UpdateFieldNotifyEmailPromotional, &userAccount.NotifyEmailPromotional, preferences.Email.Promotional},
+		{UpdateFieldNotifyEmailTransactional, &userAccount.NotifyEmailTransactional, preferences.Email.Transactional},
+	} {
+		if update.Fields&field.mask != 0 && *field.current != field.value {
+			*field.current = field.value
+			changed = true
+		}
+	}
+
+	if !changed {
+		err = tx.Commit()
+		if err != nil {
+			return nil, fmt.Errorf("committing transaction: %w", err)
+		}
 
-	var updatedGender account.Gender
-	if account2.GetProfile().Gender != account.GenderUnspecified {
-		updatedGender = account2.GetProfile().Gender
+		return &userAccount, nil
 	}
 
-	_, err = conn.ExecContext(
+	userAccount.UpdatedAt = time.Now()
+	userAccount.UpdatedBy = account.ActorIdentifier(ctx)
+
+	_, err = tx.ExecContext(
 		ctx,
 		`UPDATE
 			user_accounts
 		SET
 			name = $1,
-			gender = $2
+			gender = $2,
+			notify_push_promotional = $3,
+			notify_push_transactional = $4,
+			notify_email_promotional = $5,
+			notify_email_transactional = $6,
+			updated_at = $7,
+			updated_by = $8
 		WHERE
-			email = $3`,
-		updatedName,
-		updatedGender,
-		account2.GetProfile().Email,
+			id = $9`,
+		userAccount.Name,
+		userAccount.Gender,
+		userAccount.NotifyPushPromotional,
+		userAccount.NotifyPushTransactional,
+		userAccount.NotifyEmailPromotional,
+		userAccount.NotifyEmailTransactional,
+		userAccount.UpdatedAt,
+		userAccount.UpdatedBy,
+		userAccount.ID,
 	)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return nil, fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		return nil, fmt.Errorf("executing update query: %w", err)
+	}
 
 	err = tx.Commit()
 	if err != nil {
-		return fmt.Errorf("committing transaction: %w", err)
+		return nil, fmt.Errorf("committing transaction: %w", err)
 	}
 
-	return nil
+	return &userAccount, nil
 }
 
 func (r *repository) getUserAccountTableByEmail(ctx context.Context, email string) (*userAccountsTable, error) {
@@ -182,36 +294,16 @@ func (r *repository) getUserAccountTableByEmail(ctx context.Context, email strin
 	err = conn.QueryRowContext(
 		ctx,
 		`SELECT 
-				id, 
-				name, 
-				email, 
-				hashed_password, 
-				gender, 
-				type, 
-				email_validated, 
-				created_at, 
-				created_by, 
-				updated_at, 
-				updated_by
+				`+userAccountsColumns+`
 			FROM 
 				user_accounts
 			WHERE
 				email = $1
+				AND deleted_at IS NULL
+				AND deactivated_at IS NULL
 			LIMIT 1`,
 		email,
-	).Scan(
-		&userAccount.ID,
-		&userAccount.Name,
-		&userAccount.Email,
-		&userAccount.HashedPassword,
-		&userAccount.Gender,
-		&userAccount.Type,
-		&userAccount.EmailValidated,
-		&userAccount.CreatedAt,
-		&userAccount.CreatedBy,
-		&userAccount.UpdatedAt,
-		&userAccount.UpdatedBy,
-	)
+	).Scan(userAccount.scanDestinations()...)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrNotFound
@@ -233,6 +325,11 @@ func (r *repository) ValidatePassword(ctx context.Context, account2 account.Acco
 		return false, fmt.Errorf("acquiring user account table by email: %w", err)
 	}
 
+	// Invited accounts have no password until they set one through the invitation link.
+	if userAccountTable.HashedPassword == "" {
+		return false, nil
+	}
+
 	ok, err := r.passwordHasher.Verify(ctx, plainPassword, userAccountTable.HashedPassword)
 	if err != nil {
 		return false, fmt.Errorf("verifying password: %w", err)
@@ -241,6 +338,143 @@ func (r *repository) ValidatePassword(ctx context.Context, account2 account.Acco
 	return ok, nil
 }
 
+func (r *repository) GetByID(ctx context.Context, id int64) (account.Account, error) {
+	if id <= 0 {
+		return nil, ErrNotFound
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	var userAccount userAccountsTable
+	err = conn.QueryRowContext(
+		ctx,
+		`SELECT 
+				`+userAccountsColumns+`
+			FROM 
+				user_accounts
+			WHERE
+				id = $1
+				AND deleted_at IS NULL
+				AND deactivated_at IS NULL
+			LIMIT 1`,
+		id,
+	).Scan(userAccount.scanDestinations()...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+
+		return nil, fmt.Errorf("getting user account by id: %w", err)
+	}
+
+	return &userAccount, nil
+}
+
+func (r *repository) List(ctx context.Context, filter ListFilter) (ListResult, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	if limit > MaximumListLimit {
+		limit = MaximumListLimit
+	}
+
+	conditions := []string{"deleted_at IS NULL", "id > $1"}
+	args := []any{filter.Cursor}
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", "$"+strconv.Itoa(len(args))))
+	}
+
+	if filter.Type != account.TypeUnspecified {
+		addCondition("type = ?", filter.Type)
+	}
+	if filter.StoreID > 0 {
+		addCondition("store_id = ?", filter.StoreID)
+	}
+	if filter.EmailValidated != nil {
+		addCondition("email_validated = ?", *filter.EmailValidated)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		addCondition("created_at >= ?", filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		addCondition("created_at < ?", filter.CreatedBefore)
+	}
+	if name := strings.TrimSpace(filter.Name); name != "" {
+		addCondition(`name ILIKE ? ESCAPE '\'`, "%"+likeEscaper.Replace(name)+"%")
+	}
+
+	// One more row than requested, to know whether there is a next page.
+	args = append(args, limit+1)
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return ListResult{}, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	rows, err := conn.QueryContext(
+		ctx,
+		`SELECT 
+				`+userAccountsColumns+`
+			FROM 
+				user_accounts
+			WHERE
+				`+strings.Join(conditions, " AND ")+`
+			ORDER BY
+				id ASC
+			LIMIT $`+strconv.Itoa(len(args)),
+		args...,
+	)
+	if err != nil {
+		return ListResult{}, fmt.Errorf("executing select query: %w", err)
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing rows")
+		}
+	}()
+
+	var result ListResult
+	for rows.Next() {
+		var userAccount userAccountsTable
+		err = rows.Scan(userAccount.scanDestinations()...)
+		if err != nil {
+			return ListResult{}, fmt.Errorf("scanning row: %w", err)
+		}
+
+		result.Records = append(result.Records, userAccount.record())
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return ListResult{}, fmt.Errorf("iterating rows: %w", err)
+	}
+
+	if len(result.Records) > limit {
+		result.Records = result.Records[:limit]
+		result.NextCursor = result.Records[limit-1].Account.GetProfile().ID
+	}
+
+	return result, nil
+}
+
 func (r *repository) GetByEmail(ctx context.Context, email string) (account.Account, error) {
 	if email == "" {
 		return nil, ErrNotFound
@@ -250,11 +484,19 @@ func (r *repository) GetByEmail(ctx context.Context, email string) (account.Acco
 }
 
 func (r *repository) Insert(ctx context.Context, rawAccount RawAccount) error {
-	hashedPassword, err := r.passwordHasher.Hash(ctx, rawAccount.PlainPassword)
-	if err != nil {
-		return fmt.Errorf("hashing password: %w", err)
+	defaultNotificationPreferences := account.DefaultNotificationPreferences()
+
+	var hashedPassword string
+	if rawAccount.PlainPassword != "" {
+		var err error
+		hashedPassword, err = r.passwordHasher.Hash(ctx, rawAccount.PlainPassword)
+		if err != nil {
+			return fmt.Errorf("hashing password: %w", err)
+		}
 	}
 
+	storeID := sql.NullInt64{Int64: rawAccount.StoreID, Valid: rawAccount.StoreID > 0}
+
 	conn, err := r.db.Conn(ctx)
 	if err != nil {
 		return fmt.Errorf("acquiring connection from pool: %w", err)
@@ -287,10 +529,15 @@ func (r *repository) Insert(ctx context.Context, rawAccount RawAccount) error {
 			 created_at, 
 			 created_by, 
 			 updated_at, 
-			 updated_by
+			 updated_by,
+			 notify_push_promotional,
+			 notify_push_transactional,
+			 notify_email_promotional,
+			 notify_email_transactional,
+			 store_id
 			 )
 		VALUES 
-			($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`,
 		rawAccount.Name,
 		rawAccount.Email,
 		hashedPassword,
@@ -298,16 +545,23 @@ func (r *repository) Insert(ctx context.Context, rawAccount RawAccount) error {
 		rawAccount.Type,
 		false,
 		time.Now(),
-		"system",
+		account.ActorIdentifier(ctx),
 		time.Now(),
-		"system",
+		account.ActorIdentifier(ctx),
+		defaultNotificationPreferences.Push.Promotional,
+		defaultNotificationPreferences.Push.Transactional,
+		defaultNotificationPreferences.Email.Promotional,
+		defaultNotificationPreferences.Email.Transactional,
+		storeID,
 	)
 	if err != nil {
 		if e := tx.Rollback(); e != nil {
 			return fmt.Errorf("rolling back transaction: %w (%s)", e, err)
 		}
 
-		// TODO: Handle if user already exists
+		if isUniqueViolation(err) {
+			return ErrDuplicateEntry
+		}
 
 		return fmt.Errorf("executing insert query: %w", err)
 	}
@@ -320,9 +574,245 @@ func (r *repository) Insert(ctx context.Context, rawAccount RawAccount) error {
 	return nil
 }
 
+func (r *repository) UpdatePassword(ctx context.Context, account2 account.Account, plainPassword string) error {
+	if account2 == nil {
+		return ErrNotFound
+	}
+
+	hashedPassword, err := r.passwordHasher.Hash(ctx, plainPassword)
+	if err != nil {
+		return fmt.Errorf("hashing password: %w", err)
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	result, err := conn.ExecContext(
+		ctx,
+		`UPDATE
+			user_accounts
+		SET
+			hashed_password = $1,
+			updated_at = $2,
+			updated_by = $3
+		WHERE
+			id = $4
+			AND deleted_at IS NULL`,
+		hashedPassword,
+		time.Now(),
+		account.ActorIdentifier(ctx),
+		account2.GetProfile().ID,
+	)
+	if err != nil {
+		return fmt.Errorf("executing update query: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("acquiring affected rows: %w", err)
+	}
+
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *repository) UpdateEmail(ctx context.Context, account2 account.Account, email string) error {
+	if account2 == nil {
+		return ErrNotFound
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	// The new address has been verified by the time it reaches here, hence email_validated is set.
+	result, err := conn.ExecContext(
+		ctx,
+		`UPDATE
+			user_accounts
+		SET
+			email = $1,
+			email_validated = TRUE,
+			updated_at = $2,
+			updated_by = $3
+		WHERE
+			id = $4
+			AND deleted_at IS NULL`,
+		email,
+		time.Now(),
+		account.ActorIdentifier(ctx),
+		account2.GetProfile().ID,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicateEntry
+		}
+
+		return fmt.Errorf("executing update query: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("acquiring affected rows: %w", err)
+	}
+
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
 func (r *repository) DeleteByEmail(ctx context.Context, email string) error {
-	// TODO implement me
-	panic("implement me")
+	if email == "" {
+		return ErrNotFound
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	result, err := conn.ExecContext(
+		ctx,
+		`UPDATE
+			user_accounts
+		SET
+			deleted_at = $1,
+			updated_at = $1,
+			updated_by = $2
+		WHERE
+			email = $3
+			AND deleted_at IS NULL`,
+		time.Now(),
+		account.ActorIdentifier(ctx),
+		email,
+	)
+	if err != nil {
+		return fmt.Errorf("executing update query: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("acquiring affected rows: %w", err)
+	}
+
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *repository) AnonymiseDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("creating transaction: %w", err)
+	}
+
+	// Truncated into PostgreSQL's precision, so it can be compared against the stored value.
+	anonymisedAt := time.Now().Truncate(time.Microsecond)
+
+	// The row itself is kept as a tombstone, so orders and loyalty history can still refer to it.
+	result, err := tx.ExecContext(
+		ctx,
+		`UPDATE
+			user_accounts
+		SET
+			name = 'Deleted account',
+			email = 'deleted-' || id::TEXT || '@deleted.invalid',
+			hashed_password = '',
+			gender = 0,
+			notify_push_promotional = FALSE,
+			notify_push_transactional = FALSE,
+			notify_email_promotional = FALSE,
+			notify_email_transactional = FALSE,
+			anonymised_at = $1,
+			updated_at = $1,
+			updated_by = $2
+		WHERE
+			deleted_at < $3
+			AND anonymised_at IS NULL`,
+		anonymisedAt,
+		account.ActorIdentifier(ctx),
+		deletedBefore,
+	)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return 0, fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		return 0, fmt.Errorf("executing update query: %w", err)
+	}
+
+	// Pending email changes hold an email address of the user, security events hold their IP addresses, and
+	// reset tokens and two-factor secrets are useless by now.
+	for _, query := range []string{
+		`DELETE FROM email_change_requests WHERE account_id IN (SELECT id FROM user_accounts WHERE anonymised_at = $1)`,
+		`DELETE FROM password_reset_tokens WHERE account_id IN (SELECT id FROM user_accounts WHERE anonymised_at = $1)`,
+		`DELETE FROM account_totp WHERE account_id IN (SELECT id FROM user_accounts WHERE anonymised_at = $1)`,
+		`DELETE FROM account_recovery_codes WHERE account_id IN (SELECT id FROM user_accounts WHERE anonymised_at = $1)`,
+		`DELETE FROM security_events WHERE account_id IN (SELECT id FROM user_accounts WHERE anonymised_at = $1)`,
+	} {
+		_, err = tx.ExecContext(ctx, query, anonymisedAt)
+		if err != nil {
+			if e := tx.Rollback(); e != nil {
+				return 0, fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+			}
+
+			return 0, fmt.Errorf("executing delete query: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("committing transaction: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("acquiring affected rows: %w", err)
+	}
+
+	return affected, nil
 }
 
 func NewRepository(db *sql.DB, passwordHasher password.Hasher) (AccountStore, error) {
@@ -335,3 +825,111 @@ func NewRepository(db *sql.DB, passwordHasher password.Hasher) (AccountStore, er
 
 	return &repository{db: db, passwordHasher: passwordHasher}, nil
 }
+
+// isUniqueViolation checks whether the error is a PostgreSQL unique_violation (SQLSTATE 23505). Both
+// lib/pq and pgx errors expose the SQLSTATE through the SQLState method.
+func isUniqueViolation(err error) bool {
+	var sqlStateError interface{ SQLState() string }
+	if errors.As(err, &sqlStateError) {
+		return sqlStateError.SQLState() == "23505"
+	}
+
+	return false
+}
+
+func (r *repository) AssignStore(ctx context.Context, account2 account.Account, storeID int64) error {
+	if account2 == nil {
+		return ErrNotFound
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	result, err := conn.ExecContext(
+		ctx,
+		`UPDATE
+			user_accounts
+		SET
+			store_id = $1,
+			updated_at = $2,
+			updated_by = $3
+		WHERE
+			id = $4
+			AND deleted_at IS NULL
+			AND deactivated_at IS NULL`,
+		sql.NullInt64{Int64: storeID, Valid: storeID > 0},
+		time.Now(),
+		account.ActorIdentifier(ctx),
+		account2.GetProfile().ID,
+	)
+	if err != nil {
+		return fmt.Errorf("executing update query: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("acquiring affected rows: %w", err)
+	}
+
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *repository) Deactivate(ctx context.Context, account2 account.Account) error {
+	if account2 == nil {
+		return ErrNotFound
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	result, err := conn.ExecContext(
+		ctx,
+		`UPDATE
+			user_accounts
+		SET
+			deactivated_at = $1,
+			updated_at = $1,
+			updated_by = $2
+		WHERE
+			id = $3
+			AND deleted_at IS NULL
+			AND deactivated_at IS NULL`,
+		time.Now(),
+		account.ActorIdentifier(ctx),
+		account2.GetProfile().ID,
+	)
+	if err != nil {
+		return fmt.Errorf("executing update query: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("acquiring affected rows: %w", err)
+	}
+
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
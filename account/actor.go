@@ -0,0 +1,33 @@
+package account
+
+import (
+	"context"
+	"strconv"
+)
+
+// SystemActor identifies changes that are not made by any account, such as CLI commands and background jobs.
+const SystemActor = "system"
+
+type actorContextKey struct{}
+
+// WithActor returns a copy of ctx that carries the account performing the action.
+func WithActor(ctx context.Context, actor Account) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext returns the account performing the action, as set by WithActor.
+func ActorFromContext(ctx context.Context) (Account, bool) {
+	actor, ok := ctx.Value(actorContextKey{}).(Account)
+	return actor, ok && actor != nil
+}
+
+// ActorIdentifier returns the identifier of the account performing the action, to be stored in the
+// created_by and updated_by columns. It's the account ID, or SystemActor if there is no account.
+func ActorIdentifier(ctx context.Context) string {
+	actor, ok := ActorFromContext(ctx)
+	if !ok || actor.GetProfile().ID <= 0 {
+		return SystemActor
+	}
+
+	return strconv.FormatInt(actor.GetProfile().ID, 10)
+}
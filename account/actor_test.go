@@ -0,0 +1,29 @@
+package account_test
+
+import (
+	"context"
+	"testing"
+
+	"coffee-chain-api/account"
+)
+
+func TestActorIdentifier(t *testing.T) {
+	ctx := context.Background()
+
+	if out := account.ActorIdentifier(ctx); out != account.SystemActor {
+		t.Errorf("expecting %s, got %s instead", account.SystemActor, out)
+	}
+
+	ctx = account.WithActor(ctx, account.NewBasicAccount(account.Profile{ID: 42}, account.TypeManagement, 0))
+	if out := account.ActorIdentifier(ctx); out != "42" {
+		t.Errorf("expecting 42, got %s instead", out)
+	}
+
+	ctx = account.WithActor(context.Background(), nil)
+	if _, ok := account.ActorFromContext(ctx); ok {
+		t.Error("expecting no actor from a nil account")
+	}
+	if out := account.ActorIdentifier(ctx); out != account.SystemActor {
+		t.Errorf("expecting %s, got %s instead", account.SystemActor, out)
+	}
+}
@@ -10,10 +10,49 @@ import (
 
 var ErrInvalidAuthentication = errors.New("invalid authentication")
 
+// ErrMFARequired indicates that the password is valid, but the account must also provide a second factor.
+// Use errors.As with *MFARequiredError to acquire the challenge token for Authenticator.LoginWithMFA.
+var ErrMFARequired = errors.New("second factor required")
+
+// MFARequiredError is returned by Authenticator.Login for accounts with a second factor.
+type MFARequiredError struct {
+	ChallengeToken string
+	ExpiredAt      time.Time
+}
+
+func (m *MFARequiredError) Error() string {
+	return ErrMFARequired.Error()
+}
+
+func (m *MFARequiredError) Is(target error) bool {
+	return target == ErrMFARequired
+}
+
+// MFAChallengeLifetime is how long the challenge token of Authenticator.Login is valid for.
+const MFAChallengeLifetime = time.Minute * 5
+
+// SecondFactor verifies the second factor of accounts that have enabled it, such as a TOTP code.
+type SecondFactor interface {
+	Enrolled(ctx context.Context, accountId int64) (bool, error)
+	// Verify returns false if the code is invalid. A code must only be accepted once.
+	Verify(ctx context.Context, accountId int64, code string) (bool, error)
+}
+
 type Authenticator interface {
+	// Login returns ErrInvalidAuthentication if the credential is invalid, or *loginthrottle.ThrottledError if
+	// there have been too many failed attempts for the email address or from the client's IP address.
+	//
+	// If the account has a second factor, no session is created. It returns *MFARequiredError instead, and
+	// the login continues with LoginWithMFA.
 	Login(ctx context.Context, email string, plainPassword string) (accessToken string, refreshToken string, expiredAt time.Time, err error)
+	// LoginWithMFA completes the login with the challenge token from Login and the second factor's code. It
+	// returns the same errors as Login.
+	LoginWithMFA(ctx context.Context, challengeToken string, code string) (accessToken string, refreshToken string, expiredAt time.Time, err error)
 	Logout(ctx context.Context, accessToken string) error
 	Refresh(ctx context.Context, refreshToken string) (accessToken string, expiredAt time.Time, err error)
+	// RevokeSessions removes every access and refresh session of the account, except the ones in keepTokens,
+	// along with the pending second factor challenges.
+	RevokeSessions(ctx context.Context, accountId int64, keepTokens ...string) error
 }
 
 type Validator interface {
@@ -2,7 +2,9 @@ package authentication
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"time"
@@ -10,7 +12,12 @@ import (
 	"coffee-chain-api/account"
 	"coffee-chain-api/account/accountstore"
 	"coffee-chain-api/account/jwt"
+	"coffee-chain-api/account/loginthrottle"
+	"coffee-chain-api/account/securityevent"
 	"coffee-chain-api/account/sessionstore"
+	"coffee-chain-api/mailer"
+
+	"github.com/rs/zerolog/log"
 )
 
 type Repository struct {
@@ -19,38 +26,170 @@ type Repository struct {
 	refreshSessionStore sessionstore.SessionStore
 	accountStore        accountstore.AccountStore
 	jwt                 *jwt.AuthJwt
+	mfaChallengeStore   sessionstore.SessionStore
+	secondFactor        SecondFactor
+	throttler           *loginthrottle.Throttler
+	securityEvents      *securityevent.Recorder
+	mailer              mailer.Mailer
 }
 
 func (r *Repository) Login(ctx context.Context, email string, plainPassword string) (accessToken string, refreshToken string, expiredAt time.Time, err error) {
-	basicUserAccount := account.NewBasicAccount(account.Profile{Email: email}, account.TypeUnspecified, 0)
+	clientIP := account.ClientFromContext(ctx).IP
 
-	passwordValidated, err := r.accountStore.ValidatePassword(ctx, basicUserAccount, plainPassword)
+	err = r.throttler.Check(ctx, email, clientIP)
 	if err != nil {
-		return "", "", time.Time{}, fmt.Errorf("validating password: %w", err)
+		// The account is not looked up on the throttled attempts, so they don't cost a query each.
+		if errors.Is(err, loginthrottle.ErrThrottled) {
+			r.securityEvents.Record(ctx, 0, securityevent.TypeLogin, securityevent.OutcomeThrottled)
+		}
+
+		return "", "", time.Time{}, fmt.Errorf("checking login throttle: %w", err)
+	}
+
+	// An unknown email address counts as a failed attempt, just like a wrong password.
+	var accountId int64
+	passwordValidated := false
+	properUserAccount, err := r.accountStore.GetByEmail(ctx, email)
+	if err != nil && !errors.Is(err, accountstore.ErrNotFound) {
+		return "", "", time.Time{}, fmt.Errorf("acquiring account by email: %w", err)
+	}
+	if err == nil {
+		accountId = properUserAccount.GetProfile().ID
+
+		passwordValidated, err = r.accountStore.ValidatePassword(ctx, properUserAccount, plainPassword)
+		if err != nil && !errors.Is(err, accountstore.ErrNotFound) {
+			return "", "", time.Time{}, fmt.Errorf("validating password: %w", err)
+		}
 	}
 
 	if !passwordValidated {
+		r.securityEvents.Record(ctx, accountId, securityevent.TypeLogin, securityevent.OutcomeFailure)
+
+		err = r.recordFailure(ctx, email, clientIP)
+		if err != nil {
+			return "", "", time.Time{}, err
+		}
+
 		return "", "", time.Time{}, ErrInvalidAuthentication
 	}
 
-	properUserAccount, err := r.accountStore.GetByEmail(ctx, email)
+	enrolled, err := r.secondFactor.Enrolled(ctx, accountId)
 	if err != nil {
-		return "", "", time.Time{}, fmt.Errorf("acquiring account by email: %w", err)
+		return "", "", time.Time{}, fmt.Errorf("checking second factor enrollment: %w", err)
 	}
 
-	accessToken, refreshToken, err = r.jwt.Sign(properUserAccount.GetProfile().ID)
+	// The failed attempts are only reset after the second factor, so guessing the code is throttled too.
+	if enrolled {
+		rawToken := make([]byte, 32)
+		_, err = rand.Read(rawToken)
+		if err != nil {
+			return "", "", time.Time{}, fmt.Errorf("reading random reader: %w", err)
+		}
+		challengeToken := base64.RawURLEncoding.EncodeToString(rawToken)
+
+		challengeExpiredAt := time.Now().Add(MFAChallengeLifetime)
+		err = r.mfaChallengeStore.Set(ctx, properUserAccount, challengeToken, challengeExpiredAt)
+		if err != nil {
+			return "", "", time.Time{}, fmt.Errorf("storing challenge on store: %w", err)
+		}
+
+		r.securityEvents.Record(ctx, properUserAccount.GetProfile().ID, securityevent.TypeLogin, securityevent.OutcomeMFARequired)
+
+		return "", "", time.Time{}, &MFARequiredError{ChallengeToken: challengeToken, ExpiredAt: challengeExpiredAt}
+	}
+
+	err = r.throttler.Succeed(ctx, email)
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("resetting login throttle: %w", err)
+	}
+
+	r.securityEvents.Record(account.WithActor(ctx, properUserAccount), properUserAccount.GetProfile().ID, securityevent.TypeLogin, securityevent.OutcomeSuccess)
+
+	return r.startSession(ctx, properUserAccount)
+}
+
+func (r *Repository) LoginWithMFA(ctx context.Context, challengeToken string, code string) (accessToken string, refreshToken string, expiredAt time.Time, err error) {
+	if challengeToken == "" {
+		return "", "", time.Time{}, ErrInvalidAuthentication
+	}
+
+	challengeAccount, err := r.mfaChallengeStore.Get(ctx, challengeToken)
+	if err != nil {
+		if errors.Is(err, sessionstore.ErrEmptyToken) || errors.Is(err, sessionstore.ErrSessionNotExists) {
+			return "", "", time.Time{}, ErrInvalidAuthentication
+		}
+
+		return "", "", time.Time{}, fmt.Errorf("acquiring challenge: %w", err)
+	}
+
+	email := challengeAccount.GetProfile().Email
+	clientIP := account.ClientFromContext(ctx).IP
+
+	err = r.throttler.Check(ctx, email, clientIP)
+	if err != nil {
+		if errors.Is(err, loginthrottle.ErrThrottled) {
+			r.securityEvents.Record(ctx, challengeAccount.GetProfile().ID, securityevent.TypeLoginMFA, securityevent.OutcomeThrottled)
+		}
+
+		return "", "", time.Time{}, fmt.Errorf("checking login throttle: %w", err)
+	}
+
+	verified, err := r.secondFactor.Verify(ctx, challengeAccount.GetProfile().ID, code)
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("verifying second factor: %w", err)
+	}
+
+	if !verified {
+		r.securityEvents.Record(ctx, challengeAccount.GetProfile().ID, securityevent.TypeLoginMFA, securityevent.OutcomeFailure)
+
+		err = r.recordFailure(ctx, email, clientIP)
+		if err != nil {
+			return "", "", time.Time{}, err
+		}
+
+		return "", "", time.Time{}, ErrInvalidAuthentication
+	}
+
+	err = r.mfaChallengeStore.Remove(ctx, challengeToken)
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("removing challenge: %w", err)
+	}
+
+	err = r.throttler.Succeed(ctx, email)
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("resetting login throttle: %w", err)
+	}
+
+	// The account may have been deactivated or deleted since the challenge was issued.
+	userAccount, err := r.accountStore.GetByID(ctx, challengeAccount.GetProfile().ID)
+	if err != nil {
+		if errors.Is(err, accountstore.ErrNotFound) {
+			return "", "", time.Time{}, ErrInvalidAuthentication
+		}
+
+		return "", "", time.Time{}, fmt.Errorf("acquiring account by id: %w", err)
+	}
+
+	r.securityEvents.Record(account.WithActor(ctx, userAccount), userAccount.GetProfile().ID, securityevent.TypeLoginMFA, securityevent.OutcomeSuccess)
+
+	return r.startSession(ctx, userAccount)
+}
+
+// startSession signs and stores a new pair of access and refresh tokens for the account.
+func (r *Repository) startSession(ctx context.Context, userAccount account.Account) (accessToken string, refreshToken string, expiredAt time.Time, err error) {
+	accessToken, refreshToken, err = r.jwt.Sign(userAccount.GetProfile().ID)
 	if err != nil {
 		return "", "", time.Time{}, fmt.Errorf("signing jsonwebtoken: %w", err)
 	}
 
 	accessTokenExpiredAt := time.Now().Add(time.Hour)
-	err = r.accessSessionStore.Set(ctx, properUserAccount, accessToken, accessTokenExpiredAt)
+	err = r.accessSessionStore.Set(ctx, userAccount, accessToken, accessTokenExpiredAt)
 	if err != nil {
 		return "", "", time.Time{}, fmt.Errorf("storing session on store: %w", err)
 	}
 
 	refreshTokenExpiredAt := time.Now().Add(time.Hour * 24 * 30)
-	err = r.refreshSessionStore.Set(ctx, properUserAccount, refreshToken, refreshTokenExpiredAt)
+	err = r.refreshSessionStore.Set(ctx, userAccount, refreshToken, refreshTokenExpiredAt)
 	if err != nil {
 		return "", "", time.Time{}, fmt.Errorf("storing session on store: %w", err)
 	}
@@ -58,12 +197,82 @@ func (r *Repository) Login(ctx context.Context, email string, plainPassword stri
 	return accessToken, refreshToken, accessTokenExpiredAt, nil
 }
 
+// recordFailure records a failed login attempt, and notifies the account owner if it locks the login out.
+func (r *Repository) recordFailure(ctx context.Context, email string, clientIP string) error {
+	lockedOut, err := r.throttler.Fail(ctx, email, clientIP)
+	if err != nil {
+		return fmt.Errorf("recording failed login: %w", err)
+	}
+
+	if lockedOut {
+		// Notify in the background, so the response time does not tell whether the account exists.
+		go r.notifyLockout(context.WithoutCancel(ctx), email)
+	}
+
+	return nil
+}
+
+// notifyLockout emails the account owner that the login has been locked out, in case it's not them.
+func (r *Repository) notifyLockout(ctx context.Context, email string) {
+	userAccount, err := r.accountStore.GetByEmail(ctx, email)
+	if err != nil {
+		if !errors.Is(err, accountstore.ErrNotFound) {
+			log.Error().Err(err).Msg("acquiring account by email for lockout notification")
+		}
+		return
+	}
+
+	err = r.mailer.Send(ctx, mailer.Mail{
+		To:      []string{userAccount.GetProfile().Email},
+		Subject: "Your account has been temporarily locked",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\n"+
+				"There have been too many failed login attempts to your account, so logging in has been temporarily locked. "+
+				"You can try again later.\n\n"+
+				"If it was not you, somebody may be guessing your password. We recommend resetting your password from the login page.\n",
+			userAccount.GetProfile().Name,
+		),
+	})
+	if err != nil {
+		log.Error().Err(err).Int64("account_id", userAccount.GetProfile().ID).Msg("sending lockout notification")
+	}
+}
+
 func (r *Repository) Logout(ctx context.Context, token string) error {
-	err := r.accessSessionStore.Remove(ctx, token)
+	// The session is looked up before it's removed, only to know whose logout to record.
+	userAccount, err := r.accessSessionStore.Get(ctx, token)
+	if err != nil && !errors.Is(err, sessionstore.ErrEmptyToken) && !errors.Is(err, sessionstore.ErrSessionNotExists) {
+		return fmt.Errorf("acquiring session: %w", err)
+	}
+
+	err = r.accessSessionStore.Remove(ctx, token)
 	if err != nil {
 		return fmt.Errorf("removing session: %w", err)
 	}
 
+	if userAccount != nil {
+		r.securityEvents.Record(ctx, userAccount.GetProfile().ID, securityevent.TypeLogout, securityevent.OutcomeSuccess)
+	}
+
+	return nil
+}
+
+func (r *Repository) RevokeSessions(ctx context.Context, accountId int64, keepTokens ...string) error {
+	err := r.accessSessionStore.RemoveByAccount(ctx, accountId, keepTokens...)
+	if err != nil {
+		return fmt.Errorf("removing access sessions: %w", err)
+	}
+
+	err = r.refreshSessionStore.RemoveByAccount(ctx, accountId, keepTokens...)
+	if err != nil {
+		return fmt.Errorf("removing refresh sessions: %w", err)
+	}
+
+	err = r.mfaChallengeStore.RemoveByAccount(ctx, accountId)
+	if err != nil {
+		return fmt.Errorf("removing mfa challenges: %w", err)
+	}
+
 	return nil
 }
 
@@ -89,7 +298,7 @@ func (r *Repository) Refresh(ctx context.Context, refreshToken string) (accessTo
 		return "", time.Time{}, ErrInvalidAuthentication
 	}
 
-	userAccount, err := r.refreshSessionStore.Get(ctx, refreshToken)
+	sessionAccount, err := r.refreshSessionStore.Get(ctx, refreshToken)
 	if err != nil {
 		if errors.Is(err, sessionstore.ErrEmptyToken) || errors.Is(err, sessionstore.ErrSessionNotExists) {
 			return "", time.Time{}, ErrInvalidAuthentication
@@ -98,6 +307,16 @@ func (r *Repository) Refresh(ctx context.Context, refreshToken string) (accessTo
 		return "", time.Time{}, fmt.Errorf("acquiring session: %w", err)
 	}
 
+	// The refresh session holds a snapshot of the account from the login, which may be outdated by now.
+	userAccount, err := r.accountStore.GetByID(ctx, sessionAccount.GetProfile().ID)
+	if err != nil {
+		if errors.Is(err, accountstore.ErrNotFound) {
+			return "", time.Time{}, ErrInvalidAuthentication
+		}
+
+		return "", time.Time{}, fmt.Errorf("acquiring account by id: %w", err)
+	}
+
 	accessToken, _, err = r.jwt.Sign(userAccount.GetProfile().ID)
 	if err != nil {
 		return "", time.Time{}, fmt.Errorf("signing jsonwebtoken: %w", err)
@@ -109,23 +328,52 @@ func (r *Repository) Refresh(ctx context.Context, refreshToken string) (accessTo
 		return "", time.Time{}, fmt.Errorf("storing session on store: %w", err)
 	}
 
+	r.securityEvents.Record(account.WithActor(ctx, userAccount), userAccount.GetProfile().ID, securityevent.TypeRefresh, securityevent.OutcomeSuccess)
+
 	return accessToken, accessTokenExpiredAt, nil
 }
 
-func NewAuthenticationRepository(accessSessionStore sessionstore.SessionStore, refreshSessionStore sessionstore.SessionStore, accountStore accountstore.AccountStore) (AuthenticatorAndValidator, error) {
+// NewAuthenticationRepository creates the authenticator. mfaChallengeStore keeps the accounts that have
+// passed the password and are waiting for their second factor, it must not be shared with the access and
+// refresh session stores.
+func NewAuthenticationRepository(accessSessionStore sessionstore.SessionStore, refreshSessionStore sessionstore.SessionStore, mfaChallengeStore sessionstore.SessionStore, accountStore accountstore.AccountStore, secondFactor SecondFactor, jwt *jwt.AuthJwt, throttler *loginthrottle.Throttler, securityEvents *securityevent.Recorder, mailer mailer.Mailer) (AuthenticatorAndValidator, error) {
 	if accessSessionStore == nil {
 		return nil, fmt.Errorf("accessSessionStore is nil")
 	}
 	if refreshSessionStore == nil {
 		return nil, fmt.Errorf("refreshSessionStore is nil")
 	}
+	if mfaChallengeStore == nil {
+		return nil, fmt.Errorf("mfaChallengeStore is nil")
+	}
 	if accountStore == nil {
 		return nil, fmt.Errorf("accountStore is nil")
 	}
+	if secondFactor == nil {
+		return nil, fmt.Errorf("secondFactor is nil")
+	}
+	if jwt == nil {
+		return nil, fmt.Errorf("jwt is nil")
+	}
+	if throttler == nil {
+		return nil, fmt.Errorf("throttler is nil")
+	}
+	if securityEvents == nil {
+		return nil, fmt.Errorf("securityEvents is nil")
+	}
+	if mailer == nil {
+		return nil, fmt.Errorf("mailer is nil")
+	}
 
 	return &Repository{
 		accessSessionStore:  accessSessionStore,
 		refreshSessionStore: refreshSessionStore,
+		mfaChallengeStore:   mfaChallengeStore,
 		accountStore:        accountStore,
+		secondFactor:        secondFactor,
+		jwt:                 jwt,
+		throttler:           throttler,
+		securityEvents:      securityEvents,
+		mailer:              mailer,
 	}, nil
 }
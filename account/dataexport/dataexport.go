@@ -0,0 +1,97 @@
+package dataexport
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"coffee-chain-api/account"
+)
+
+const (
+	// SectionOrders is the archive section holding the user's order history.
+	SectionOrders = "orders"
+	// SectionPointsLedger is the archive section holding the user's loyalty points ledger.
+	SectionPointsLedger = "points_ledger"
+)
+
+// SectionProvider exports the user's data owned by a single domain, e.g. orders. The returned value
+// must be JSON serializable.
+type SectionProvider interface {
+	Export(ctx context.Context, userAccount account.Account) (any, error)
+}
+
+// Archive is a JSON archive of every data we hold about the user.
+type Archive struct {
+	ExportedAt              time.Time                       `json:"exported_at"`
+	Profile                 archiveProfile                  `json:"profile"`
+	NotificationPreferences account.NotificationPreferences `json:"notification_preferences"`
+	Orders                  any                             `json:"orders"`
+	PointsLedger            any                             `json:"points_ledger"`
+}
+
+type archiveProfile struct {
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	Gender string `json:"gender"`
+	Type   string `json:"type"`
+}
+
+type Exporter struct {
+	sections map[string]SectionProvider
+}
+
+// NewExporter creates an Exporter with the section providers, keyed by SectionOrders and
+// SectionPointsLedger. A section without a provider is exported as an empty list.
+func NewExporter(sections map[string]SectionProvider) (*Exporter, error) {
+	for name, provider := range sections {
+		if name != SectionOrders && name != SectionPointsLedger {
+			return nil, fmt.Errorf("unknown section %q", name)
+		}
+		if provider == nil {
+			return nil, fmt.Errorf("provider for %q section is nil", name)
+		}
+	}
+
+	return &Exporter{sections: sections}, nil
+}
+
+// Export builds the archive of the account. The account should be freshly acquired from
+// accountstore.AccountStore rather than from the session.
+func (e *Exporter) Export(ctx context.Context, userAccount account.Account) (Archive, error) {
+	profile := userAccount.GetProfile()
+
+	archive := Archive{
+		ExportedAt: time.Now().UTC(),
+		Profile: archiveProfile{
+			ID:     profile.ID,
+			Name:   profile.Name,
+			Email:  profile.Email,
+			Gender: profile.Gender.String(),
+			Type:   userAccount.GetType().String(),
+		},
+		NotificationPreferences: profile.NotificationPreferences,
+		Orders:                  []any{},
+		PointsLedger:            []any{},
+	}
+
+	for name, destination := range map[string]*any{
+		SectionOrders:       &archive.Orders,
+		SectionPointsLedger: &archive.PointsLedger,
+	} {
+		provider, ok := e.sections[name]
+		if !ok {
+			continue
+		}
+
+		data, err := provider.Export(ctx, userAccount)
+		if err != nil {
+			return Archive{}, fmt.Errorf("exporting %s section: %w", name, err)
+		}
+
+		*destination = data
+	}
+
+	return archive, nil
+}
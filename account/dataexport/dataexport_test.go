@@ -0,0 +1,80 @@
+package dataexport_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"coffee-chain-api/account"
+	"coffee-chain-api/account/dataexport"
+)
+
+type staticProvider struct {
+	data any
+}
+
+func (s staticProvider) Export(ctx context.Context, userAccount account.Account) (any, error) {
+	return s.data, nil
+}
+
+func TestExporter_Export(t *testing.T) {
+	userAccount := account.NewBasicAccount(account.Profile{
+		ID:                      1,
+		Name:                    "John",
+		Email:                   "john@example.com",
+		Gender:                  account.GenderMale,
+		NotificationPreferences: account.DefaultNotificationPreferences(),
+	}, account.TypeCustomer, 0)
+
+	t.Run("without providers", func(t *testing.T) {
+		exporter, err := dataexport.NewExporter(nil)
+		if err != nil {
+			t.Fatalf("initializing exporter: %s", err.Error())
+		}
+
+		archive, err := exporter.Export(context.Background(), userAccount)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err.Error())
+		}
+
+		out, err := json.Marshal(archive)
+		if err != nil {
+			t.Fatalf("marshaling archive: %s", err.Error())
+		}
+
+		for _, expect := range []string{`"orders":[]`, `"points_ledger":[]`, `"email":"john@example.com"`, `"gender":"Male"`, `"type":"Customer"`} {
+			if !strings.Contains(string(out), expect) {
+				t.Errorf("expecting %s in %s", expect, out)
+			}
+		}
+	})
+
+	t.Run("with providers", func(t *testing.T) {
+		exporter, err := dataexport.NewExporter(map[string]dataexport.SectionProvider{
+			dataexport.SectionOrders: staticProvider{data: []string{"order-1"}},
+		})
+		if err != nil {
+			t.Fatalf("initializing exporter: %s", err.Error())
+		}
+
+		archive, err := exporter.Export(context.Background(), userAccount)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err.Error())
+		}
+
+		orders, ok := archive.Orders.([]string)
+		if !ok || len(orders) != 1 {
+			t.Errorf("unexpected orders: %v", archive.Orders)
+		}
+	})
+
+	t.Run("unknown section", func(t *testing.T) {
+		_, err := dataexport.NewExporter(map[string]dataexport.SectionProvider{
+			"favourites": staticProvider{},
+		})
+		if err == nil {
+			t.Error("expecting an error, got nil")
+		}
+	})
+}
@@ -0,0 +1,25 @@
+package emailchange
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrInvalidCode indicates that there is no pending email change, it has expired, or the code does not match.
+var ErrInvalidCode = errors.New("invalid email change code")
+
+// ErrInvalidEmail indicates that the new email address is not a valid address.
+var ErrInvalidEmail = errors.New("invalid email address")
+
+// ErrEmailTaken indicates that the new email address already belongs to another account.
+var ErrEmailTaken = errors.New("email address is taken")
+
+// ErrSameEmail indicates that the new email address is the current one.
+var ErrSameEmail = errors.New("email address is unchanged")
+
+const (
+	// DefaultCodeLifetime is how long a verification code stays valid after it's issued.
+	DefaultCodeLifetime = time.Minute * 15
+	// MaximumAttempts is the number of wrong codes allowed before the pending email change is discarded.
+	MaximumAttempts = 5
+)
@@ -0,0 +1,342 @@
+package emailchange
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"coffee-chain-api/account"
+	"coffee-chain-api/account/accountstore"
+	"coffee-chain-api/account/authentication"
+	"coffee-chain-api/account/securityevent"
+	"coffee-chain-api/mailer"
+
+	"github.com/rs/zerolog/log"
+)
+
+type Repository struct {
+	db             *sql.DB
+	accountStore   accountstore.AccountStore
+	mailer         mailer.Mailer
+	authenticator  authentication.Authenticator
+	securityEvents *securityevent.Recorder
+	codeLifetime   time.Duration
+}
+
+func NewEmailChangeRepository(db *sql.DB, accountStore accountstore.AccountStore, mailer mailer.Mailer, authenticator authentication.Authenticator, securityEvents *securityevent.Recorder, codeLifetime time.Duration) (*Repository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db is nil")
+	}
+	if accountStore == nil {
+		return nil, fmt.Errorf("accountStore is nil")
+	}
+	if mailer == nil {
+		return nil, fmt.Errorf("mailer is nil")
+	}
+	if authenticator == nil {
+		return nil, fmt.Errorf("authenticator is nil")
+	}
+	if securityEvents == nil {
+		return nil, fmt.Errorf("securityEvents is nil")
+	}
+	if codeLifetime <= 0 {
+		codeLifetime = DefaultCodeLifetime
+	}
+
+	return &Repository{
+		db:             db,
+		accountStore:   accountStore,
+		mailer:         mailer,
+		authenticator:  authenticator,
+		securityEvents: securityEvents,
+		codeLifetime:   codeLifetime,
+	}, nil
+}
+
+// RequestChange stores newEmail as the account's pending email address, sends a verification code to it,
+// and notifies the current address. Any previous pending change of the account is discarded.
+func (r *Repository) RequestChange(ctx context.Context, userAccount account.Account, newEmail string) error {
+	address, err := mail.ParseAddress(newEmail)
+	if err != nil || address.Name != "" {
+		return ErrInvalidEmail
+	}
+	newEmail = address.Address
+
+	currentEmail := userAccount.GetProfile().Email
+	if strings.EqualFold(newEmail, currentEmail) {
+		return ErrSameEmail
+	}
+
+	_, err = r.accountStore.GetByEmail(ctx, newEmail)
+	if err == nil {
+		return ErrEmailTaken
+	}
+	if !errors.Is(err, accountstore.ErrNotFound) {
+		return fmt.Errorf("acquiring account by email: %w", err)
+	}
+
+	code, err := generateCode()
+	if err != nil {
+		return fmt.Errorf("generating code: %w", err)
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		return fmt.Errorf("creating transaction: %w", err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE
+			email_change_requests
+		SET
+			consumed_at = NOW()
+		WHERE
+			account_id = $1
+			AND consumed_at IS NULL`,
+		userAccount.GetProfile().ID,
+	)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		return fmt.Errorf("executing update query: %w", err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO
+			email_change_requests
+			(account_id,
+			 new_email,
+			 code_hash,
+			 expires_at,
+			 created_at
+			 )
+		VALUES
+			($1, $2, $3, $4, $5)`,
+		userAccount.GetProfile().ID,
+		newEmail,
+		hashCode(userAccount.GetProfile().ID, code),
+		time.Now().Add(r.codeLifetime),
+		time.Now(),
+	)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		return fmt.Errorf("executing insert query: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	r.securityEvents.Record(ctx, userAccount.GetProfile().ID, securityevent.TypeEmailChangeRequest, securityevent.OutcomeSuccess)
+
+	err = r.mailer.Send(ctx, mailer.Mail{
+		To:      []string{newEmail},
+		Subject: "Verify your new email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\n"+
+				"Use the code below to confirm %s as the new email address of your account:\n\n"+
+				"%s\n\n"+
+				"The code expires in %d minutes. If you did not request it, you can safely ignore this email.\n",
+			userAccount.GetProfile().Name,
+			newEmail,
+			code,
+			int(r.codeLifetime.Minutes()),
+		),
+	})
+	if err != nil {
+		return fmt.Errorf("sending verification email: %w", err)
+	}
+
+	err = r.mailer.Send(ctx, mailer.Mail{
+		To:      []string{currentEmail},
+		Subject: "Your email address is being changed",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\n"+
+				"Somebody requested to change the email address of your account to %s. The change only takes "+
+				"effect once it's confirmed from the new address.\n\n"+
+				"If it was not you, change your password immediately.\n",
+			userAccount.GetProfile().Name,
+			maskEmail(newEmail),
+		),
+	})
+	if err != nil {
+		return fmt.Errorf("sending notice email: %w", err)
+	}
+
+	return nil
+}
+
+// Confirm swaps the account's email address with the pending one if the code matches, then revokes every
+// session of the account, since the sessions hold the previous email address. It returns ErrInvalidCode if
+// the code is wrong, and ErrEmailTaken if the address has been taken in the meantime.
+func (r *Repository) Confirm(ctx context.Context, userAccount account.Account, code string) error {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		return fmt.Errorf("creating transaction: %w", err)
+	}
+
+	var requestId int64
+	var newEmail string
+	var codeHash string
+	var attempts int
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT
+				id,
+				new_email,
+				code_hash,
+				attempts
+			FROM
+				email_change_requests
+			WHERE
+				account_id = $1
+				AND consumed_at IS NULL
+				AND expires_at > NOW()
+			ORDER BY
+				created_at DESC
+			LIMIT 1
+			FOR UPDATE`,
+		userAccount.GetProfile().ID,
+	).Scan(&requestId, &newEmail, &codeHash, &attempts)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidCode
+		}
+
+		return fmt.Errorf("getting email change request: %w", err)
+	}
+
+	matched := subtle.ConstantTimeCompare([]byte(hashCode(userAccount.GetProfile().ID, code)), []byte(codeHash)) == 1
+	if matched || attempts+1 >= MaximumAttempts {
+		// Either way, the request can not be used anymore.
+		_, err = tx.ExecContext(
+			ctx,
+			`UPDATE email_change_requests SET attempts = attempts + 1, consumed_at = NOW() WHERE id = $1`,
+			requestId,
+		)
+	} else {
+		_, err = tx.ExecContext(
+			ctx,
+			`UPDATE email_change_requests SET attempts = attempts + 1 WHERE id = $1`,
+			requestId,
+		)
+	}
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		return fmt.Errorf("executing update query: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	if !matched {
+		r.securityEvents.Record(ctx, userAccount.GetProfile().ID, securityevent.TypeEmailChange, securityevent.OutcomeFailure)
+		return ErrInvalidCode
+	}
+
+	err = r.accountStore.UpdateEmail(ctx, userAccount, newEmail)
+	if err != nil {
+		if errors.Is(err, accountstore.ErrDuplicateEntry) {
+			return ErrEmailTaken
+		}
+
+		return fmt.Errorf("updating email: %w", err)
+	}
+
+	r.securityEvents.Record(ctx, userAccount.GetProfile().ID, securityevent.TypeEmailChange, securityevent.OutcomeSuccess)
+
+	err = r.authenticator.RevokeSessions(ctx, userAccount.GetProfile().ID)
+	if err != nil {
+		return fmt.Errorf("revoking sessions: %w", err)
+	}
+
+	return nil
+}
+
+// generateCode generates a random 6-digit numeric code.
+func generateCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// hashCode binds the code into the account, so the same code can not be used by another account.
+func hashCode(accountId int64, code string) string {
+	sum := sha256.Sum256([]byte(strconv.FormatInt(accountId, 10) + ":" + strings.TrimSpace(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+// maskEmail hides most of the local part of the email address, e.g. "jo***@example.com".
+func maskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return "***"
+	}
+
+	// Sliced by runes, so a multi-byte character is not cut in half.
+	runes := []rune(local)
+	visible := 2
+	if len(runes) <= visible {
+		visible = 1
+	}
+
+	return string(runes[:visible]) + "***@" + domain
+}
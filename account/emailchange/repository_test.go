@@ -0,0 +1,39 @@
+package emailchange
+
+import (
+	"testing"
+)
+
+func TestMaskEmail(t *testing.T) {
+	testCases := []struct {
+		input  string
+		expect string
+	}{
+		{input: "john@example.com", expect: "jo***@example.com"},
+		{input: "jo@example.com", expect: "j***@example.com"},
+		{input: "j@example.com", expect: "j***@example.com"},
+		{input: "not-an-email", expect: "***"},
+		{input: "éloïse@example.com", expect: "él***@example.com"},
+		{input: "éa@example.com", expect: "é***@example.com"},
+		{input: "@example.com", expect: "***"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.input, func(t *testing.T) {
+			out := maskEmail(tt.input)
+			if out != tt.expect {
+				t.Errorf("expecting %s, got %s instead", tt.expect, out)
+			}
+		})
+	}
+}
+
+func TestHashCode(t *testing.T) {
+	if hashCode(1, "123456") == hashCode(2, "123456") {
+		t.Error("expecting the same code of different accounts to have different hashes")
+	}
+
+	if hashCode(1, " 123456 ") != hashCode(1, "123456") {
+		t.Error("expecting surrounding whitespaces to be ignored")
+	}
+}
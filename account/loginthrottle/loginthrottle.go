@@ -0,0 +1,192 @@
+package loginthrottle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrThrottled indicates that there have been too many failed login attempts. Use errors.As with
+// *ThrottledError to acquire how long until the next attempt is allowed.
+var ErrThrottled = errors.New("too many failed login attempts")
+
+// ThrottledError is returned by Throttler.Check when the attempt must wait.
+type ThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (t *ThrottledError) Error() string {
+	return ErrThrottled.Error() + ", retry after " + t.RetryAfter.String()
+}
+
+func (t *ThrottledError) Is(target error) bool {
+	return target == ErrThrottled
+}
+
+// Attempts is the failed login history of a single key, such as an email address or an IP address.
+type Attempts struct {
+	Failures    int
+	LastFailure time.Time
+}
+
+// Rule decides how long a key must wait after its failed attempts.
+type Rule struct {
+	// FreeAttempts is the number of failures that are not delayed at all.
+	FreeAttempts int
+	// BaseDelay is the delay after the first failure beyond FreeAttempts. It's doubled for every
+	// subsequent failure, up to MaximumDelay.
+	BaseDelay    time.Duration
+	MaximumDelay time.Duration
+	// LockoutThreshold is the number of failures that locks the key out for LockoutDuration.
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+	// Window is how long failures are remembered since the last one. It should be longer than
+	// LockoutDuration, otherwise the failures are forgotten before the lockout ends.
+	Window time.Duration
+}
+
+var (
+	// DefaultAccountRule is lenient enough for someone who forgot their password, but stops guessing a
+	// single account's password.
+	DefaultAccountRule = Rule{
+		FreeAttempts:     3,
+		BaseDelay:        time.Second * 2,
+		MaximumDelay:     time.Minute,
+		LockoutThreshold: 10,
+		LockoutDuration:  time.Minute * 15,
+		Window:           time.Hour,
+	}
+	// DefaultIPRule allows a shared network to have plenty of failures, but stops trying a list of leaked
+	// credentials against many accounts from a single address.
+	DefaultIPRule = Rule{
+		FreeAttempts:     20,
+		BaseDelay:        time.Second,
+		MaximumDelay:     time.Minute,
+		LockoutThreshold: 100,
+		LockoutDuration:  time.Hour,
+		Window:           time.Hour * 2,
+	}
+)
+
+// RetryAfter returns how long until the next attempt is allowed, or zero if it's allowed right now.
+func (r Rule) RetryAfter(attempts Attempts, now time.Time) time.Duration {
+	if attempts.Failures == 0 || now.Sub(attempts.LastFailure) >= r.Window {
+		return 0
+	}
+
+	var wait time.Duration
+	switch {
+	case r.LockoutThreshold > 0 && attempts.Failures >= r.LockoutThreshold:
+		wait = r.LockoutDuration
+	case attempts.Failures > r.FreeAttempts:
+		wait = r.MaximumDelay
+		// Keep the shift small enough to not overflow, it's capped by MaximumDelay anyway.
+		if exponent := attempts.Failures - r.FreeAttempts - 1; exponent < 32 {
+			wait = min(r.BaseDelay<<exponent, r.MaximumDelay)
+		}
+	default:
+		return 0
+	}
+
+	return max(attempts.LastFailure.Add(wait).Sub(now), 0)
+}
+
+// Store keeps the failed login attempts. Like sessionstore.SessionStore, it can be implemented with any
+// backend, as long as it's shared by every instance of the application.
+type Store interface {
+	Get(ctx context.Context, key string) (Attempts, error)
+	// RecordFailure adds a failure to the key and returns the updated attempts. The previous failures are
+	// forgotten if the last one is older than window.
+	RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (Attempts, error)
+	Reset(ctx context.Context, key string) error
+}
+
+// Throttler applies a Rule to the failed attempts of every account, and another to the failed attempts
+// of every IP address.
+type Throttler struct {
+	store       Store
+	accountRule Rule
+	ipRule      Rule
+	now         func() time.Time
+}
+
+func NewThrottler(store Store, accountRule Rule, ipRule Rule) (*Throttler, error) {
+	if store == nil {
+		return nil, fmt.Errorf("store is nil")
+	}
+
+	return &Throttler{
+		store:       store,
+		accountRule: accountRule,
+		ipRule:      ipRule,
+		now:         time.Now,
+	}, nil
+}
+
+// Check returns *ThrottledError if a login attempt for the email address from the IP address must wait.
+// The email address does not have to belong to an account, so the response does not reveal whether it
+// does. An empty IP address is not throttled on.
+func (t *Throttler) Check(ctx context.Context, email string, ip string) error {
+	now := t.now()
+
+	attempts, err := t.store.Get(ctx, accountKey(email))
+	if err != nil {
+		return fmt.Errorf("acquiring account attempts: %w", err)
+	}
+	retryAfter := t.accountRule.RetryAfter(attempts, now)
+
+	if ip != "" {
+		attempts, err = t.store.Get(ctx, ipKey(ip))
+		if err != nil {
+			return fmt.Errorf("acquiring ip attempts: %w", err)
+		}
+		retryAfter = max(retryAfter, t.ipRule.RetryAfter(attempts, now))
+	}
+
+	if retryAfter > 0 {
+		return &ThrottledError{RetryAfter: retryAfter}
+	}
+
+	return nil
+}
+
+// Fail records a failed login attempt. lockedOut is true only for the failure that locks the email
+// address out, so the owner is notified once per lockout.
+func (t *Throttler) Fail(ctx context.Context, email string, ip string) (lockedOut bool, err error) {
+	now := t.now()
+
+	attempts, err := t.store.RecordFailure(ctx, accountKey(email), now, t.accountRule.Window)
+	if err != nil {
+		return false, fmt.Errorf("recording account failure: %w", err)
+	}
+
+	if ip != "" {
+		_, err = t.store.RecordFailure(ctx, ipKey(ip), now, t.ipRule.Window)
+		if err != nil {
+			return false, fmt.Errorf("recording ip failure: %w", err)
+		}
+	}
+
+	return t.accountRule.LockoutThreshold > 0 && attempts.Failures == t.accountRule.LockoutThreshold, nil
+}
+
+// Succeed forgets the failed attempts of the email address. The IP address's failures are kept, a single
+// valid credential should not clear the failures of the others tried from the same address.
+func (t *Throttler) Succeed(ctx context.Context, email string) error {
+	err := t.store.Reset(ctx, accountKey(email))
+	if err != nil {
+		return fmt.Errorf("resetting account attempts: %w", err)
+	}
+
+	return nil
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
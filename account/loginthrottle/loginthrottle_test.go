@@ -0,0 +1,189 @@
+package loginthrottle_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"coffee-chain-api/account/loginthrottle"
+)
+
+func TestRule_RetryAfter(t *testing.T) {
+	rule := loginthrottle.Rule{
+		FreeAttempts:     3,
+		BaseDelay:        time.Second * 2,
+		MaximumDelay:     time.Minute,
+		LockoutThreshold: 10,
+		LockoutDuration:  time.Minute * 15,
+		Window:           time.Hour,
+	}
+	now := time.Date(2024, 3, 8, 10, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		attempts loginthrottle.Attempts
+		expect   time.Duration
+	}{
+		{
+			name:     "no failures",
+			attempts: loginthrottle.Attempts{},
+			expect:   0,
+		},
+		{
+			name:     "free attempts",
+			attempts: loginthrottle.Attempts{Failures: 3, LastFailure: now},
+			expect:   0,
+		},
+		{
+			name:     "first delayed failure",
+			attempts: loginthrottle.Attempts{Failures: 4, LastFailure: now},
+			expect:   time.Second * 2,
+		},
+		{
+			name:     "delay doubles",
+			attempts: loginthrottle.Attempts{Failures: 6, LastFailure: now},
+			expect:   time.Second * 8,
+		},
+		{
+			name:     "delay is capped",
+			attempts: loginthrottle.Attempts{Failures: 9, LastFailure: now},
+			expect:   time.Minute,
+		},
+		{
+			name:     "delay has partially passed",
+			attempts: loginthrottle.Attempts{Failures: 5, LastFailure: now.Add(-time.Second * 3)},
+			expect:   time.Second,
+		},
+		{
+			name:     "delay has passed",
+			attempts: loginthrottle.Attempts{Failures: 5, LastFailure: now.Add(-time.Second * 5)},
+			expect:   0,
+		},
+		{
+			name:     "locked out",
+			attempts: loginthrottle.Attempts{Failures: 10, LastFailure: now.Add(-time.Minute * 5)},
+			expect:   time.Minute * 10,
+		},
+		{
+			name:     "lockout has passed",
+			attempts: loginthrottle.Attempts{Failures: 12, LastFailure: now.Add(-time.Minute * 20)},
+			expect:   0,
+		},
+		{
+			name:     "failures are forgotten after the window",
+			attempts: loginthrottle.Attempts{Failures: 10, LastFailure: now.Add(-time.Hour)},
+			expect:   0,
+		},
+		{
+			name:     "many failures do not overflow",
+			attempts: loginthrottle.Attempts{Failures: 1000, LastFailure: now},
+			expect:   time.Minute * 15,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rule.RetryAfter(tt.attempts, now)
+			if got != tt.expect {
+				t.Errorf("expecting %s, got %s instead", tt.expect, got)
+			}
+		})
+	}
+
+	t.Run("without lockout", func(t *testing.T) {
+		withoutLockout := rule
+		withoutLockout.LockoutThreshold = 0
+
+		got := withoutLockout.RetryAfter(loginthrottle.Attempts{Failures: 100, LastFailure: now}, now)
+		if got != time.Minute {
+			t.Errorf("expecting %s, got %s instead", time.Minute, got)
+		}
+	})
+}
+
+func TestThrottler(t *testing.T) {
+	store, err := loginthrottle.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("creating memory store: %s", err.Error())
+	}
+
+	accountRule := loginthrottle.Rule{
+		FreeAttempts:     2,
+		BaseDelay:        time.Minute,
+		MaximumDelay:     time.Minute,
+		LockoutThreshold: 3,
+		LockoutDuration:  time.Minute * 15,
+		Window:           time.Hour,
+	}
+	ipRule := loginthrottle.Rule{
+		FreeAttempts: 4,
+		BaseDelay:    time.Minute,
+		MaximumDelay: time.Minute,
+		Window:       time.Hour,
+	}
+
+	throttler, err := loginthrottle.NewThrottler(store, accountRule, ipRule)
+	if err != nil {
+		t.Fatalf("creating throttler: %s", err.Error())
+	}
+
+	ctx := context.Background()
+
+	for i := 1; i <= 3; i++ {
+		err = throttler.Check(ctx, "Someone@example.com", "192.0.2.1")
+		if err != nil {
+			t.Fatalf("attempt %d: expecting no error, got %s", i, err.Error())
+		}
+
+		lockedOut, err := throttler.Fail(ctx, "someone@example.com", "192.0.2.1")
+		if err != nil {
+			t.Fatalf("attempt %d: unexpected error: %s", i, err.Error())
+		}
+		if lockedOut != (i == 3) {
+			t.Errorf("attempt %d: expecting locked out to be %t, got %t", i, i == 3, lockedOut)
+		}
+	}
+
+	var throttledError *loginthrottle.ThrottledError
+	err = throttler.Check(ctx, "someone@example.com", "198.51.100.1")
+	if !errors.As(err, &throttledError) {
+		t.Fatalf("expecting *loginthrottle.ThrottledError, got %v", err)
+	}
+	if !errors.Is(err, loginthrottle.ErrThrottled) {
+		t.Error("expecting error to be loginthrottle.ErrThrottled")
+	}
+	if throttledError.RetryAfter <= time.Minute*14 {
+		t.Errorf("expecting the lockout duration, got %s", throttledError.RetryAfter)
+	}
+
+	// The email addresses are throttled separately, but the IP address is shared.
+	err = throttler.Check(ctx, "another@example.com", "192.0.2.1")
+	if err != nil {
+		t.Fatalf("expecting no error, got %s", err.Error())
+	}
+
+	_, err = throttler.Fail(ctx, "another@example.com", "192.0.2.1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	_, err = throttler.Fail(ctx, "yet-another@example.com", "192.0.2.1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	err = throttler.Check(ctx, "fresh@example.com", "192.0.2.1")
+	if !errors.Is(err, loginthrottle.ErrThrottled) {
+		t.Errorf("expecting the ip address to be throttled, got %v", err)
+	}
+
+	err = throttler.Succeed(ctx, "someone@example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	err = throttler.Check(ctx, "someone@example.com", "")
+	if err != nil {
+		t.Errorf("expecting the account to be reset, got %s", err.Error())
+	}
+}
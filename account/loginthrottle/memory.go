@@ -0,0 +1,60 @@
+package loginthrottle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryStore struct {
+	mu       sync.Mutex
+	attempts map[string]Attempts
+}
+
+func (m *memoryStore) Get(ctx context.Context, key string) (Attempts, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.attempts[key], nil
+}
+
+func (m *memoryStore) RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (Attempts, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	attempts := m.attempts[key]
+	if now.Sub(attempts.LastFailure) >= window {
+		attempts = Attempts{}
+	}
+	attempts.Failures++
+	attempts.LastFailure = now
+	m.attempts[key] = attempts
+
+	time.AfterFunc(window, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		// Only forget the attempts if there has not been another failure since.
+		if current, ok := m.attempts[key]; ok && current.LastFailure.Equal(now) {
+			delete(m.attempts, key)
+		}
+	})
+
+	return attempts, nil
+}
+
+func (m *memoryStore) Reset(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.attempts, key)
+	return nil
+}
+
+// NewMemoryStore implements Store that saves the attempts in-memory. Like the in-memory session store,
+// it's only feasible for a single instance, as every instance would have its own attempts.
+func NewMemoryStore() (Store, error) {
+	return &memoryStore{
+		attempts: make(map[string]Attempts),
+	}, nil
+}
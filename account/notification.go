@@ -0,0 +1,79 @@
+package account
+
+type NotificationChannel uint8
+
+const (
+	NotificationChannelPush NotificationChannel = iota
+	NotificationChannelEmail
+)
+
+func (n NotificationChannel) String() string {
+	switch n {
+	case NotificationChannelPush:
+		return "Push"
+	case NotificationChannelEmail:
+		return "Email"
+	default:
+		return ""
+	}
+}
+
+type NotificationCategory uint8
+
+const (
+	NotificationCategoryPromotional NotificationCategory = iota
+	NotificationCategoryTransactional
+)
+
+func (n NotificationCategory) String() string {
+	switch n {
+	case NotificationCategoryPromotional:
+		return "Promotional"
+	case NotificationCategoryTransactional:
+		return "Transactional"
+	default:
+		return ""
+	}
+}
+
+// NotificationCategories holds the opt-in of each notification category for a single channel.
+type NotificationCategories struct {
+	Promotional   bool `json:"promotional"`
+	Transactional bool `json:"transactional"`
+}
+
+// NotificationPreferences holds the user's notification opt-in per channel, per category.
+type NotificationPreferences struct {
+	Push  NotificationCategories `json:"push"`
+	Email NotificationCategories `json:"email"`
+}
+
+// DefaultNotificationPreferences opts in to transactional notifications, and out of promotional ones.
+func DefaultNotificationPreferences() NotificationPreferences {
+	return NotificationPreferences{
+		Push:  NotificationCategories{Promotional: false, Transactional: true},
+		Email: NotificationCategories{Promotional: false, Transactional: true},
+	}
+}
+
+// Allows tells whether the user has opted in to the notification category on the channel.
+func (n NotificationPreferences) Allows(channel NotificationChannel, category NotificationCategory) bool {
+	var categories NotificationCategories
+	switch channel {
+	case NotificationChannelPush:
+		categories = n.Push
+	case NotificationChannelEmail:
+		categories = n.Email
+	default:
+		return false
+	}
+
+	switch category {
+	case NotificationCategoryPromotional:
+		return categories.Promotional
+	case NotificationCategoryTransactional:
+		return categories.Transactional
+	default:
+		return false
+	}
+}
@@ -0,0 +1,61 @@
+package account_test
+
+import (
+	"strconv"
+	"testing"
+
+	"coffee-chain-api/account"
+)
+
+func TestNotificationPreferences_Allows(t *testing.T) {
+	preferences := account.NotificationPreferences{
+		Push:  account.NotificationCategories{Promotional: true, Transactional: false},
+		Email: account.NotificationCategories{Promotional: false, Transactional: true},
+	}
+
+	testCases := []struct {
+		channel  account.NotificationChannel
+		category account.NotificationCategory
+		expect   bool
+	}{
+		{
+			channel:  account.NotificationChannelPush,
+			category: account.NotificationCategoryPromotional,
+			expect:   true,
+		},
+		{
+			channel:  account.NotificationChannelPush,
+			category: account.NotificationCategoryTransactional,
+			expect:   false,
+		},
+		{
+			channel:  account.NotificationChannelEmail,
+			category: account.NotificationCategoryPromotional,
+			expect:   false,
+		},
+		{
+			channel:  account.NotificationChannelEmail,
+			category: account.NotificationCategoryTransactional,
+			expect:   true,
+		},
+		{
+			channel:  account.NotificationChannel(42),
+			category: account.NotificationCategoryPromotional,
+			expect:   false,
+		},
+		{
+			channel:  account.NotificationChannelPush,
+			category: account.NotificationCategory(42),
+			expect:   false,
+		},
+	}
+
+	for i, tt := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			out := preferences.Allows(tt.channel, tt.category)
+			if out != tt.expect {
+				t.Errorf("expecting %t, got %t instead", tt.expect, out)
+			}
+		})
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
-	"strconv"
 	"strings"
 
 	"golang.org/x/crypto/argon2"
@@ -73,6 +72,16 @@ const (
 	Argon2DefaultVariant = Argon2IDVariant
 	// Argon2DefaultSaltLength is the default salt length in bytes.
 	Argon2DefaultSaltLength = 32
+	// Argon2MaximumTime is the maximum number of iterations accepted when verifying a hash.
+	Argon2MaximumTime = 256
+	// Argon2MaximumMemory is the maximum amount of memory (in kilobytes) accepted when verifying a hash.
+	// Anything above it is considered malformed rather than letting a single login exhaust the memory.
+	Argon2MaximumMemory = 1024 * 1024
+)
+
+const (
+	argon2MaximumKeyLength  = 1024
+	argon2MaximumSaltLength = 1024
 )
 
 // Hash creates a PHC-formatted hash with config provided
@@ -149,39 +158,45 @@ func (a *argon2Hasher) Verify(ctx context.Context, plainPassword string, hashedP
 		return false, ErrEmptyToken
 	}
 
-	deserialized := deserialize(hashedPassword)
+	deserialized, err := deserialize(hashedPassword)
+	if err != nil {
+		return false, err
+	}
 	if !strings.HasPrefix(deserialized.ID, "argon2") {
 		return false, ErrUnexpectedHasherInstance
 	}
+	if deserialized.ID != "argon2id" && deserialized.ID != "argon2i" {
+		return false, ErrUnexpectedHasherInstance
+	}
 
-	var verifyHash []byte
-	decodedHash, err := hex.DecodeString(deserialized.Hash)
+	decodedHash, err := hexField("hash", deserialized.Hash, 4, argon2MaximumKeyLength)
 	if err != nil {
 		return false, err
 	}
 	keyLen := uint32(len(decodedHash))
 
-	time, err := strconv.ParseUint(deserialized.Params["t"].(string), 10, 32)
+	time, err := deserialized.uintParam("t", 1, Argon2MaximumTime)
 	if err != nil {
 		return false, err
 	}
-	memory, err := strconv.ParseUint(deserialized.Params["m"].(string), 10, 32)
+	memory, err := deserialized.uintParam("m", 1, Argon2MaximumMemory)
 	if err != nil {
 		return false, err
 	}
-	parallelism, err := strconv.ParseUint(deserialized.Params["p"].(string), 10, 32)
+	parallelism, err := deserialized.uintParam("p", 1, 255)
 	if err != nil {
 		return false, err
 	}
 
-	salt, err := hex.DecodeString(deserialized.Salt)
+	salt, err := hexField("salt", deserialized.Salt, 1, argon2MaximumSaltLength)
 	if err != nil {
 		return false, err
 	}
 
+	var verifyHash []byte
 	if deserialized.ID == "argon2id" {
 		verifyHash = argon2.IDKey([]byte(plainPassword), salt, uint32(time), uint32(memory), uint8(parallelism), keyLen)
-	} else if deserialized.ID == "argon2i" {
+	} else {
 		verifyHash = argon2.Key([]byte(plainPassword), salt, uint32(time), uint32(memory), uint8(parallelism), keyLen)
 	}
 
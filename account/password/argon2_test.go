@@ -179,3 +179,32 @@ func TestArgon2Hasher_Error(t *testing.T) {
 		}
 	})
 }
+
+func TestArgon2Hasher_Malformed(t *testing.T) {
+	hasher, err := password.NewArgonPasswordHasher(password.Argon2Config{})
+	if err != nil {
+		t.Fatalf("initializing argon2 hasher: %s", err.Error())
+	}
+
+	ctx := context.Background()
+
+	testCases := []string{
+		"$argon2id",
+		"$argon2id$v=19$m=65536,t=16,p=4$9336bb54e8f5532cc1f3050262d90b8d",
+		"$argon2id$v=19$t=16,p=4$9336bb54e8f5532cc1f3050262d90b8d$c9e0d744acafe5ddcb844942a75b86d5",
+		"$argon2id$v=19$m=65536,t=16,p=4,x$9336bb54e8f5532cc1f3050262d90b8d$c9e0d744acafe5ddcb844942a75b86d5",
+		"$argon2id$v=19$m=4294967295,t=16,p=4$9336bb54e8f5532cc1f3050262d90b8d$c9e0d744acafe5ddcb844942a75b86d5",
+		"$argon2id$v=19$m=65536,t=100000,p=4$9336bb54e8f5532cc1f3050262d90b8d$c9e0d744acafe5ddcb844942a75b86d5",
+		"$argon2id$v=19$m=65536,t=16,p=0$9336bb54e8f5532cc1f3050262d90b8d$c9e0d744acafe5ddcb844942a75b86d5",
+		"$argon2id$v=19$m=65536,t=16,p=256$9336bb54e8f5532cc1f3050262d90b8d$c9e0d744acafe5ddcb844942a75b86d5",
+		"$argon2id$v=19$m=65536,t=16,p=4$$c9e0d744acafe5ddcb844942a75b86d5",
+		"$argon2id$v=19$m=65536,t=16,p=4$9336bb54e8f5532cc1f3050262d90b8d$",
+	}
+
+	for _, hashString := range testCases {
+		_, err := hasher.Verify(ctx, "something", hashString)
+		if !errors.Is(err, password.ErrMalformedHash) {
+			t.Errorf("expecting ErrMalformedHash for %q, got %v", hashString, err)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package password
 import (
 	"context"
 	"encoding/hex"
+	"errors"
+	"fmt"
 	"strings"
 
 	"golang.org/x/crypto/bcrypt"
@@ -16,6 +18,9 @@ func NewBcryptPasswordHasher(rounds int) (Hasher, error) {
 	if rounds <= 0 {
 		rounds = BcryptDefaultRounds
 	}
+	if rounds > BcryptMaximumRounds {
+		return nil, fmt.Errorf("rounds must not exceed %d", BcryptMaximumRounds)
+	}
 
 	return &bcryptHasher{Rounds: rounds}, nil
 }
@@ -23,8 +28,13 @@ func NewBcryptPasswordHasher(rounds int) (Hasher, error) {
 const (
 	// BcryptDefaultRounds is the cost of rounds, minimum of 4, maximum of 31.
 	BcryptDefaultRounds = 10
+	// BcryptMaximumRounds is the maximum cost of rounds accepted when verifying a hash. Although bcrypt
+	// allows up to 31, anything above it takes too long for a login request.
+	BcryptMaximumRounds = 20
 )
 
+const bcryptMaximumHashLength = 128
+
 // Hash creates a PHC-formatted hash with config provided
 //
 //	import (
@@ -83,17 +93,40 @@ func (b *bcryptHasher) Verify(ctx context.Context, plainPassword string, hashedP
 		return false, ErrEmptyToken
 	}
 
-	deserialized := deserialize(hashedPassword)
+	deserialized, err := deserialize(hashedPassword)
+	if err != nil {
+		return false, err
+	}
 	if !strings.HasPrefix(deserialized.ID, "bcrypt") {
 		return false, ErrUnexpectedHasherInstance
 	}
-	decodedHash, err := hex.DecodeString(deserialized.Hash)
+
+	_, err = deserialized.uintParam("r", uint64(bcrypt.MinCost), BcryptMaximumRounds)
+	if err != nil {
+		return false, err
+	}
+
+	decodedHash, err := hexField("hash", deserialized.Hash, 1, bcryptMaximumHashLength)
 	if err != nil {
 		return false, err
 	}
+
+	// The cost is also stored in the bcrypt hash itself, which is the one actually being used.
+	cost, err := bcrypt.Cost(decodedHash)
+	if err != nil {
+		return false, &MalformedHashError{Reason: "invalid bcrypt hash"}
+	}
+	if cost > BcryptMaximumRounds {
+		return false, &MalformedHashError{Reason: "parameter \"r\" is out of range"}
+	}
+
 	err = bcrypt.CompareHashAndPassword(decodedHash, []byte(plainPassword))
 	if err != nil {
-		return false, nil
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+
+		return false, &MalformedHashError{Reason: "invalid bcrypt hash"}
 	}
 	return true, nil
 }
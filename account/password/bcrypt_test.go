@@ -119,3 +119,35 @@ func TestBcryptHasher_Error(t *testing.T) {
 		}
 	})
 }
+
+func TestBcryptHasher_Malformed(t *testing.T) {
+	hasher, err := password.NewBcryptPasswordHasher(10)
+	if err != nil {
+		t.Fatalf("initializing bcrypt hasher: %s", err.Error())
+	}
+
+	ctx := context.Background()
+
+	testCases := []string{
+		"$bcrypt$v=0",
+		"$bcrypt$v=0$r=10$$",
+		"$bcrypt$v=0$$$2432612431302478",
+		"$bcrypt$v=0$r=64$$2432612431302478",
+		"$bcrypt$v=0$r=10$$2432612431302478",
+		"$bcrypt$v=0$r=10$$zz",
+	}
+
+	for _, hashString := range testCases {
+		_, err := hasher.Verify(ctx, "something", hashString)
+		if !errors.Is(err, password.ErrMalformedHash) {
+			t.Errorf("expecting ErrMalformedHash for %q, got %v", hashString, err)
+		}
+	}
+
+	t.Run("should refuse absurd rounds", func(t *testing.T) {
+		_, err := password.NewBcryptPasswordHasher(31)
+		if err == nil {
+			t.Error("error should have been thrown")
+		}
+	})
+}
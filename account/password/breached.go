@@ -0,0 +1,145 @@
+package password
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// BreachedPasswordChecker checks whether a password has appeared in a known data breach.
+type BreachedPasswordChecker interface {
+	IsBreached(ctx context.Context, plainPassword string) (bool, error)
+}
+
+type breachedPasswordFile struct {
+	path      string
+	prefixLen int
+}
+
+// NewBreachedPasswordFile implements BreachedPasswordChecker on top of a local file of SHA-1 hashes in the
+// format of the Have I Been Pwned "ordered by hash" downloads: one uppercase hexadecimal hash per line,
+// optionally followed by a colon and the occurrence count, sorted ascending.
+//
+// To save some disk space, the hashes may be truncated into a prefix, as long as every line shares the
+// same prefix length. The file is never loaded into memory, lookups are done with a binary search.
+func NewBreachedPasswordFile(path string) (BreachedPasswordChecker, error) {
+	if path == "" {
+		return nil, fmt.Errorf("path is empty")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening breached password file: %w", err)
+	}
+	defer func() {
+		err := file.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing breached password file")
+		}
+	}()
+
+	firstLine, err := bufio.NewReader(file).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("reading breached password file: %w", err)
+	}
+
+	prefix := breachedLineHash(firstLine)
+	if len(prefix) == 0 || len(prefix) > sha1.Size*2 {
+		return nil, fmt.Errorf("breached password file does not start with a SHA-1 hash")
+	}
+
+	return &breachedPasswordFile{path: path, prefixLen: len(prefix)}, nil
+}
+
+func (b *breachedPasswordFile) IsBreached(ctx context.Context, plainPassword string) (bool, error) {
+	sum := sha1.Sum([]byte(plainPassword))
+	target := strings.ToUpper(hex.EncodeToString(sum[:]))[:b.prefixLen]
+
+	file, err := os.Open(b.path)
+	if err != nil {
+		return false, fmt.Errorf("opening breached password file: %w", err)
+	}
+	defer func() {
+		err := file.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing breached password file")
+		}
+	}()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return false, fmt.Errorf("acquiring breached password file stat: %w", err)
+	}
+
+	// Binary search over byte offsets. Every step looks at the first line starting at or after the middle
+	// offset, which narrows down the byte range the target line may start in.
+	low, high := int64(0), stat.Size()
+	for low < high {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+
+		middle := low + (high-low)/2
+		lineStart, line, err := breachedLineAfter(file, middle)
+		if err != nil {
+			return false, fmt.Errorf("reading breached password file: %w", err)
+		}
+		if lineStart < 0 {
+			high = middle
+			continue
+		}
+
+		switch hash := breachedLineHash(line); {
+		case hash == target:
+			return true, nil
+		case hash < target:
+			low = lineStart + int64(len(line))
+		default:
+			high = middle
+		}
+	}
+
+	return false, nil
+}
+
+// breachedLineAfter returns the first line (including its line feed) that starts at or after offset.
+// lineStart is negative if there is no such line.
+func breachedLineAfter(file io.ReaderAt, offset int64) (lineStart int64, line string, err error) {
+	lineStart = offset
+	if offset > 0 {
+		// Start from the previous byte, in case offset points exactly to the start of a line.
+		reader := bufio.NewReader(io.NewSectionReader(file, offset-1, 1<<62))
+		skipped, err := reader.ReadString('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return -1, "", nil
+			}
+			return 0, "", err
+		}
+		lineStart = offset - 1 + int64(len(skipped))
+	}
+
+	reader := bufio.NewReader(io.NewSectionReader(file, lineStart, 1<<62))
+	line, err = reader.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, "", err
+	}
+	if line == "" {
+		return -1, "", nil
+	}
+
+	return lineStart, line, nil
+}
+
+func breachedLineHash(line string) string {
+	hash, _, _ := strings.Cut(strings.TrimSpace(line), ":")
+	return strings.ToUpper(hash)
+}
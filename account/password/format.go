@@ -1,6 +1,7 @@
 package password
 
 import (
+	"encoding/hex"
 	"strconv"
 	"strings"
 )
@@ -30,25 +31,87 @@ func serialize(config phcConfig) string {
 	return "$" + config.ID + "$v=" + strconv.Itoa(config.Version) + "$" + strings.Join(params, ",") + "$" + config.Salt + "$" + config.Hash
 }
 
-// deserialize converts a PHC string into a PHCConfig struct
-func deserialize(hash string) phcConfig {
+// deserialize converts a PHC string into a PHCConfig struct. It returns *MalformedHashError if the
+// string does not follow the PHC string format, without trusting any of the input.
+func deserialize(hash string) (phcConfig, error) {
+	if !strings.HasPrefix(hash, "$") {
+		return phcConfig{}, &MalformedHashError{Reason: "missing leading separator"}
+	}
+
 	hashArray := strings.Split(hash, "$")
-	params := make(map[string]interface{})
+	if len(hashArray) != 6 {
+		return phcConfig{}, &MalformedHashError{Reason: "unexpected number of sections"}
+	}
+
+	if hashArray[1] == "" {
+		return phcConfig{}, &MalformedHashError{Reason: "empty identifier"}
+	}
 
+	rawVersion, ok := strings.CutPrefix(hashArray[2], "v=")
+	if !ok {
+		return phcConfig{}, &MalformedHashError{Reason: "missing version"}
+	}
+	version, err := strconv.Atoi(rawVersion)
+	if err != nil || version < 0 {
+		return phcConfig{}, &MalformedHashError{Reason: "invalid version"}
+	}
+
+	params := make(map[string]interface{})
 	if len(hashArray[3]) != 0 {
 		paramsArray := strings.Split(hashArray[3], ",")
 		for _, value := range paramsArray {
-			pair := strings.Split(value, "=")
-			params[pair[0]] = pair[1]
+			key, paramValue, ok := strings.Cut(value, "=")
+			if !ok || key == "" || strings.Contains(paramValue, "=") {
+				return phcConfig{}, &MalformedHashError{Reason: "invalid parameter " + strconv.Quote(value)}
+			}
+			if _, exists := params[key]; exists {
+				return phcConfig{}, &MalformedHashError{Reason: "duplicate parameter " + strconv.Quote(key)}
+			}
+
+			params[key] = paramValue
 		}
 	}
 
-	version, _ := strconv.Atoi(strings.Replace(hashArray[2], "v=", "", 1))
 	return phcConfig{
 		ID:      hashArray[1],
 		Version: version,
 		Params:  params,
 		Salt:    hashArray[4],
 		Hash:    hashArray[5],
+	}, nil
+}
+
+// uintParam acquires a numeric parameter of a deserialized PHC string, and makes sure it's within the
+// inclusive range of minimum and maximum.
+func (p phcConfig) uintParam(key string, minimum uint64, maximum uint64) (uint64, error) {
+	raw, ok := p.Params[key].(string)
+	if !ok {
+		return 0, &MalformedHashError{Reason: "missing parameter " + strconv.Quote(key)}
+	}
+
+	value, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, &MalformedHashError{Reason: "invalid parameter " + strconv.Quote(key)}
+	}
+
+	if value < minimum || value > maximum {
+		return 0, &MalformedHashError{Reason: "parameter " + strconv.Quote(key) + " is out of range"}
+	}
+
+	return value, nil
+}
+
+// hexField decodes a hex encoded section of a deserialized PHC string, and makes sure the decoded length
+// is within the inclusive range of minimum and maximum.
+func hexField(name string, value string, minimum int, maximum int) ([]byte, error) {
+	decoded, err := hex.DecodeString(value)
+	if err != nil {
+		return nil, &MalformedHashError{Reason: "invalid " + name + " encoding"}
 	}
+
+	if len(decoded) < minimum || len(decoded) > maximum {
+		return nil, &MalformedHashError{Reason: name + " length is out of range"}
+	}
+
+	return decoded, nil
 }
@@ -1,6 +1,8 @@
 package password
 
 import (
+	"errors"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -26,7 +28,10 @@ func TestSerialize(t *testing.T) {
 }
 
 func TestDeserialize(t *testing.T) {
-	deserialized := deserialize("$argon2id$v=2$Something=New,Somewhere=Far,Meaning=42$SaltyText$HashyText")
+	deserialized, err := deserialize("$argon2id$v=2$Something=New,Somewhere=Far,Meaning=42$SaltyText$HashyText")
+	if err != nil {
+		t.Fatal("Unexpected error: ", err)
+	}
 
 	if deserialized.ID != "argon2id" {
 		t.Error("Unexpected Argon2IDVariant: ", deserialized.ID)
@@ -48,3 +53,82 @@ func TestDeserialize(t *testing.T) {
 		t.Error("Unexpected Params: ", deserialized.Params)
 	}
 }
+
+func TestDeserialize_Malformed(t *testing.T) {
+	testCases := []string{
+		"",
+		"$",
+		"argon2id$v=19$m=65536,t=16,p=4$73616c74$68617368",
+		"$argon2id",
+		"$argon2id$v=19",
+		"$argon2id$v=19$m=65536,t=16,p=4",
+		"$argon2id$v=19$m=65536,t=16,p=4$73616c74",
+		"$argon2id$v=19$m=65536,t=16,p=4$73616c74$68617368$extra",
+		"$$v=19$m=65536$73616c74$68617368",
+		"$argon2id$19$m=65536$73616c74$68617368",
+		"$argon2id$v=abc$m=65536$73616c74$68617368",
+		"$argon2id$v=-1$m=65536$73616c74$68617368",
+		"$argon2id$v=19$m$73616c74$68617368",
+		"$argon2id$v=19$=65536$73616c74$68617368",
+		"$argon2id$v=19$m=1=2$73616c74$68617368",
+		"$argon2id$v=19$m=1,m=2$73616c74$68617368",
+		"$argon2id$v=19$m=1,,t=2$73616c74$68617368",
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt, func(t *testing.T) {
+			_, err := deserialize(tt)
+			if !errors.Is(err, ErrMalformedHash) {
+				t.Errorf("expecting ErrMalformedHash, got %v", err)
+			}
+
+			var malformedHashError *MalformedHashError
+			if !errors.As(err, &malformedHashError) || malformedHashError.Reason == "" {
+				t.Errorf("expecting *MalformedHashError with a reason, got %v", err)
+			}
+		})
+	}
+}
+
+func FuzzDeserialize(f *testing.F) {
+	f.Add("$argon2id$v=19$m=65536,t=16,p=4$73616c74$68617368")
+	f.Add("$bcrypt$v=0$r=10$$2432612431302478")
+	f.Add("$pbkdf2sha256$v=0$i=4096$73616c74$68617368")
+	f.Add("$argon2id$v=19$$$")
+	f.Add("$a$v=1$k=v,k2=v2$s$h")
+
+	f.Fuzz(func(t *testing.T, hash string) {
+		deserialized, err := deserialize(hash)
+		if err != nil {
+			if !errors.Is(err, ErrMalformedHash) {
+				t.Fatalf("expecting ErrMalformedHash, got %v", err)
+			}
+			return
+		}
+
+		// Anything that parses must survive a round trip, otherwise we're accepting garbage.
+		params := make(map[string]interface{}, len(deserialized.Params))
+		for key, value := range deserialized.Params {
+			params[key] = value
+		}
+		roundTrip, err := deserialize(serialize(phcConfig{
+			ID:      deserialized.ID,
+			Version: deserialized.Version,
+			Params:  params,
+			Salt:    deserialized.Salt,
+			Hash:    deserialized.Hash,
+		}))
+		if err != nil {
+			t.Fatalf("unexpected error on round trip of %q: %v", hash, err)
+		}
+		if !reflect.DeepEqual(roundTrip, deserialized) {
+			t.Fatalf("round trip mismatch: %#v != %#v", roundTrip, deserialized)
+		}
+
+		for _, key := range []string{"m", "t", "p", "i", "r"} {
+			_, _ = deserialized.uintParam(key, 1, 1024)
+		}
+		_, _ = hexField("salt", deserialized.Salt, 1, 1024)
+		_, _ = hexField("hash", deserialized.Hash, 1, 1024)
+	})
+}
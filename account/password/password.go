@@ -8,6 +8,23 @@ import (
 var ErrEmptyToken = errors.New("string token is empty")
 var ErrUnexpectedHasherInstance = errors.New("unexpected hasher instance")
 
+// ErrMalformedHash indicates that the stored hashed password can not be parsed, or has parameters that are
+// out of the acceptable range. Use errors.As with *MalformedHashError to acquire the reason.
+var ErrMalformedHash = errors.New("malformed hash")
+
+// MalformedHashError is returned by Hasher.Verify when the hashed password is malformed.
+type MalformedHashError struct {
+	Reason string
+}
+
+func (m *MalformedHashError) Error() string {
+	return ErrMalformedHash.Error() + ": " + m.Reason
+}
+
+func (m *MalformedHashError) Is(target error) bool {
+	return target == ErrMalformedHash
+}
+
 type Hasher interface {
 	Hash(ctx context.Context, plainPassword string) (string, error)
 	Verify(ctx context.Context, plainPassword string, hashedPassword string) (bool, error)
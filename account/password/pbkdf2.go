@@ -10,7 +10,6 @@ import (
 	"crypto/subtle"
 	"encoding/hex"
 	"io"
-	"strconv"
 	"strings"
 
 	"golang.org/x/crypto/pbkdf2"
@@ -54,6 +53,13 @@ const (
 	Pbdkf2DefaultHashFunction = SHA256
 	// Pbdkf2DefaultSaltLength is the default salth length in bytes.
 	Pbdkf2DefaultSaltLength = 16
+	// Pbkdf2MaximumRounds is the maximum iteration counts accepted when verifying a hash.
+	Pbkdf2MaximumRounds = 10_000_000
+)
+
+const (
+	pbkdf2MaximumKeyLength  = 1024
+	pbkdf2MaximumSaltLength = 1024
 )
 
 type HashFunction int
@@ -161,24 +167,27 @@ func (p *pbdkf2Hasher) Verify(ctx context.Context, plainPassword string, hashedP
 		return false, ErrEmptyToken
 	}
 
-	deserialized := deserialize(hashedPassword)
+	deserialized, err := deserialize(hashedPassword)
+	if err != nil {
+		return false, err
+	}
 
 	if !strings.HasPrefix(deserialized.ID, "pbkdf2") {
 		return false, ErrUnexpectedHasherInstance
 	}
 
-	decodedHash, err := hex.DecodeString(deserialized.Hash)
+	decodedHash, err := hexField("hash", deserialized.Hash, 1, pbkdf2MaximumKeyLength)
 	if err != nil {
 		return false, err
 	}
-	keyLen := int(len(decodedHash))
+	keyLen := len(decodedHash)
 
-	rounds, err := strconv.ParseInt(deserialized.Params["i"].(string), 10, 32)
+	rounds, err := deserialized.uintParam("i", 1, Pbkdf2MaximumRounds)
 	if err != nil {
 		return false, err
 	}
 
-	salt, err := hex.DecodeString(deserialized.Salt)
+	salt, err := hexField("salt", deserialized.Salt, 1, pbkdf2MaximumSaltLength)
 	if err != nil {
 		return false, err
 	}
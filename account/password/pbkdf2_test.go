@@ -293,3 +293,28 @@ func TestPbdkf2Hasher_Error(t *testing.T) {
 		}
 	})
 }
+
+func TestPbdkf2Hasher_Malformed(t *testing.T) {
+	hasher, err := password.NewPbdkf2PasswordHasher(password.Pbdkf2Config{})
+	if err != nil {
+		t.Fatalf("initializing pbkdf2 hasher: %s", err.Error())
+	}
+
+	ctx := context.Background()
+
+	testCases := []string{
+		"$pbkdf2sha256$v=0$i=4096",
+		"$pbkdf2sha256$v=0$$d172c14e9955bf4e4c01422f2af10d4f$ad21bd7d8568ce800754aafb6630e7e9",
+		"$pbkdf2sha256$v=0$i=0$d172c14e9955bf4e4c01422f2af10d4f$ad21bd7d8568ce800754aafb6630e7e9",
+		"$pbkdf2sha256$v=0$i=99999999999$d172c14e9955bf4e4c01422f2af10d4f$ad21bd7d8568ce800754aafb6630e7e9",
+		"$pbkdf2sha256$v=0$i=4096$$ad21bd7d8568ce800754aafb6630e7e9",
+		"$pbkdf2sha256$v=0$i=4096$d172c14e9955bf4e4c01422f2af10d4f$",
+	}
+
+	for _, hashString := range testCases {
+		_, err := hasher.Verify(ctx, "something", hashString)
+		if !errors.Is(err, password.ErrMalformedHash) {
+			t.Errorf("expecting ErrMalformedHash for %q, got %v", hashString, err)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package password
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// PolicyDefaultMinimumLength is the minimum amount of characters for a password.
+	PolicyDefaultMinimumLength = 8
+	// PolicyDefaultMinimumScore is the minimum strength score, as estimated by EstimateStrength.
+	PolicyDefaultMinimumScore = 3
+)
+
+// PolicyConfig initialize the config required to create a password policy
+type PolicyConfig struct {
+	MinimumLength int
+	MinimumScore  int
+	// BreachedPasswords is optional. If it's nil, passwords are not checked against any data breach.
+	BreachedPasswords BreachedPasswordChecker
+}
+
+// Policy decides whether a password is acceptable to be set for an account.
+type Policy struct {
+	config PolicyConfig
+}
+
+func NewPolicy(config PolicyConfig) (*Policy, error) {
+	if config.MinimumLength <= 0 {
+		config.MinimumLength = PolicyDefaultMinimumLength
+	}
+	if config.MinimumScore <= 0 || config.MinimumScore > 4 {
+		config.MinimumScore = PolicyDefaultMinimumScore
+	}
+
+	return &Policy{config: config}, nil
+}
+
+// ViolationCode is a machine-readable reason of why a password is rejected.
+type ViolationCode string
+
+const (
+	ViolationTooShort             ViolationCode = "too_short"
+	ViolationTooWeak              ViolationCode = "too_weak"
+	ViolationContainsPersonalInfo ViolationCode = "contains_personal_info"
+	ViolationBreached             ViolationCode = "breached"
+)
+
+// Violation is a single reason of why a password is rejected, safe to be shown to the user.
+type Violation struct {
+	Code    ViolationCode `json:"code"`
+	Message string        `json:"message"`
+}
+
+// PolicyError is returned by Policy.Check when the password violates one or more rules.
+type PolicyError struct {
+	Violations []Violation
+}
+
+func (p *PolicyError) Error() string {
+	messages := make([]string, 0, len(p.Violations))
+	for _, violation := range p.Violations {
+		messages = append(messages, violation.Message)
+	}
+	return "password rejected: " + strings.Join(messages, "; ")
+}
+
+// Check validates the plain password against the policy. personalInputs are the user's own data (name,
+// email address) that must not be a part of the password.
+//
+// It returns *PolicyError if the password is rejected, or any other error if we fail to check it.
+func (p *Policy) Check(ctx context.Context, plainPassword string, personalInputs ...string) error {
+	var violations []Violation
+
+	if utf8.RuneCountInString(plainPassword) < p.config.MinimumLength {
+		violations = append(violations, Violation{
+			Code:    ViolationTooShort,
+			Message: fmt.Sprintf("Password must be at least %d characters long", p.config.MinimumLength),
+		})
+	}
+
+	personalWords := splitPersonalInputs(personalInputs)
+	lowerPassword := strings.ToLower(plainPassword)
+	for _, word := range personalWords {
+		if strings.Contains(lowerPassword, word) {
+			violations = append(violations, Violation{
+				Code:    ViolationContainsPersonalInfo,
+				Message: "Password must not contain your name or email address",
+			})
+			break
+		}
+	}
+
+	strength := EstimateStrength(plainPassword, append(personalWords, personalInputs...)...)
+	if strength.Score < p.config.MinimumScore {
+		violations = append(violations, Violation{
+			Code:    ViolationTooWeak,
+			Message: "Password is too easy to guess, try a longer password or an uncommon phrase",
+		})
+	}
+
+	if p.config.BreachedPasswords != nil && plainPassword != "" {
+		breached, err := p.config.BreachedPasswords.IsBreached(ctx, plainPassword)
+		if err != nil {
+			return fmt.Errorf("checking breached password: %w", err)
+		}
+
+		if breached {
+			violations = append(violations, Violation{
+				Code:    ViolationBreached,
+				Message: "Password has appeared in a data breach, please choose a different one",
+			})
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+
+	return nil
+}
+
+// splitPersonalInputs breaks down names and email addresses into lowercased words that are long
+// enough to be meaningful.
+func splitPersonalInputs(personalInputs []string) []string {
+	var words []string
+	for _, input := range personalInputs {
+		input = strings.ToLower(input)
+		if local, _, ok := strings.Cut(input, "@"); ok {
+			if utf8.RuneCountInString(local) >= 3 {
+				words = append(words, local)
+			}
+			input = local
+		}
+
+		fields := strings.FieldsFunc(input, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for _, field := range fields {
+			if utf8.RuneCountInString(field) >= 3 {
+				words = append(words, field)
+			}
+		}
+	}
+
+	return words
+}
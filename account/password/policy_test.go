@@ -0,0 +1,219 @@
+package password_test
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"coffee-chain-api/account/password"
+)
+
+func TestEstimateStrength(t *testing.T) {
+	testCases := []struct {
+		input    string
+		minScore int
+		maxScore int
+	}{
+		{input: "password", minScore: 0, maxScore: 0},
+		{input: "P@ssw0rd", minScore: 0, maxScore: 1},
+		{input: "qwertyuiop", minScore: 0, maxScore: 1},
+		{input: "aaaaaaaaaa", minScore: 0, maxScore: 1},
+		{input: "abcdefgh12345", minScore: 0, maxScore: 2},
+		{input: "coffee2024", minScore: 0, maxScore: 2},
+		{input: "kR7#vQ2!mZ9x", minScore: 4, maxScore: 4},
+		{input: "purple monkey dishwasher", minScore: 4, maxScore: 4},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.input, func(t *testing.T) {
+			strength := password.EstimateStrength(tt.input)
+			if strength.Score < tt.minScore || strength.Score > tt.maxScore {
+				t.Errorf("expecting score between %d and %d, got %d (log10 guesses %f)", tt.minScore, tt.maxScore, strength.Score, strength.GuessesLog10)
+			}
+		})
+	}
+
+	t.Run("only digits are years", func(t *testing.T) {
+		// "19kq" compares between "1900" and "2099" as a string, but it's not a year.
+		year := password.EstimateStrength("Wv1999#m")
+		letters := password.EstimateStrength("Wv19kq#m")
+		if letters.GuessesLog10 <= year.GuessesLog10 {
+			t.Errorf("expecting letters not to match a year, got %f and %f", letters.GuessesLog10, year.GuessesLog10)
+		}
+	})
+
+	t.Run("user inputs lower the strength", func(t *testing.T) {
+		without := password.EstimateStrength("reinaldyrafli")
+		with := password.EstimateStrength("reinaldyrafli", "reinaldy", "rafli")
+		if with.GuessesLog10 >= without.GuessesLog10 {
+			t.Errorf("expecting user inputs to lower guesses, got %f and %f", with.GuessesLog10, without.GuessesLog10)
+		}
+	})
+}
+
+func TestPolicy_Check(t *testing.T) {
+	breachedFile := writeBreachedPasswordFile(t, []string{"correct horse battery staple", "kR7#vQ2!mZ9x-breached"}, 40)
+	breachedChecker, err := password.NewBreachedPasswordFile(breachedFile)
+	if err != nil {
+		t.Fatalf("initializing breached password file: %s", err.Error())
+	}
+
+	policy, err := password.NewPolicy(password.PolicyConfig{BreachedPasswords: breachedChecker})
+	if err != nil {
+		t.Fatalf("initializing policy: %s", err.Error())
+	}
+
+	ctx := context.Background()
+
+	testCases := []struct {
+		name           string
+		password       string
+		personalInputs []string
+		expect         []password.ViolationCode
+	}{
+		{
+			name:     "strong password",
+			password: "kR7#vQ2!mZ9x",
+			expect:   nil,
+		},
+		{
+			name:     "too short",
+			password: "kR7#vQ",
+			expect:   []password.ViolationCode{password.ViolationTooShort, password.ViolationTooWeak},
+		},
+		{
+			name:     "common password",
+			password: "password123",
+			expect:   []password.ViolationCode{password.ViolationTooWeak},
+		},
+		{
+			name:           "contains name",
+			password:       "Reinaldy#vQ2!mZ9x",
+			personalInputs: []string{"Reinaldy Rafli", "aldy505@proton.me"},
+			expect:         []password.ViolationCode{password.ViolationContainsPersonalInfo},
+		},
+		{
+			name:           "contains email",
+			password:       "xx-aldy505-kR7#vQ",
+			personalInputs: []string{"Reinaldy Rafli", "aldy505@proton.me"},
+			expect:         []password.ViolationCode{password.ViolationContainsPersonalInfo},
+		},
+		{
+			name:     "breached",
+			password: "correct horse battery staple",
+			expect:   []password.ViolationCode{password.ViolationBreached},
+		},
+		{
+			name:     "breached strong password",
+			password: "kR7#vQ2!mZ9x-breached",
+			expect:   []password.ViolationCode{password.ViolationBreached},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(ctx, tt.password, tt.personalInputs...)
+			if len(tt.expect) == 0 {
+				if err != nil {
+					t.Errorf("expecting no error, got %s", err.Error())
+				}
+				return
+			}
+
+			var policyError *password.PolicyError
+			if !errors.As(err, &policyError) {
+				t.Fatalf("expecting *password.PolicyError, got %v", err)
+			}
+
+			var got []password.ViolationCode
+			for _, violation := range policyError.Violations {
+				got = append(got, violation.Code)
+			}
+
+			if len(got) != len(tt.expect) {
+				t.Fatalf("expecting violations %v, got %v", tt.expect, got)
+			}
+			for i := range got {
+				if got[i] != tt.expect[i] {
+					t.Errorf("expecting violations %v, got %v", tt.expect, got)
+				}
+			}
+		})
+	}
+}
+
+func TestBreachedPasswordFile(t *testing.T) {
+	var fillers []string
+	for i := 0; i < 500; i++ {
+		fillers = append(fillers, "filler-"+strings.Repeat("x", i%7)+string(rune('a'+i%26))+string(rune('a'+i/26)))
+	}
+	breached := append([]string{"hunter2", "123456", "trustno1"}, fillers...)
+
+	ctx := context.Background()
+
+	for _, prefixLen := range []int{40, 10} {
+		path := writeBreachedPasswordFile(t, breached, prefixLen)
+		checker, err := password.NewBreachedPasswordFile(path)
+		if err != nil {
+			t.Fatalf("initializing breached password file: %s", err.Error())
+		}
+
+		for _, plainPassword := range breached {
+			ok, err := checker.IsBreached(ctx, plainPassword)
+			if err != nil {
+				t.Errorf("unexpected error: %s", err.Error())
+			}
+			if !ok {
+				t.Errorf("expecting %q to be breached with prefix length %d", plainPassword, prefixLen)
+			}
+		}
+
+		for _, plainPassword := range []string{"kR7#vQ2!mZ9x", "not in the list", ""} {
+			ok, err := checker.IsBreached(ctx, plainPassword)
+			if err != nil {
+				t.Errorf("unexpected error: %s", err.Error())
+			}
+			if ok {
+				t.Errorf("expecting %q not to be breached with prefix length %d", plainPassword, prefixLen)
+			}
+		}
+	}
+
+	t.Run("invalid file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "empty.txt")
+		if err := os.WriteFile(path, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := password.NewBreachedPasswordFile(path)
+		if err == nil {
+			t.Error("expecting an error, got nil")
+		}
+	})
+}
+
+func writeBreachedPasswordFile(t *testing.T, passwords []string, prefixLen int) string {
+	t.Helper()
+
+	var lines []string
+	for i, plainPassword := range passwords {
+		sum := sha1.Sum([]byte(plainPassword))
+		hash := strings.ToUpper(hex.EncodeToString(sum[:]))[:prefixLen]
+		lines = append(lines, hash+":"+strings.Repeat("1", i%5+1))
+	}
+	sort.Strings(lines)
+
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	err := os.WriteFile(path, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o600)
+	if err != nil {
+		t.Fatalf("writing breached password file: %s", err.Error())
+	}
+
+	return path
+}
@@ -0,0 +1,317 @@
+package password
+
+import (
+	_ "embed"
+	"math"
+	"strings"
+	"unicode"
+)
+
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+// commonPasswords maps a lowercased common password into its popularity rank, 1 being the most popular.
+var commonPasswords = func() map[string]int {
+	ranked := make(map[string]int)
+	for i, word := range strings.Fields(commonPasswordsFile) {
+		ranked[word] = i + 1
+	}
+	return ranked
+}()
+
+// keyboardRows are adjacent key sequences on a QWERTY keyboard, used to detect spatial patterns.
+var keyboardRows = []string{"1234567890", "qwertyuiop", "asdfghjkl", "zxcvbnm"}
+
+// leetSubstitutions reverts the most common l33t-speak substitutions back to letters.
+var leetSubstitutions = strings.NewReplacer("4", "a", "@", "a", "3", "e", "1", "i", "!", "i", "0", "o", "$", "s", "5", "s", "7", "t", "+", "t")
+
+// strengthMaximumLength caps the amount of characters we look for patterns in, anything beyond
+// is counted as brute force guesses.
+const strengthMaximumLength = 64
+
+// Strength is the estimated strength of a password.
+type Strength struct {
+	// GuessesLog10 is the base 10 logarithm of the estimated number of guesses needed to crack the password.
+	GuessesLog10 float64
+	// Score is a 0 to 4 scale of GuessesLog10, with 0 being too guessable and 4 being very unguessable.
+	Score int
+}
+
+// strengthMatch is a substring of the password that matches a known pattern.
+type strengthMatch struct {
+	start        int
+	end          int
+	guessesLog10 float64
+}
+
+// EstimateStrength estimates the password strength by finding the cheapest way to guess the password as
+// a sequence of known patterns (common passwords, user inputs, repeats, sequences, keyboard rows, years),
+// filling the gaps by brute force. It is loosely based on the approach taken by zxcvbn.
+//
+// userInputs are words that are specific to the user, such as their name or email address, which are
+// treated as the most popular passwords.
+func EstimateStrength(plainPassword string, userInputs ...string) Strength {
+	runes := []rune(plainPassword)
+	if len(runes) == 0 {
+		return Strength{}
+	}
+
+	overflow := 0
+	if len(runes) > strengthMaximumLength {
+		overflow = len(runes) - strengthMaximumLength
+		runes = runes[:strengthMaximumLength]
+	}
+
+	dictionary := make(map[string]int, len(userInputs))
+	for i, input := range userInputs {
+		input = strings.ToLower(strings.TrimSpace(input))
+		if input != "" {
+			dictionary[input] = i + 1
+		}
+	}
+
+	matches := findStrengthMatches(runes, dictionary)
+	n := len(runes)
+
+	// best[i][k] holds the minimum log10 guesses to cover the first i characters with exactly k matches.
+	best := make([][]float64, n+1)
+	for i := range best {
+		best[i] = make([]float64, n+1)
+		for k := range best[i] {
+			best[i][k] = math.Inf(1)
+		}
+	}
+	best[0][0] = 0
+
+	byEnd := make([][]strengthMatch, n+1)
+	for _, m := range matches {
+		byEnd[m.end] = append(byEnd[m.end], m)
+	}
+	for end := 1; end <= n; end++ {
+		// Any substring can be brute forced.
+		for start := 0; start < end; start++ {
+			byEnd[end] = append(byEnd[end], strengthMatch{
+				start:        start,
+				end:          end,
+				guessesLog10: bruteForceGuessesLog10(end - start),
+			})
+		}
+	}
+
+	for end := 1; end <= n; end++ {
+		for _, m := range byEnd[end] {
+			for k := 0; k < n; k++ {
+				if math.IsInf(best[m.start][k], 1) {
+					continue
+				}
+				candidate := best[m.start][k] + m.guessesLog10
+				if candidate < best[end][k+1] {
+					best[end][k+1] = candidate
+				}
+			}
+		}
+	}
+
+	guessesLog10 := math.Inf(1)
+	for k := 1; k <= n; k++ {
+		if math.IsInf(best[n][k], 1) {
+			continue
+		}
+		// An attacker also needs to guess how many patterns the password is made of, and in which order.
+		lgamma, _ := math.Lgamma(float64(k + 1))
+		candidate := best[n][k] + lgamma/math.Ln10
+		if candidate < guessesLog10 {
+			guessesLog10 = candidate
+		}
+	}
+	guessesLog10 += float64(overflow)
+
+	return Strength{
+		GuessesLog10: guessesLog10,
+		Score:        strengthScore(guessesLog10),
+	}
+}
+
+func strengthScore(guessesLog10 float64) int {
+	switch {
+	case guessesLog10 < 3:
+		return 0
+	case guessesLog10 < 6:
+		return 1
+	case guessesLog10 < 8:
+		return 2
+	case guessesLog10 < 10:
+		return 3
+	default:
+		return 4
+	}
+}
+
+func bruteForceGuessesLog10(length int) float64 {
+	// Ten guesses per character, same as zxcvbn. It is intentionally low since humans rarely pick
+	// characters uniformly at random.
+	return float64(length)
+}
+
+func findStrengthMatches(runes []rune, userInputs map[string]int) []strengthMatch {
+	var matches []strengthMatch
+	n := len(runes)
+
+	lower := make([]rune, n)
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	// Dictionary matches, including user inputs, reversed words and l33t substitutions.
+	for start := 0; start < n; start++ {
+		for end := start + 3; end <= n; end++ {
+			original := string(runes[start:end])
+			word := string(lower[start:end])
+
+			multiplier := 1.0
+			if original != word {
+				multiplier *= 2
+			}
+
+			if rank, ok := lookupRank(word, userInputs); ok {
+				matches = append(matches, dictionaryMatch(start, end, rank, multiplier))
+			}
+			if reversed := reverseString(word); reversed != word {
+				if rank, ok := lookupRank(reversed, userInputs); ok {
+					matches = append(matches, dictionaryMatch(start, end, rank, multiplier*2))
+				}
+			}
+			if unleet := leetSubstitutions.Replace(word); unleet != word {
+				if rank, ok := lookupRank(unleet, userInputs); ok {
+					matches = append(matches, dictionaryMatch(start, end, rank, multiplier*2))
+				}
+			}
+		}
+	}
+
+	// Repeated characters, such as "aaaa".
+	for start := 0; start < n; {
+		end := start + 1
+		for end < n && lower[end] == lower[start] {
+			end++
+		}
+		if end-start >= 3 {
+			matches = append(matches, strengthMatch{
+				start:        start,
+				end:          end,
+				guessesLog10: math.Log10(characterCardinality(lower[start]) * float64(end-start)),
+			})
+		}
+		start = end
+	}
+
+	// Sequences with a constant step of one, such as "abcd" or "4321".
+	for start := 0; start < n-2; {
+		delta := lower[start+1] - lower[start]
+		if delta != 1 && delta != -1 {
+			start++
+			continue
+		}
+
+		end := start + 2
+		for end < n && lower[end]-lower[end-1] == delta {
+			end++
+		}
+		if end-start >= 3 {
+			base := characterCardinality(lower[start])
+			if lower[start] == 'a' || lower[start] == '1' || lower[start] == 'z' || lower[start] == '9' {
+				base = 4
+			}
+			if delta < 0 {
+				base *= 2
+			}
+			matches = append(matches, strengthMatch{
+				start:        start,
+				end:          end,
+				guessesLog10: math.Log10(base * float64(end-start)),
+			})
+		}
+		start = end - 1
+	}
+
+	// Keyboard rows, such as "qwerty" or "lkjh".
+	for _, row := range keyboardRows {
+		for _, candidate := range []string{row, reverseString(row)} {
+			for start := 0; start < n; start++ {
+				for end := start + 3; end <= n; end++ {
+					if !strings.Contains(candidate, string(lower[start:end])) {
+						break
+					}
+					matches = append(matches, strengthMatch{
+						start:        start,
+						end:          end,
+						guessesLog10: math.Log10(float64(len(keyboardRows)) * 10 * float64(end-start)),
+					})
+				}
+			}
+		}
+	}
+
+	// Recent years, such as "1998" or "2024".
+	for start := 0; start+4 <= n; start++ {
+		if !allDigits(lower[start : start+4]) {
+			continue
+		}
+
+		year := string(lower[start : start+4])
+		if year >= "1900" && year <= "2099" {
+			matches = append(matches, strengthMatch{
+				start:        start,
+				end:          start + 4,
+				guessesLog10: math.Log10(200),
+			})
+		}
+	}
+
+	return matches
+}
+
+func allDigits(runes []rune) bool {
+	for _, r := range runes {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func lookupRank(word string, userInputs map[string]int) (int, bool) {
+	if rank, ok := userInputs[word]; ok {
+		return rank, true
+	}
+	rank, ok := commonPasswords[word]
+	return rank, ok
+}
+
+func dictionaryMatch(start, end, rank int, multiplier float64) strengthMatch {
+	return strengthMatch{
+		start:        start,
+		end:          end,
+		guessesLog10: math.Log10(float64(rank) * multiplier),
+	}
+}
+
+func characterCardinality(r rune) float64 {
+	switch {
+	case unicode.IsDigit(r):
+		return 10
+	case unicode.IsLetter(r):
+		return 26
+	default:
+		return 33
+	}
+}
+
+func reverseString(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
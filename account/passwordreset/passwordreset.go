@@ -0,0 +1,50 @@
+package passwordreset
+
+import (
+	"errors"
+	"time"
+
+	"coffee-chain-api/account/loginthrottle"
+)
+
+// ErrInvalidToken indicates that the reset token does not exist, has expired, or has been used.
+var ErrInvalidToken = errors.New("invalid password reset token")
+
+const (
+	// DefaultTokenLifetime is how long a reset token stays valid after it's issued.
+	DefaultTokenLifetime = time.Minute * 30
+	// DefaultInviteLifetime is how long an invitation token stays valid after it's issued. It's longer than
+	// DefaultTokenLifetime, since the invited person is not waiting for the email.
+	DefaultInviteLifetime = time.Hour * 72
+)
+
+var (
+	// DefaultThrottleAccountRule throttles the reset requests for a single email address, counting every
+	// request, so the account's inbox is not flooded with reset links.
+	DefaultThrottleAccountRule = loginthrottle.Rule{
+		FreeAttempts:     3,
+		BaseDelay:        time.Minute,
+		MaximumDelay:     time.Minute * 15,
+		LockoutThreshold: 10,
+		LockoutDuration:  time.Hour,
+		Window:           time.Hour * 2,
+	}
+	// DefaultThrottleIPRule throttles the reset requests from a single IP address, so it can not email
+	// every registered address in turn.
+	DefaultThrottleIPRule = loginthrottle.Rule{
+		FreeAttempts:     10,
+		BaseDelay:        time.Second * 30,
+		MaximumDelay:     time.Minute * 15,
+		LockoutThreshold: 50,
+		LockoutDuration:  time.Hour,
+		Window:           time.Hour * 2,
+	}
+)
+
+type Config struct {
+	// ResetURL is the page on the client application that accepts the new password. The reset token is
+	// appended as the "token" query parameter, e.g. https://example.com/reset-password?token=...
+	ResetURL       string
+	TokenLifetime  time.Duration
+	InviteLifetime time.Duration
+}
@@ -0,0 +1,393 @@
+package passwordreset
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"coffee-chain-api/account"
+	"coffee-chain-api/account/accountstore"
+	"coffee-chain-api/account/authentication"
+	"coffee-chain-api/account/password"
+	"coffee-chain-api/account/securityevent"
+	"coffee-chain-api/mailer"
+
+	"github.com/rs/zerolog/log"
+)
+
+type Repository struct {
+	db             *sql.DB
+	accountStore   accountstore.AccountStore
+	passwordHasher password.Hasher
+	passwordPolicy *password.Policy
+	mailer         mailer.Mailer
+	authenticator  authentication.Authenticator
+	securityEvents *securityevent.Recorder
+	resetURL       *url.URL
+	tokenLifetime  time.Duration
+	inviteLifetime time.Duration
+}
+
+func NewPasswordResetRepository(db *sql.DB, accountStore accountstore.AccountStore, passwordHasher password.Hasher, passwordPolicy *password.Policy, mailer mailer.Mailer, authenticator authentication.Authenticator, securityEvents *securityevent.Recorder, config Config) (*Repository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db is nil")
+	}
+	if accountStore == nil {
+		return nil, fmt.Errorf("accountStore is nil")
+	}
+	if passwordHasher == nil {
+		return nil, fmt.Errorf("passwordHasher is nil")
+	}
+	if passwordPolicy == nil {
+		return nil, fmt.Errorf("passwordPolicy is nil")
+	}
+	if mailer == nil {
+		return nil, fmt.Errorf("mailer is nil")
+	}
+	if authenticator == nil {
+		return nil, fmt.Errorf("authenticator is nil")
+	}
+	if securityEvents == nil {
+		return nil, fmt.Errorf("securityEvents is nil")
+	}
+
+	resetURL, err := url.Parse(config.ResetURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing reset url: %w", err)
+	}
+	if !resetURL.IsAbs() {
+		return nil, fmt.Errorf("reset url must be absolute")
+	}
+
+	if config.TokenLifetime <= 0 {
+		config.TokenLifetime = DefaultTokenLifetime
+	}
+	if config.InviteLifetime <= 0 {
+		config.InviteLifetime = DefaultInviteLifetime
+	}
+
+	return &Repository{
+		db:             db,
+		accountStore:   accountStore,
+		passwordHasher: passwordHasher,
+		passwordPolicy: passwordPolicy,
+		mailer:         mailer,
+		authenticator:  authenticator,
+		securityEvents: securityEvents,
+		resetURL:       resetURL,
+		tokenLifetime:  config.TokenLifetime,
+		inviteLifetime: config.InviteLifetime,
+	}, nil
+}
+
+// RequestReset issues a new reset token for the account and emails the reset link to it. Any previously
+// issued token for the same account is invalidated.
+//
+// It returns nil if there is no account with such email, so the caller can not tell whether the email
+// is registered or not.
+func (r *Repository) RequestReset(ctx context.Context, email string) error {
+	userAccount, err := r.accountStore.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, accountstore.ErrNotFound) {
+			return nil
+		}
+
+		return fmt.Errorf("acquiring account by email: %w", err)
+	}
+
+	token, err := r.issueToken(ctx, userAccount, r.tokenLifetime)
+	if err != nil {
+		return fmt.Errorf("issuing token: %w", err)
+	}
+
+	r.securityEvents.Record(ctx, userAccount.GetProfile().ID, securityevent.TypePasswordResetRequest, securityevent.OutcomeSuccess)
+
+	err = r.mailer.Send(ctx, mailer.Mail{
+		To:      []string{userAccount.GetProfile().Email},
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\n"+
+				"Somebody requested a password reset for your account. If it was you, open the link below to choose a new password:\n\n"+
+				"%s\n\n"+
+				"The link expires in %d minutes and can only be used once. If you did not request it, you can safely ignore this email.\n",
+			userAccount.GetProfile().Name,
+			r.resetLink(token),
+			int(r.tokenLifetime.Minutes()),
+		),
+	})
+	if err != nil {
+		return fmt.Errorf("sending reset email: %w", err)
+	}
+
+	return nil
+}
+
+// Reset sets a new password for the account that owns the reset token, consumes the token, and revokes
+// every session of the account. It returns ErrInvalidToken if the token can not be used, or
+// *password.PolicyError if the new password is rejected, in which case the token stays usable.
+func (r *Repository) Reset(ctx context.Context, token string, newPlainPassword string) error {
+	if token == "" {
+		return ErrInvalidToken
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		return fmt.Errorf("creating transaction: %w", err)
+	}
+
+	var accountId int64
+	var name string
+	var email string
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT
+				password_reset_tokens.account_id,
+				user_accounts.name,
+				user_accounts.email
+			FROM
+				password_reset_tokens
+				INNER JOIN user_accounts ON user_accounts.id = password_reset_tokens.account_id
+			WHERE
+				password_reset_tokens.token_hash = $1
+				AND password_reset_tokens.consumed_at IS NULL
+				AND password_reset_tokens.expires_at > NOW()
+				AND user_accounts.deleted_at IS NULL
+				AND user_accounts.deactivated_at IS NULL
+			LIMIT 1
+			FOR UPDATE`,
+		hashToken(token),
+	).Scan(&accountId, &name, &email)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidToken
+		}
+
+		return fmt.Errorf("getting password reset token: %w", err)
+	}
+
+	// Whoever holds the reset token acts on behalf of the account.
+	ctx = account.WithActor(ctx, account.NewBasicAccount(account.Profile{ID: accountId, Name: name, Email: email}, account.TypeUnspecified, 0))
+
+	err = r.passwordPolicy.Check(ctx, newPlainPassword, name, email)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		return fmt.Errorf("checking password policy: %w", err)
+	}
+
+	hashedPassword, err := r.passwordHasher.Hash(ctx, newPlainPassword)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		return fmt.Errorf("hashing password: %w", err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE
+			user_accounts
+		SET
+			hashed_password = $1,
+			updated_at = $2,
+			updated_by = $3
+		WHERE
+			id = $4`,
+		hashedPassword,
+		time.Now(),
+		account.ActorIdentifier(ctx),
+		accountId,
+	)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		return fmt.Errorf("executing update query: %w", err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE
+			password_reset_tokens
+		SET
+			consumed_at = NOW()
+		WHERE
+			account_id = $1
+			AND consumed_at IS NULL`,
+		accountId,
+	)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		return fmt.Errorf("executing update query: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	r.securityEvents.Record(ctx, accountId, securityevent.TypePasswordReset, securityevent.OutcomeSuccess)
+
+	err = r.authenticator.RevokeSessions(ctx, accountId)
+	if err != nil {
+		return fmt.Errorf("revoking sessions: %w", err)
+	}
+
+	return nil
+}
+
+// Invite issues a token for an account that was created without a password, and emails the link to set
+// one. Any previously issued token for the same account is invalidated. The token is redeemed with Reset.
+func (r *Repository) Invite(ctx context.Context, userAccount account.Account) error {
+	token, err := r.issueToken(ctx, userAccount, r.inviteLifetime)
+	if err != nil {
+		return fmt.Errorf("issuing token: %w", err)
+	}
+
+	err = r.mailer.Send(ctx, mailer.Mail{
+		To:      []string{userAccount.GetProfile().Email},
+		Subject: "You have been invited to Coffee Chain",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\n"+
+				"An account has been created for you. Open the link below to choose your password:\n\n"+
+				"%s\n\n"+
+				"The link expires in %d hours and can only be used once. If it expires, ask your manager for a new invitation.\n",
+			userAccount.GetProfile().Name,
+			r.resetLink(token),
+			int(r.inviteLifetime.Hours()),
+		),
+	})
+	if err != nil {
+		return fmt.Errorf("sending invitation email: %w", err)
+	}
+
+	return nil
+}
+
+// issueToken invalidates the account's unused tokens and stores a new one that expires after lifetime.
+func (r *Repository) issueToken(ctx context.Context, userAccount account.Account, lifetime time.Duration) (string, error) {
+	rawToken := make([]byte, 32)
+	_, err := rand.Read(rawToken)
+	if err != nil {
+		return "", fmt.Errorf("reading random reader: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(rawToken)
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return "", fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		return "", fmt.Errorf("creating transaction: %w", err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE
+			password_reset_tokens
+		SET
+			consumed_at = NOW()
+		WHERE
+			account_id = $1
+			AND consumed_at IS NULL`,
+		userAccount.GetProfile().ID,
+	)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return "", fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		return "", fmt.Errorf("executing update query: %w", err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO
+			password_reset_tokens
+			(account_id,
+			 token_hash,
+			 expires_at,
+			 created_at
+			 )
+		VALUES
+			($1, $2, $3, $4)`,
+		userAccount.GetProfile().ID,
+		hashToken(token),
+		time.Now().Add(lifetime),
+		time.Now(),
+	)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return "", fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		return "", fmt.Errorf("executing insert query: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return "", fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return token, nil
+}
+
+// resetLink appends the token to the reset URL.
+func (r *Repository) resetLink(token string) string {
+	resetURL := *r.resetURL
+	query := resetURL.Query()
+	query.Set("token", token)
+	resetURL.RawQuery = query.Encode()
+
+	return resetURL.String()
+}
+
+// hashToken hashes the reset token before it touches the database, so a leaked table can not be
+// used to reset anyone's password. The token has enough entropy that a plain SHA-256 is sufficient.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -5,5 +5,6 @@ type Profile struct {
 	Name  string `json:"name"`
 	Email string `json:"email"`
 	// We do not store account password here, it's on the database
-	Gender Gender `json:"gender"`
+	Gender                  Gender                  `json:"gender"`
+	NotificationPreferences NotificationPreferences `json:"notification_preferences"`
 }
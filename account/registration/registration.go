@@ -2,22 +2,44 @@ package registration
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
+
+	"coffee-chain-api/account"
+	"coffee-chain-api/account/accountstore"
+	"coffee-chain-api/account/password"
 )
 
 type Repository struct {
-	db *sql.DB
+	accountStore   accountstore.AccountStore
+	passwordPolicy *password.Policy
 }
 
-func NewRegistrationRepository(db *sql.DB) (*Repository, error) {
-	if db == nil {
-		return nil, fmt.Errorf("db is nil")
+func NewRegistrationRepository(accountStore accountstore.AccountStore, passwordPolicy *password.Policy) (*Repository, error) {
+	if accountStore == nil {
+		return nil, fmt.Errorf("accountStore is nil")
+	}
+	if passwordPolicy == nil {
+		return nil, fmt.Errorf("passwordPolicy is nil")
 	}
 
-	return &Repository{db: db}, nil
+	return &Repository{accountStore: accountStore, passwordPolicy: passwordPolicy}, nil
 }
 
-func (r *Repository) Register(ctx context.Context) error {
+// Register creates a new customer account. It returns *password.PolicyError if the password
+// does not satisfy the password policy.
+func (r *Repository) Register(ctx context.Context, rawAccount accountstore.RawAccount) error {
+	err := r.passwordPolicy.Check(ctx, rawAccount.PlainPassword, rawAccount.Name, rawAccount.Email)
+	if err != nil {
+		return fmt.Errorf("checking password policy: %w", err)
+	}
+
+	// Only customers can register by themselves
+	rawAccount.Type = account.TypeCustomer
+
+	err = r.accountStore.Insert(ctx, rawAccount)
+	if err != nil {
+		return fmt.Errorf("inserting account: %w", err)
+	}
+
 	return nil
 }
@@ -0,0 +1,185 @@
+package securityevent
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+type repository struct {
+	db *sql.DB
+}
+
+func NewSecurityEventRepository(db *sql.DB) (Store, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db is nil")
+	}
+
+	return &repository{db: db}, nil
+}
+
+func (r *repository) Insert(ctx context.Context, event Event) error {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	_, err = conn.ExecContext(
+		ctx,
+		`INSERT INTO
+			security_events
+			(account_id,
+			 type,
+			 outcome,
+			 ip_address,
+			 user_agent,
+			 actor,
+			 created_at
+			 )
+		VALUES
+			($1, $2, $3, $4, $5, $6, $7)`,
+		sql.NullInt64{Int64: event.AccountID, Valid: event.AccountID > 0},
+		event.Type,
+		event.Outcome,
+		event.IP,
+		event.UserAgent,
+		event.Actor,
+		event.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("executing insert query: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repository) List(ctx context.Context, filter ListFilter) (ListResult, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	if limit > MaximumListLimit {
+		limit = MaximumListLimit
+	}
+
+	var conditions []string
+	var args []any
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", "$"+strconv.Itoa(len(args))))
+	}
+
+	if filter.Cursor > 0 {
+		addCondition("id < ?", filter.Cursor)
+	}
+	if filter.AccountID > 0 {
+		addCondition("account_id = ?", filter.AccountID)
+	}
+	if filter.Type != "" {
+		addCondition("type = ?", filter.Type)
+	}
+	if filter.Outcome != "" {
+		addCondition("outcome = ?", filter.Outcome)
+	}
+	if filter.IP != "" {
+		addCondition("ip_address = ?", filter.IP)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		addCondition("created_at >= ?", filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		addCondition("created_at < ?", filter.CreatedBefore)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// One more row than requested, to know whether there is a next page.
+	args = append(args, limit+1)
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return ListResult{}, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	rows, err := conn.QueryContext(
+		ctx,
+		`SELECT
+				id,
+				account_id,
+				type,
+				outcome,
+				ip_address,
+				user_agent,
+				actor,
+				created_at
+			FROM
+				security_events
+			`+where+`
+			ORDER BY
+				id DESC
+			LIMIT $`+strconv.Itoa(len(args)),
+		args...,
+	)
+	if err != nil {
+		return ListResult{}, fmt.Errorf("executing select query: %w", err)
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing rows")
+		}
+	}()
+
+	var result ListResult
+	for rows.Next() {
+		var event Event
+		var accountId sql.NullInt64
+		err = rows.Scan(
+			&event.ID,
+			&accountId,
+			&event.Type,
+			&event.Outcome,
+			&event.IP,
+			&event.UserAgent,
+			&event.Actor,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return ListResult{}, fmt.Errorf("scanning row: %w", err)
+		}
+		event.AccountID = accountId.Int64
+
+		result.Events = append(result.Events, event)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return ListResult{}, fmt.Errorf("iterating rows: %w", err)
+	}
+
+	if len(result.Events) > limit {
+		result.Events = result.Events[:limit]
+		result.NextCursor = result.Events[limit-1].ID
+	}
+
+	return result, nil
+}
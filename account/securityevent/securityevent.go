@@ -0,0 +1,121 @@
+package securityevent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"coffee-chain-api/account"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Type is what happened to the account.
+type Type string
+
+const (
+	TypeLogin                Type = "login"
+	TypeLoginMFA             Type = "login_mfa"
+	TypeLogout               Type = "logout"
+	TypeRefresh              Type = "refresh"
+	TypePasswordChange       Type = "password_change"
+	TypePasswordResetRequest Type = "password_reset_request"
+	TypePasswordReset        Type = "password_reset"
+	TypeEmailChangeRequest   Type = "email_change_request"
+	TypeEmailChange          Type = "email_change"
+	TypeMFAEnable            Type = "mfa_enable"
+	TypeMFADisable           Type = "mfa_disable"
+	TypeAccountDelete        Type = "account_delete"
+	TypeAccountDeactivate    Type = "account_deactivate"
+)
+
+// Outcome is how the event ended.
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+	// OutcomeThrottled means the attempt was rejected before the credential was checked.
+	OutcomeThrottled Outcome = "throttled"
+	// OutcomeMFARequired means the password was valid, and the login waits for the second factor.
+	OutcomeMFARequired Outcome = "mfa_required"
+)
+
+type Event struct {
+	ID int64
+	// AccountID is zero if the event does not belong to any account, such as a failed login with an
+	// unregistered email address, or if the account is not looked up, such as a throttled login.
+	AccountID int64
+	Type      Type
+	Outcome   Outcome
+	IP        string
+	UserAgent string
+	// Actor is the identifier of who caused the event, see account.ActorIdentifier. It differs from the
+	// account when, for example, a manager deactivates a cashier.
+	Actor     string
+	CreatedAt time.Time
+}
+
+const (
+	// DefaultListLimit is the page size of Store.List if ListFilter.Limit is not set.
+	DefaultListLimit = 50
+	// MaximumListLimit is the largest page size of Store.List.
+	MaximumListLimit = 200
+)
+
+// ListFilter narrows down the events returned by Store.List. Zero values are not filtered on.
+type ListFilter struct {
+	AccountID     int64
+	Type          Type
+	Outcome       Outcome
+	IP            string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// Cursor is the NextCursor of the previous page, or zero for the first page.
+	Cursor int64
+	Limit  int
+}
+
+type ListResult struct {
+	Events []Event
+	// NextCursor is zero if there is no next page.
+	NextCursor int64
+}
+
+type Store interface {
+	Insert(ctx context.Context, event Event) error
+	// List returns events from the newest, paginated with the ID as the cursor.
+	List(ctx context.Context, filter ListFilter) (ListResult, error)
+}
+
+// Recorder records security events on behalf of the account flows.
+type Recorder struct {
+	store Store
+}
+
+func NewRecorder(store Store) (*Recorder, error) {
+	if store == nil {
+		return nil, fmt.Errorf("store is nil")
+	}
+
+	return &Recorder{store: store}, nil
+}
+
+// Record saves an event of the account, along with the client and actor from ctx. Failures are logged
+// rather than returned, recording must never fail the action that is being recorded.
+func (r *Recorder) Record(ctx context.Context, accountId int64, eventType Type, outcome Outcome) {
+	client := account.ClientFromContext(ctx)
+
+	err := r.store.Insert(ctx, Event{
+		AccountID: accountId,
+		Type:      eventType,
+		Outcome:   outcome,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		Actor:     account.ActorIdentifier(ctx),
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		log.Error().Err(err).Int64("account_id", accountId).Str("type", string(eventType)).Msg("recording security event")
+	}
+}
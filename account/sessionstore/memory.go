@@ -18,6 +18,30 @@ func (m *memorySessionStore) Remove(ctx context.Context, token string) error {
 	return nil
 }
 
+func (m *memorySessionStore) RemoveByAccount(ctx context.Context, accountId int64, keepTokens ...string) error {
+	m.m.Range(func(key, value any) bool {
+		token, ok := key.(string)
+		if !ok {
+			return true
+		}
+
+		session, ok := value.(account.Account)
+		if !ok || session.GetProfile().ID != accountId {
+			return true
+		}
+
+		for _, keepToken := range keepTokens {
+			if token == keepToken {
+				return true
+			}
+		}
+
+		m.m.Delete(token)
+		return true
+	})
+	return nil
+}
+
 func (m *memorySessionStore) Set(ctx context.Context, session account.Account, token string, expiry time.Time) error {
 	m.m.Store(token, session)
 	go func(key string, duration time.Duration) {
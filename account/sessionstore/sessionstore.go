@@ -22,4 +22,6 @@ type SessionStore interface {
 	Set(ctx context.Context, session account.Account, token string, expiry time.Time) error
 	Get(ctx context.Context, token string) (session account.Account, err error)
 	Remove(ctx context.Context, token string) error
+	// RemoveByAccount removes every session that belongs to the account, except the ones in keepTokens.
+	RemoveByAccount(ctx context.Context, accountId int64, keepTokens ...string) error
 }
@@ -0,0 +1,442 @@
+package totp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"coffee-chain-api/account"
+
+	"github.com/rs/zerolog/log"
+)
+
+type Repository struct {
+	db     *sql.DB
+	issuer string
+}
+
+// NewTOTPRepository creates a repository for two-factor enrollment. issuer is the name shown on the
+// authenticator app, such as the brand name.
+func NewTOTPRepository(db *sql.DB, issuer string) (*Repository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db is nil")
+	}
+	if issuer == "" {
+		return nil, fmt.Errorf("issuer is empty")
+	}
+
+	return &Repository{db: db, issuer: issuer}, nil
+}
+
+// Enrollment is the pending secret of an account, to be added into an authenticator app.
+type Enrollment struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+// Enroll starts a new enrollment for the account, replacing any pending one. The enrollment is not active
+// until it's confirmed with ConfirmEnrollment. It returns ErrAlreadyEnrolled if the account has a
+// confirmed enrollment.
+func (r *Repository) Enroll(ctx context.Context, userAccount account.Account) (Enrollment, error) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		return Enrollment{}, fmt.Errorf("generating secret: %w", err)
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return Enrollment{}, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	result, err := conn.ExecContext(
+		ctx,
+		`INSERT INTO
+			account_totp
+			(account_id,
+			 secret,
+			 created_at
+			 )
+		VALUES
+			($1, $2, $3)
+		ON CONFLICT (account_id) DO UPDATE SET
+			secret = EXCLUDED.secret,
+			last_used_step = NULL,
+			created_at = EXCLUDED.created_at
+		WHERE
+			account_totp.confirmed_at IS NULL`,
+		userAccount.GetProfile().ID,
+		secret,
+		time.Now(),
+	)
+	if err != nil {
+		return Enrollment{}, fmt.Errorf("executing insert query: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return Enrollment{}, fmt.Errorf("acquiring affected rows: %w", err)
+	}
+
+	if affected == 0 {
+		return Enrollment{}, ErrAlreadyEnrolled
+	}
+
+	return Enrollment{
+		Secret:          secret,
+		ProvisioningURI: ProvisioningURI(r.issuer, userAccount.GetProfile().Email, secret),
+	}, nil
+}
+
+// ConfirmEnrollment activates the pending enrollment with the first code from the authenticator app, and
+// returns the recovery codes in plain text. They can not be acquired again.
+func (r *Repository) ConfirmEnrollment(ctx context.Context, userAccount account.Account, code string) (recoveryCodes []string, err error) {
+	recoveryCodes = make([]string, 0, RecoveryCodeCount)
+	for i := 0; i < RecoveryCodeCount; i++ {
+		recoveryCode, err := GenerateRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("generating recovery code: %w", err)
+		}
+		recoveryCodes = append(recoveryCodes, recoveryCode)
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating transaction: %w", err)
+	}
+
+	var secret string
+	var confirmedAt sql.NullTime
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT
+				secret,
+				confirmed_at
+			FROM
+				account_totp
+			WHERE
+				account_id = $1
+			FOR UPDATE`,
+		userAccount.GetProfile().ID,
+	).Scan(&secret, &confirmedAt)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return nil, fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotEnrolled
+		}
+
+		return nil, fmt.Errorf("getting totp secret: %w", err)
+	}
+
+	if confirmedAt.Valid {
+		if e := tx.Rollback(); e != nil {
+			return nil, fmt.Errorf("rolling back transaction: %w (%s)", e, ErrAlreadyEnrolled)
+		}
+
+		return nil, ErrAlreadyEnrolled
+	}
+
+	step, ok, err := ValidateCode(secret, normalizeCode(code), time.Now())
+	if err != nil || !ok {
+		if e := tx.Rollback(); e != nil {
+			return nil, fmt.Errorf("rolling back transaction: %w (%v)", e, err)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("validating code: %w", err)
+		}
+
+		return nil, ErrInvalidCode
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE
+			account_totp
+		SET
+			confirmed_at = $1,
+			last_used_step = $2
+		WHERE
+			account_id = $3`,
+		time.Now(),
+		step,
+		userAccount.GetProfile().ID,
+	)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return nil, fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		return nil, fmt.Errorf("executing update query: %w", err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`DELETE FROM account_recovery_codes WHERE account_id = $1`,
+		userAccount.GetProfile().ID,
+	)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return nil, fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		return nil, fmt.Errorf("executing delete query: %w", err)
+	}
+
+	for _, recoveryCode := range recoveryCodes {
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO
+				account_recovery_codes
+				(account_id,
+				 code_hash,
+				 created_at
+				 )
+			VALUES
+				($1, $2, $3)`,
+			userAccount.GetProfile().ID,
+			hashRecoveryCode(recoveryCode),
+			time.Now(),
+		)
+		if err != nil {
+			if e := tx.Rollback(); e != nil {
+				return nil, fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+			}
+
+			return nil, fmt.Errorf("executing insert query: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return recoveryCodes, nil
+}
+
+// Disable removes the enrollment, pending or confirmed, and the recovery codes of the account. It returns
+// ErrNotEnrolled if there is nothing to remove.
+func (r *Repository) Disable(ctx context.Context, userAccount account.Account) error {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		return fmt.Errorf("creating transaction: %w", err)
+	}
+
+	result, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM account_totp WHERE account_id = $1`,
+		userAccount.GetProfile().ID,
+	)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		return fmt.Errorf("executing delete query: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		return fmt.Errorf("acquiring affected rows: %w", err)
+	}
+
+	if affected == 0 {
+		if e := tx.Rollback(); e != nil {
+			return fmt.Errorf("rolling back transaction: %w (%s)", e, ErrNotEnrolled)
+		}
+
+		return ErrNotEnrolled
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`DELETE FROM account_recovery_codes WHERE account_id = $1`,
+		userAccount.GetProfile().ID,
+	)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		return fmt.Errorf("executing delete query: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Enrolled reports whether the account has a confirmed enrollment.
+func (r *Repository) Enrolled(ctx context.Context, accountId int64) (bool, error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	var enrolled bool
+	err = conn.QueryRowContext(
+		ctx,
+		`SELECT EXISTS (
+			SELECT 1 FROM account_totp WHERE account_id = $1 AND confirmed_at IS NOT NULL
+		)`,
+		accountId,
+	).Scan(&enrolled)
+	if err != nil {
+		return false, fmt.Errorf("executing select query: %w", err)
+	}
+
+	return enrolled, nil
+}
+
+// Verify checks either a one-time code or a recovery code of an account with a confirmed enrollment. A
+// code is only accepted once: a one-time code can not be replayed within its period, and a recovery code
+// is used up.
+func (r *Repository) Verify(ctx context.Context, accountId int64, code string) (bool, error) {
+	code = normalizeCode(code)
+	if code == "" {
+		return false, nil
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	if isRecoveryCode(code) {
+		result, err := conn.ExecContext(
+			ctx,
+			`UPDATE
+				account_recovery_codes
+			SET
+				used_at = $1
+			WHERE
+				account_id = $2
+				AND code_hash = $3
+				AND used_at IS NULL
+				AND EXISTS (SELECT 1 FROM account_totp WHERE account_id = $2 AND confirmed_at IS NOT NULL)`,
+			time.Now(),
+			accountId,
+			hashRecoveryCode(code),
+		)
+		if err != nil {
+			return false, fmt.Errorf("executing update query: %w", err)
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return false, fmt.Errorf("acquiring affected rows: %w", err)
+		}
+
+		return affected > 0, nil
+	}
+
+	var secret string
+	err = conn.QueryRowContext(
+		ctx,
+		`SELECT
+				secret
+			FROM
+				account_totp
+			WHERE
+				account_id = $1
+				AND confirmed_at IS NOT NULL`,
+		accountId,
+	).Scan(&secret)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("getting totp secret: %w", err)
+	}
+
+	step, ok, err := ValidateCode(secret, code, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("validating code: %w", err)
+	}
+	if !ok {
+		return false, nil
+	}
+
+	// Only move forward, so a code of the same or an earlier step is rejected even on concurrent requests.
+	result, err := conn.ExecContext(
+		ctx,
+		`UPDATE
+			account_totp
+		SET
+			last_used_step = $1
+		WHERE
+			account_id = $2
+			AND (last_used_step IS NULL OR last_used_step < $1)`,
+		step,
+		accountId,
+	)
+	if err != nil {
+		return false, fmt.Errorf("executing update query: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("acquiring affected rows: %w", err)
+	}
+
+	return affected > 0, nil
+}
@@ -0,0 +1,199 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"coffee-chain-api/account"
+)
+
+// ErrInvalidCode indicates that the one-time code or recovery code is wrong, expired, or has been used.
+var ErrInvalidCode = errors.New("invalid two-factor code")
+
+// ErrAlreadyEnrolled indicates that the account has confirmed its enrollment, it must be disabled first.
+var ErrAlreadyEnrolled = errors.New("two-factor authentication is already enabled")
+
+// ErrNotEnrolled indicates that the account has not started, or has not confirmed, its enrollment.
+var ErrNotEnrolled = errors.New("two-factor authentication is not enabled")
+
+const (
+	// Digits is the length of the one-time codes.
+	Digits = 6
+	// Period is how long a single one-time code is valid for.
+	Period = time.Second * 30
+	// Skew is the number of periods before and after the current one that are accepted, to tolerate the
+	// clock drift of the authenticator device.
+	Skew = 1
+	// SecretLength is the secret size in bytes, as recommended by RFC 4226 for HMAC-SHA1.
+	SecretLength = 20
+	// RecoveryCodeCount is the number of recovery codes issued on enrollment.
+	RecoveryCodeCount = 10
+)
+
+// secretEncoding is how authenticator apps expect the secret, base32 without padding.
+var secretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret creates a new random secret, encoded in base32.
+func GenerateSecret() (string, error) {
+	secret := make([]byte, SecretLength)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", fmt.Errorf("reading random reader: %w", err)
+	}
+
+	return secretEncoding.EncodeToString(secret), nil
+}
+
+// GenerateCode returns the one-time code of the secret at the given time, as specified by RFC 6238 with
+// HMAC-SHA1.
+func GenerateCode(secret string, t time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return codeAt(key, timeStep(t)), nil
+}
+
+// ValidateCode checks the one-time code against the secret at the given time, within Skew. It returns the
+// time step the code belongs to, so the caller can reject a code that has been used before.
+func ValidateCode(secret string, code string, t time.Time) (step int64, ok bool, err error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if len(code) != Digits {
+		return 0, false, nil
+	}
+
+	current := timeStep(t)
+	for offset := int64(-Skew); offset <= Skew; offset++ {
+		if subtle.ConstantTimeCompare([]byte(codeAt(key, current+offset)), []byte(code)) == 1 {
+			return current + offset, true, nil
+		}
+	}
+
+	return 0, false, nil
+}
+
+// ProvisioningURI returns the otpauth URI to be rendered as a QR code and scanned by an authenticator app.
+// See https://github.com/google/google-authenticator/wiki/Key-Uri-Format
+func ProvisioningURI(issuer string, accountName string, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(Digits))
+	query.Set("period", fmt.Sprint(int(Period.Seconds())))
+
+	provisioningURI := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + accountName,
+		RawQuery: query.Encode(),
+	}
+
+	return provisioningURI.String()
+}
+
+// GenerateRecoveryCode creates a single-use code for when the authenticator device is not available,
+// formatted as two groups of five lowercase base32 characters, e.g. "abcde-fghij".
+func GenerateRecoveryCode() (string, error) {
+	raw := make([]byte, 10)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return "", fmt.Errorf("reading random reader: %w", err)
+	}
+
+	encoded := strings.ToLower(secretEncoding.EncodeToString(raw))[:10]
+	return encoded[:5] + "-" + encoded[5:], nil
+}
+
+// isRecoveryCode tells apart a recovery code from a one-time code, after normalizeCode.
+func isRecoveryCode(code string) bool {
+	return len(code) != Digits || strings.Contains(code, "-")
+}
+
+// normalizeCode removes the spaces that users tend to type in, and lowercases recovery codes.
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.Join(strings.Fields(code), ""))
+}
+
+// hashRecoveryCode hashes the recovery code before it touches the database. Like the password reset
+// tokens, the code has enough entropy that a plain SHA-256 is sufficient.
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+	key, err := secretEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return nil, fmt.Errorf("decoding secret: %w", err)
+	}
+
+	return key, nil
+}
+
+func timeStep(t time.Time) int64 {
+	return t.Unix() / int64(Period.Seconds())
+}
+
+// codeAt implements the HOTP algorithm of RFC 4226 for the counter.
+func codeAt(key []byte, counter int64) string {
+	var message [8]byte
+	binary.BigEndian.PutUint64(message[:], uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	truncated := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < Digits; i++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", Digits, truncated%modulo)
+}
+
+// DefaultRequiredTypes are the account types that can change stores and products, or handle payments.
+var DefaultRequiredTypes = []account.Type{account.TypeManagement, account.TypeMerchantCashier}
+
+// Policy decides which account types must enable two-factor authentication before they can do anything
+// else.
+type Policy struct {
+	requiredTypes map[account.Type]struct{}
+}
+
+func NewPolicy(requiredTypes ...account.Type) (*Policy, error) {
+	policy := &Policy{requiredTypes: make(map[account.Type]struct{}, len(requiredTypes))}
+	for _, t := range requiredTypes {
+		if t == account.TypeUnspecified {
+			return nil, fmt.Errorf("required type must not be unspecified")
+		}
+		policy.requiredTypes[t] = struct{}{}
+	}
+
+	return policy, nil
+}
+
+// Required reports whether accounts of the type must enable two-factor authentication.
+func (p *Policy) Required(t account.Type) bool {
+	_, ok := p.requiredTypes[t]
+	return ok
+}
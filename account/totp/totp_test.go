@@ -0,0 +1,172 @@
+package totp_test
+
+import (
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"coffee-chain-api/account"
+	"coffee-chain-api/account/totp"
+)
+
+// rfc6238Secret is the SHA-1 seed of the RFC 6238 test vectors, "12345678901234567890", in base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateCode(t *testing.T) {
+	// The RFC lists 8 digits codes, these are their last 6 digits.
+	testCases := []struct {
+		unix   int64
+		expect string
+	}{
+		{unix: 59, expect: "287082"},
+		{unix: 1111111109, expect: "081804"},
+		{unix: 1111111111, expect: "050471"},
+		{unix: 1234567890, expect: "005924"},
+		{unix: 2000000000, expect: "279037"},
+		{unix: 20000000000, expect: "353130"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.expect, func(t *testing.T) {
+			code, err := totp.GenerateCode(rfc6238Secret, time.Unix(tt.unix, 0))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+
+			if code != tt.expect {
+				t.Errorf("expecting %s, got %s instead", tt.expect, code)
+			}
+		})
+	}
+
+	t.Run("invalid secret", func(t *testing.T) {
+		_, err := totp.GenerateCode("not base32!", time.Now())
+		if err == nil {
+			t.Error("expecting an error, got nil")
+		}
+	})
+}
+
+func TestValidateCode(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatalf("generating secret: %s", err.Error())
+	}
+
+	now := time.Date(2024, 3, 11, 9, 0, 10, 0, time.UTC)
+	code, err := totp.GenerateCode(secret, now)
+	if err != nil {
+		t.Fatalf("generating code: %s", err.Error())
+	}
+
+	testCases := []struct {
+		name   string
+		code   string
+		at     time.Time
+		expect bool
+	}{
+		{name: "same period", code: code, at: now, expect: true},
+		{name: "previous period", code: code, at: now.Add(totp.Period), expect: true},
+		{name: "next period", code: code, at: now.Add(-totp.Period), expect: true},
+		{name: "too late", code: code, at: now.Add(totp.Period * 2), expect: false},
+		{name: "too early", code: code, at: now.Add(-totp.Period * 2), expect: false},
+		{name: "wrong length", code: code[:5], at: now, expect: false},
+		{name: "empty", code: "", at: now, expect: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok, err := totp.ValidateCode(secret, tt.code, tt.at)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+
+			if ok != tt.expect {
+				t.Errorf("expecting %t, got %t instead", tt.expect, ok)
+			}
+
+			if ok && step != now.Unix()/30 {
+				t.Errorf("expecting step %d, got %d instead", now.Unix()/30, step)
+			}
+		})
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	provisioningURI, err := url.Parse(totp.ProvisioningURI("Coffee Chain", "someone@example.com", rfc6238Secret))
+	if err != nil {
+		t.Fatalf("parsing provisioning uri: %s", err.Error())
+	}
+
+	if provisioningURI.Scheme != "otpauth" || provisioningURI.Host != "totp" {
+		t.Errorf("expecting otpauth://totp, got %s://%s instead", provisioningURI.Scheme, provisioningURI.Host)
+	}
+
+	if provisioningURI.Path != "/Coffee Chain:someone@example.com" {
+		t.Errorf("expecting label of issuer and account name, got %s instead", provisioningURI.Path)
+	}
+
+	query := provisioningURI.Query()
+	expect := map[string]string{
+		"secret":    rfc6238Secret,
+		"issuer":    "Coffee Chain",
+		"algorithm": "SHA1",
+		"digits":    "6",
+		"period":    "30",
+	}
+	for key, value := range expect {
+		if query.Get(key) != value {
+			t.Errorf("expecting %s to be %s, got %s instead", key, value, query.Get(key))
+		}
+	}
+}
+
+func TestGenerateRecoveryCode(t *testing.T) {
+	pattern := regexp.MustCompile(`^[a-z2-7]{5}-[a-z2-7]{5}$`)
+	seen := make(map[string]struct{})
+
+	for i := 0; i < 100; i++ {
+		code, err := totp.GenerateRecoveryCode()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err.Error())
+		}
+
+		if !pattern.MatchString(code) {
+			t.Errorf("expecting code to match %s, got %s instead", pattern.String(), code)
+		}
+
+		if _, ok := seen[code]; ok {
+			t.Errorf("expecting unique codes, got %s twice", code)
+		}
+		seen[code] = struct{}{}
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	policy, err := totp.NewPolicy(totp.DefaultRequiredTypes...)
+	if err != nil {
+		t.Fatalf("creating policy: %s", err.Error())
+	}
+
+	testCases := []struct {
+		input  account.Type
+		expect bool
+	}{
+		{input: account.TypeCustomer, expect: false},
+		{input: account.TypeMerchantCashier, expect: true},
+		{input: account.TypeManagement, expect: true},
+		{input: account.TypeUnspecified, expect: false},
+	}
+
+	for _, tt := range testCases {
+		if got := policy.Required(tt.input); got != tt.expect {
+			t.Errorf("%s: expecting %t, got %t instead", tt.input, tt.expect, got)
+		}
+	}
+
+	_, err = totp.NewPolicy(account.TypeUnspecified)
+	if err == nil {
+		t.Error("expecting an error for unspecified type, got nil")
+	}
+}
@@ -23,3 +23,17 @@ func (a Type) String() string {
 		return ""
 	}
 }
+
+// ParseType parses the account type from its snake_cased name, e.g. "merchant_cashier".
+func ParseType(s string) (Type, bool) {
+	switch s {
+	case "customer":
+		return TypeCustomer, true
+	case "merchant_cashier":
+		return TypeMerchantCashier, true
+	case "management":
+		return TypeManagement, true
+	default:
+		return TypeUnspecified, false
+	}
+}
@@ -0,0 +1,30 @@
+package account_test
+
+import (
+	"testing"
+
+	"coffee-chain-api/account"
+)
+
+func TestParseType(t *testing.T) {
+	testCases := []struct {
+		input  string
+		expect account.Type
+		ok     bool
+	}{
+		{input: "customer", expect: account.TypeCustomer, ok: true},
+		{input: "merchant_cashier", expect: account.TypeMerchantCashier, ok: true},
+		{input: "management", expect: account.TypeManagement, ok: true},
+		{input: "Management", expect: account.TypeUnspecified, ok: false},
+		{input: "", expect: account.TypeUnspecified, ok: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok := account.ParseType(tt.input)
+			if got != tt.expect || ok != tt.ok {
+				t.Errorf("expecting %s (%t), got %s (%t) instead", tt.expect, tt.ok, got, ok)
+			}
+		})
+	}
+}
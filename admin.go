@@ -0,0 +1,387 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"os"
+	"strings"
+
+	"coffee-chain-api/account"
+	"coffee-chain-api/account/accountstore"
+	"coffee-chain-api/account/password"
+	"coffee-chain-api/account/securityevent"
+	"coffee-chain-api/store"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/term"
+)
+
+// adminCommand manages accounts directly on the database, for the cases that can not go through the API,
+// such as creating the very first management account.
+func adminCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "admin",
+		Usage: "Manage accounts directly on the database",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "database-url",
+				Usage:    "PostgreSQL connection string",
+				EnvVars:  []string{"DATABASE_URL"},
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:    "password-hasher",
+				Usage:   "Password hashing algorithm for new passwords, one of argon2, bcrypt, or pbkdf2",
+				EnvVars: []string{"PASSWORD_HASHER"},
+				Value:   "argon2",
+			},
+		},
+		Subcommands: []*cli.Command{
+			{
+				Name:  "create-user",
+				Usage: "Create an account, prompting for any value that is not given",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "name", Usage: "Account name"},
+					&cli.StringFlag{Name: "email", Usage: "Account email address"},
+					&cli.StringFlag{Name: "type", Usage: "Account type, one of customer, merchant_cashier, or management"},
+					&cli.Int64Flag{Name: "store-id", Usage: "Store the merchant cashier is assigned to"},
+				},
+				Action: adminCreateUser,
+			},
+			{
+				Name:  "set-password",
+				Usage: "Set a new password for an account",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "email", Usage: "Account email address"},
+				},
+				Action: adminSetPassword,
+			},
+			{
+				Name:  "disable",
+				Usage: "Deactivate an account, it can no longer login",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "email", Usage: "Account email address"},
+				},
+				Action: adminDisable,
+			},
+		},
+	}
+}
+
+func adminCreateUser(c *cli.Context) error {
+	input := bufio.NewReader(os.Stdin)
+
+	name, err := promptValue(input, c.String("name"), "Name")
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	email, err := promptEmail(input, c.String("email"))
+	if err != nil {
+		return err
+	}
+
+	rawType, err := promptValue(input, c.String("type"), "Type (customer, merchant_cashier, management)")
+	if err != nil {
+		return err
+	}
+	accountType, ok := account.ParseType(rawType)
+	if !ok {
+		return fmt.Errorf("type must be one of customer, merchant_cashier, or management")
+	}
+
+	storeId := c.Int64("store-id")
+	if accountType == account.TypeMerchantCashier && storeId <= 0 {
+		return fmt.Errorf("store-id is required for merchant cashier accounts")
+	}
+	if accountType != account.TypeMerchantCashier {
+		storeId = 0
+	}
+
+	plainPassword, err := promptNewPassword(c.Context, input, name, email)
+	if err != nil {
+		return err
+	}
+
+	db, accountStore, err := openAccountStore(c)
+	if err != nil {
+		return err
+	}
+	defer closeDatabase(db)
+
+	if storeId > 0 {
+		err = validateStoreID(c.Context, db, storeId)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = accountStore.Insert(c.Context, accountstore.RawAccount{
+		Name:          name,
+		Email:         email,
+		PlainPassword: plainPassword,
+		Type:          accountType,
+		StoreID:       storeId,
+	})
+	if err != nil {
+		if errors.Is(err, accountstore.ErrDuplicateEntry) {
+			return fmt.Errorf("email address is used by another account")
+		}
+
+		return fmt.Errorf("inserting account: %w", err)
+	}
+
+	_, err = fmt.Fprintf(c.App.Writer, "Created %s account for %s\n", accountType, email)
+	return err
+}
+
+func adminSetPassword(c *cli.Context) error {
+	input := bufio.NewReader(os.Stdin)
+
+	email, err := promptEmail(input, c.String("email"))
+	if err != nil {
+		return err
+	}
+
+	db, accountStore, err := openAccountStore(c)
+	if err != nil {
+		return err
+	}
+	defer closeDatabase(db)
+
+	userAccount, err := accountStore.GetByEmail(c.Context, email)
+	if err != nil {
+		if errors.Is(err, accountstore.ErrNotFound) {
+			return fmt.Errorf("there is no active account with email %s", email)
+		}
+
+		return fmt.Errorf("acquiring account by email: %w", err)
+	}
+
+	plainPassword, err := promptNewPassword(c.Context, input, userAccount.GetProfile().Name, email)
+	if err != nil {
+		return err
+	}
+
+	err = accountStore.UpdatePassword(c.Context, userAccount, plainPassword)
+	if err != nil {
+		return fmt.Errorf("updating password: %w", err)
+	}
+
+	// Sessions live in the server's memory, they are not reachable from here.
+	_, err = fmt.Fprintf(c.App.Writer, "Password updated for %s, existing sessions stay valid until they expire or the server restarts\n", email)
+	return err
+}
+
+func adminDisable(c *cli.Context) error {
+	input := bufio.NewReader(os.Stdin)
+
+	email, err := promptEmail(input, c.String("email"))
+	if err != nil {
+		return err
+	}
+
+	db, accountStore, err := openAccountStore(c)
+	if err != nil {
+		return err
+	}
+	defer closeDatabase(db)
+
+	userAccount, err := accountStore.GetByEmail(c.Context, email)
+	if err != nil {
+		if errors.Is(err, accountstore.ErrNotFound) {
+			return fmt.Errorf("there is no active account with email %s", email)
+		}
+
+		return fmt.Errorf("acquiring account by email: %w", err)
+	}
+
+	err = accountStore.Deactivate(c.Context, userAccount)
+	if err != nil {
+		return fmt.Errorf("deactivating account: %w", err)
+	}
+
+	securityEventStore, err := securityevent.NewSecurityEventRepository(db)
+	if err != nil {
+		return fmt.Errorf("creating security event store: %w", err)
+	}
+
+	securityEvents, err := securityevent.NewRecorder(securityEventStore)
+	if err != nil {
+		return fmt.Errorf("creating security event recorder: %w", err)
+	}
+
+	// There is no actor in the context, the event is recorded as done by the system.
+	securityEvents.Record(c.Context, userAccount.GetProfile().ID, securityevent.TypeAccountDeactivate, securityevent.OutcomeSuccess)
+
+	_, err = fmt.Fprintf(c.App.Writer, "Deactivated %s, existing sessions stay valid until they expire or the server restarts\n", email)
+	return err
+}
+
+func openAccountStore(c *cli.Context) (*sql.DB, accountstore.AccountStore, error) {
+	passwordHasher, err := newPasswordHasher(c.String("password-hasher"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db, err := sql.Open("pgx", c.String("database-url"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening database: %w", err)
+	}
+
+	err = db.PingContext(c.Context)
+	if err != nil {
+		closeDatabase(db)
+		return nil, nil, fmt.Errorf("connecting to database: %w", err)
+	}
+
+	accountStore, err := accountstore.NewRepository(db, passwordHasher)
+	if err != nil {
+		closeDatabase(db)
+		return nil, nil, fmt.Errorf("creating account store: %w", err)
+	}
+
+	return db, accountStore, nil
+}
+
+// validateStoreID checks that the store a cashier is assigned to exists.
+func validateStoreID(ctx context.Context, db *sql.DB, storeId int64) error {
+	stores, err := store.NewRepository(db)
+	if err != nil {
+		return fmt.Errorf("creating store repository: %w", err)
+	}
+
+	_, err = stores.GetByID(ctx, storeId)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return fmt.Errorf("there is no store with id %d", storeId)
+		}
+
+		return fmt.Errorf("acquiring store: %w", err)
+	}
+
+	return nil
+}
+
+func closeDatabase(db *sql.DB) {
+	err := db.Close()
+	if err != nil {
+		log.Error().Err(err).Msg("closing database")
+	}
+}
+
+func newPasswordHasher(algorithm string) (password.Hasher, error) {
+	switch algorithm {
+	case "argon2":
+		return password.NewArgonPasswordHasher(password.Argon2Config{})
+	case "bcrypt":
+		return password.NewBcryptPasswordHasher(password.BcryptDefaultRounds)
+	case "pbkdf2":
+		return password.NewPbdkf2PasswordHasher(password.Pbdkf2Config{})
+	default:
+		return nil, fmt.Errorf("password hasher must be one of argon2, bcrypt, or pbkdf2")
+	}
+}
+
+// promptValue returns value if it's set, otherwise asks for it on the standard input.
+func promptValue(input *bufio.Reader, value string, label string) (string, error) {
+	if value != "" {
+		return strings.TrimSpace(value), nil
+	}
+
+	_, err := fmt.Fprintf(os.Stderr, "%s: ", label)
+	if err != nil {
+		return "", err
+	}
+
+	line, err := input.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("reading %s: %w", strings.ToLower(label), err)
+	}
+
+	return strings.TrimSpace(line), nil
+}
+
+func promptEmail(input *bufio.Reader, value string) (string, error) {
+	email, err := promptValue(input, value, "Email")
+	if err != nil {
+		return "", err
+	}
+
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Name != "" {
+		return "", fmt.Errorf("email address is invalid")
+	}
+
+	return address.Address, nil
+}
+
+// promptNewPassword asks for a password and checks it against the default password policy. On a terminal,
+// the password is not echoed and has to be typed twice. Otherwise, it's read as a single line, so it can
+// be piped in from a script.
+func promptNewPassword(ctx context.Context, input *bufio.Reader, personalInputs ...string) (string, error) {
+	var plainPassword string
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		first, err := readHiddenLine(fd, "Password")
+		if err != nil {
+			return "", err
+		}
+
+		second, err := readHiddenLine(fd, "Confirm password")
+		if err != nil {
+			return "", err
+		}
+
+		if first != second {
+			return "", fmt.Errorf("passwords do not match")
+		}
+		plainPassword = first
+	} else {
+		line, err := input.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", fmt.Errorf("reading password: %w", err)
+		}
+		plainPassword = strings.TrimRight(line, "\r\n")
+	}
+
+	policy, err := password.NewPolicy(password.PolicyConfig{})
+	if err != nil {
+		return "", fmt.Errorf("creating password policy: %w", err)
+	}
+
+	err = policy.Check(ctx, plainPassword, personalInputs...)
+	if err != nil {
+		return "", err
+	}
+
+	return plainPassword, nil
+}
+
+func readHiddenLine(fd int, label string) (string, error) {
+	_, err := fmt.Fprintf(os.Stderr, "%s: ", label)
+	if err != nil {
+		return "", err
+	}
+
+	line, err := term.ReadPassword(fd)
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", strings.ToLower(label), err)
+	}
+
+	_, err = fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+
+	return string(line), nil
+}
@@ -0,0 +1,18 @@
+package mailer
+
+import (
+	"context"
+)
+
+// Mail is a plain text email message.
+type Mail struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Mailer provides a generic interface for sending emails. It can be implemented with SMTP, or any
+// email provider's HTTP API.
+type Mailer interface {
+	Send(ctx context.Context, mail Mail) error
+}
@@ -34,6 +34,7 @@ func App() *cli.App {
 				HelpName:               "",
 				CustomHelpTemplate:     "",
 			},
+			adminCommand(),
 		},
 	}
 }
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE password_reset_tokens
+(
+    id          BIGSERIAL PRIMARY KEY NOT NULL,
+    account_id  BIGINT                NOT NULL REFERENCES user_accounts (id) ON DELETE CASCADE,
+    token_hash  VARCHAR(64)           NOT NULL,
+    expires_at  TIMESTAMPTZ           NOT NULL,
+    consumed_at TIMESTAMPTZ           NULL,
+    created_at  TIMESTAMPTZ           NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX unq_password_reset_tokens_token_hash ON password_reset_tokens (token_hash);
+CREATE INDEX idx_password_reset_tokens_account_id ON password_reset_tokens (account_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS password_reset_tokens;
+-- +goose StatementEnd
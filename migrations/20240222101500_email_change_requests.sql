@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE email_change_requests
+(
+    id          BIGSERIAL PRIMARY KEY NOT NULL,
+    account_id  BIGINT                NOT NULL REFERENCES user_accounts (id) ON DELETE CASCADE,
+    new_email   VARCHAR(255)          NOT NULL,
+    code_hash   VARCHAR(64)           NOT NULL,
+    attempts    SMALLINT              NOT NULL DEFAULT 0,
+    expires_at  TIMESTAMPTZ           NOT NULL,
+    consumed_at TIMESTAMPTZ           NULL,
+    created_at  TIMESTAMPTZ           NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_email_change_requests_account_id ON email_change_requests (account_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS email_change_requests;
+-- +goose StatementEnd
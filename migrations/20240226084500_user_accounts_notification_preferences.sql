@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user_accounts
+    ADD COLUMN notify_push_promotional    BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN notify_push_transactional  BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN notify_email_promotional   BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN notify_email_transactional BOOLEAN NOT NULL DEFAULT TRUE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_accounts
+    DROP COLUMN IF EXISTS notify_push_promotional,
+    DROP COLUMN IF EXISTS notify_push_transactional,
+    DROP COLUMN IF EXISTS notify_email_promotional,
+    DROP COLUMN IF EXISTS notify_email_transactional;
+-- +goose StatementEnd
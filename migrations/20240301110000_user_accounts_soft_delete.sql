@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user_accounts
+    ADD COLUMN deleted_at    TIMESTAMPTZ NULL,
+    ADD COLUMN anonymised_at TIMESTAMPTZ NULL;
+
+-- Deleted accounts keep their email during the grace period, it should not block a new registration.
+DROP INDEX IF EXISTS unq_user_accounts_email;
+CREATE UNIQUE INDEX unq_user_accounts_email ON user_accounts (email) WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS unq_user_accounts_email;
+CREATE UNIQUE INDEX unq_user_accounts_email ON user_accounts (email);
+
+ALTER TABLE user_accounts
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS anonymised_at;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user_accounts
+    ADD COLUMN store_id BIGINT NULL;
+
+CREATE INDEX idx_user_accounts_store_id ON user_accounts (store_id);
+CREATE INDEX idx_user_accounts_type ON user_accounts (type);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_user_accounts_type;
+DROP INDEX IF EXISTS idx_user_accounts_store_id;
+
+ALTER TABLE user_accounts
+    DROP COLUMN IF EXISTS store_id;
+-- +goose StatementEnd
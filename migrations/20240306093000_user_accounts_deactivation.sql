@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user_accounts
+    ADD COLUMN deactivated_at TIMESTAMPTZ NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_accounts
+    DROP COLUMN IF EXISTS deactivated_at;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE account_totp
+(
+    account_id     BIGINT PRIMARY KEY NOT NULL REFERENCES user_accounts (id) ON DELETE CASCADE,
+    secret         VARCHAR(64)        NOT NULL,
+    -- The enrollment is pending until the first code is confirmed.
+    confirmed_at   TIMESTAMPTZ        NULL,
+    -- The time step of the last accepted code, so the same code can not be used twice.
+    last_used_step BIGINT             NULL,
+    created_at     TIMESTAMPTZ        NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE account_recovery_codes
+(
+    id         BIGSERIAL PRIMARY KEY NOT NULL,
+    account_id BIGINT                NOT NULL REFERENCES user_accounts (id) ON DELETE CASCADE,
+    code_hash  VARCHAR(64)           NOT NULL,
+    used_at    TIMESTAMPTZ           NULL,
+    created_at TIMESTAMPTZ           NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX unq_account_recovery_codes_account_id_code_hash ON account_recovery_codes (account_id, code_hash);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS account_recovery_codes;
+DROP TABLE IF EXISTS account_totp;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE security_events
+(
+    id         BIGSERIAL PRIMARY KEY NOT NULL,
+    -- Failed logins with an unregistered email address do not belong to any account.
+    account_id BIGINT                NULL REFERENCES user_accounts (id) ON DELETE CASCADE,
+    type       VARCHAR(64)           NOT NULL,
+    outcome    VARCHAR(32)           NOT NULL,
+    ip_address VARCHAR(64)           NOT NULL DEFAULT '',
+    user_agent VARCHAR(512)          NOT NULL DEFAULT '',
+    actor      VARCHAR(255)          NOT NULL,
+    created_at TIMESTAMPTZ           NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_security_events_account_id ON security_events (account_id, id);
+CREATE INDEX idx_security_events_ip_address ON security_events (ip_address, id);
+CREATE INDEX idx_security_events_created_at ON security_events (created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS security_events;
+-- +goose StatementEnd
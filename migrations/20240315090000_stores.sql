@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE stores
+(
+    id         BIGSERIAL PRIMARY KEY NOT NULL,
+    name       VARCHAR(255)          NOT NULL,
+    address    VARCHAR(1023)         NOT NULL,
+    latitude   DOUBLE PRECISION      NOT NULL,
+    longitude  DOUBLE PRECISION      NOT NULL,
+    phone      VARCHAR(32)           NOT NULL DEFAULT '',
+    timezone   VARCHAR(63)           NOT NULL DEFAULT 'Asia/Jakarta',
+    status     SMALLINT              NOT NULL DEFAULT 1,
+    created_at TIMESTAMPTZ           NOT NULL DEFAULT NOW(),
+    created_by VARCHAR(63)           NOT NULL,
+    updated_at TIMESTAMPTZ           NOT NULL DEFAULT NOW(),
+    updated_by VARCHAR(63)           NOT NULL,
+    deleted_at TIMESTAMPTZ           NULL,
+
+    CONSTRAINT chk_stores_latitude CHECK (latitude BETWEEN -90 AND 90),
+    CONSTRAINT chk_stores_longitude CHECK (longitude BETWEEN -180 AND 180)
+);
+
+CREATE INDEX idx_stores_status ON stores (status) WHERE deleted_at IS NULL;
+
+-- The cashiers' stores, added before the stores themselves. Stores are soft-deleted, so the row stays.
+ALTER TABLE user_accounts
+    ADD CONSTRAINT fk_user_accounts_store_id FOREIGN KEY (store_id) REFERENCES stores (id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_accounts
+    DROP CONSTRAINT IF EXISTS fk_user_accounts_store_id;
+
+DROP TABLE IF EXISTS stores;
+-- +goose StatementEnd
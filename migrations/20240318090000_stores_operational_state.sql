@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE stores
+    ADD COLUMN operational_state SMALLINT     NOT NULL DEFAULT 0,
+    ADD COLUMN pause_reason      VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN resume_at         TIMESTAMPTZ  NULL,
+    ADD COLUMN state_updated_at  TIMESTAMPTZ  NULL,
+    ADD COLUMN state_updated_by  VARCHAR(63)  NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE stores
+    DROP COLUMN IF EXISTS operational_state,
+    DROP COLUMN IF EXISTS pause_reason,
+    DROP COLUMN IF EXISTS resume_at,
+    DROP COLUMN IF EXISTS state_updated_at,
+    DROP COLUMN IF EXISTS state_updated_by;
+-- +goose StatementEnd
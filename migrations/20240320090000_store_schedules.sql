@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Times are in minutes since midnight in the store's timezone. A range that closes at or before it opens
+-- goes past midnight.
+CREATE TABLE store_opening_hours
+(
+    id        BIGSERIAL PRIMARY KEY NOT NULL,
+    store_id  BIGINT                NOT NULL REFERENCES stores (id) ON DELETE CASCADE,
+    weekday   SMALLINT              NOT NULL,
+    opens_at  SMALLINT              NOT NULL,
+    closes_at SMALLINT              NOT NULL,
+
+    CONSTRAINT chk_store_opening_hours_weekday CHECK (weekday BETWEEN 0 AND 6),
+    CONSTRAINT chk_store_opening_hours_opens_at CHECK (opens_at BETWEEN 0 AND 1439),
+    CONSTRAINT chk_store_opening_hours_closes_at CHECK (closes_at BETWEEN 0 AND 1440)
+);
+
+CREATE INDEX idx_store_opening_hours_store_id ON store_opening_hours (store_id);
+
+-- An exception replaces the opening hours of a date, with a row per range. A date the store is closed
+-- altogether has a single row without a range.
+CREATE TABLE store_schedule_exceptions
+(
+    id        BIGSERIAL PRIMARY KEY NOT NULL,
+    store_id  BIGINT                NOT NULL REFERENCES stores (id) ON DELETE CASCADE,
+    date      DATE                  NOT NULL,
+    note      VARCHAR(255)          NOT NULL DEFAULT '',
+    opens_at  SMALLINT              NULL,
+    closes_at SMALLINT              NULL,
+
+    CONSTRAINT chk_store_schedule_exceptions_range CHECK ((opens_at IS NULL) = (closes_at IS NULL))
+);
+
+CREATE INDEX idx_store_schedule_exceptions_store_id_date ON store_schedule_exceptions (store_id, date);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS store_schedule_exceptions;
+DROP TABLE IF EXISTS store_opening_hours;
+-- +goose StatementEnd
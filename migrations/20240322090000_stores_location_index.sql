@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- For the bounding box of the nearby store search.
+CREATE INDEX idx_stores_location ON stores (latitude, longitude) WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_stores_location;
+-- +goose StatementEnd
@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE product_categories
+(
+    id         BIGSERIAL PRIMARY KEY NOT NULL,
+    name       VARCHAR(255)          NOT NULL,
+    position   INTEGER               NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ           NOT NULL DEFAULT NOW(),
+    created_by VARCHAR(63)           NOT NULL,
+    updated_at TIMESTAMPTZ           NOT NULL DEFAULT NOW(),
+    updated_by VARCHAR(63)           NOT NULL,
+    deleted_at TIMESTAMPTZ           NULL
+);
+
+CREATE TABLE products
+(
+    id          BIGSERIAL PRIMARY KEY NOT NULL,
+    category_id BIGINT                NOT NULL REFERENCES product_categories (id),
+    name        VARCHAR(255)          NOT NULL,
+    description VARCHAR(2047)         NOT NULL DEFAULT '',
+    image_url   VARCHAR(2047)         NOT NULL DEFAULT '',
+    -- In IDR minor units, 100 to the Rupiah.
+    base_price  BIGINT                NOT NULL,
+    active      BOOLEAN               NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMPTZ           NOT NULL DEFAULT NOW(),
+    created_by  VARCHAR(63)           NOT NULL,
+    updated_at  TIMESTAMPTZ           NOT NULL DEFAULT NOW(),
+    updated_by  VARCHAR(63)           NOT NULL,
+    deleted_at  TIMESTAMPTZ           NULL,
+
+    CONSTRAINT chk_products_base_price CHECK (base_price >= 0)
+);
+
+CREATE INDEX idx_products_category_id ON products (category_id) WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS products;
+DROP TABLE IF EXISTS product_categories;
+-- +goose StatementEnd
@@ -0,0 +1,51 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE modifier_groups
+(
+    id             BIGSERIAL PRIMARY KEY NOT NULL,
+    name           VARCHAR(255)          NOT NULL,
+    min_selections INTEGER               NOT NULL DEFAULT 0,
+    max_selections INTEGER               NOT NULL DEFAULT 1,
+    created_at     TIMESTAMPTZ           NOT NULL DEFAULT NOW(),
+    created_by     VARCHAR(63)           NOT NULL,
+    updated_at     TIMESTAMPTZ           NOT NULL DEFAULT NOW(),
+    updated_by     VARCHAR(63)           NOT NULL,
+    deleted_at     TIMESTAMPTZ           NULL,
+
+    CONSTRAINT chk_modifier_groups_selections CHECK (min_selections >= 0 AND max_selections >= min_selections)
+);
+
+CREATE TABLE modifier_options
+(
+    id          BIGSERIAL PRIMARY KEY NOT NULL,
+    group_id    BIGINT                NOT NULL REFERENCES modifier_groups (id),
+    name        VARCHAR(255)          NOT NULL,
+    -- In IDR minor units, negative for options that cost less.
+    price_delta BIGINT                NOT NULL DEFAULT 0,
+    is_default  BOOLEAN               NOT NULL DEFAULT FALSE,
+    position    INTEGER               NOT NULL DEFAULT 0,
+    created_at  TIMESTAMPTZ           NOT NULL DEFAULT NOW(),
+    -- Options are soft-deleted so their IDs are never reused by another option.
+    deleted_at  TIMESTAMPTZ           NULL
+);
+
+CREATE INDEX idx_modifier_options_group_id ON modifier_options (group_id) WHERE deleted_at IS NULL;
+
+CREATE TABLE product_modifier_groups
+(
+    product_id        BIGINT  NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    modifier_group_id BIGINT  NOT NULL REFERENCES modifier_groups (id) ON DELETE CASCADE,
+    position          INTEGER NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (product_id, modifier_group_id)
+);
+
+CREATE INDEX idx_product_modifier_groups_modifier_group_id ON product_modifier_groups (modifier_group_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS product_modifier_groups;
+DROP TABLE IF EXISTS modifier_options;
+DROP TABLE IF EXISTS modifier_groups;
+-- +goose StatementEnd
@@ -0,0 +1,56 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE orders
+(
+    id         BIGSERIAL PRIMARY KEY NOT NULL,
+    account_id BIGINT                NOT NULL REFERENCES user_accounts (id),
+    store_id   BIGINT                NOT NULL REFERENCES stores (id),
+    status     SMALLINT              NOT NULL,
+    -- In IDR minor units, the sum of the line subtotals.
+    total      BIGINT                NOT NULL,
+    created_at TIMESTAMPTZ           NOT NULL DEFAULT NOW(),
+    created_by VARCHAR(63)           NOT NULL,
+    updated_at TIMESTAMPTZ           NOT NULL DEFAULT NOW(),
+    updated_by VARCHAR(63)           NOT NULL
+);
+
+-- For the orders of an account, by when they were placed.
+CREATE INDEX idx_orders_account_id_created_at ON orders (account_id, created_at);
+
+-- The names and prices are copied from the catalog, so the order stays as it was placed.
+CREATE TABLE order_lines
+(
+    id           BIGSERIAL PRIMARY KEY NOT NULL,
+    order_id     BIGINT                NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    position     INTEGER               NOT NULL,
+    product_id   BIGINT                NOT NULL REFERENCES products (id),
+    product_name VARCHAR(255)          NOT NULL,
+    quantity     INTEGER               NOT NULL,
+    -- In IDR minor units, the base price plus the price deltas of the options.
+    unit_price   BIGINT                NOT NULL,
+
+    CONSTRAINT chk_order_lines_quantity CHECK (quantity > 0),
+    CONSTRAINT chk_order_lines_unit_price CHECK (unit_price >= 0)
+);
+
+CREATE INDEX idx_order_lines_order_id ON order_lines (order_id);
+
+CREATE TABLE order_line_options
+(
+    order_line_id BIGINT       NOT NULL REFERENCES order_lines (id) ON DELETE CASCADE,
+    position      INTEGER      NOT NULL,
+    option_id     BIGINT       NOT NULL REFERENCES modifier_options (id),
+    option_name   VARCHAR(255) NOT NULL,
+    -- In IDR minor units, negative for options that cost less.
+    price_delta   BIGINT       NOT NULL,
+
+    PRIMARY KEY (order_line_id, option_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS order_line_options;
+DROP TABLE IF EXISTS order_lines;
+DROP TABLE IF EXISTS orders;
+-- +goose StatementEnd
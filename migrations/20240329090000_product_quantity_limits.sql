@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE products
+    ADD COLUMN max_quantity_per_order  INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN max_quantity_per_window INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN quantity_window_seconds BIGINT  NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE products
+    DROP COLUMN IF EXISTS max_quantity_per_order,
+    DROP COLUMN IF EXISTS max_quantity_per_window,
+    DROP COLUMN IF EXISTS quantity_window_seconds;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE product_availability
+(
+    store_id       BIGINT      NOT NULL REFERENCES stores (id) ON DELETE CASCADE,
+    product_id     BIGINT      NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    -- 0 available, 1 sold out, 2 hidden. Products without a row are available.
+    availability   SMALLINT    NOT NULL DEFAULT 0,
+    sold_out_until TIMESTAMPTZ NULL,
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_by     VARCHAR(63) NOT NULL,
+
+    PRIMARY KEY (store_id, product_id)
+);
+
+CREATE INDEX idx_product_availability_product_id ON product_availability (product_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS product_availability;
+-- +goose StatementEnd
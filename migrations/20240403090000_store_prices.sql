@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE store_product_prices
+(
+    store_id   BIGINT      NOT NULL REFERENCES stores (id) ON DELETE CASCADE,
+    product_id BIGINT      NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    -- In IDR minor units, in place of the product's base price at the store.
+    price      BIGINT      NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_by VARCHAR(63) NOT NULL,
+
+    PRIMARY KEY (store_id, product_id),
+    CONSTRAINT chk_store_product_prices_price CHECK (price >= 0)
+);
+
+CREATE TABLE store_modifier_option_prices
+(
+    store_id    BIGINT      NOT NULL REFERENCES stores (id) ON DELETE CASCADE,
+    option_id   BIGINT      NOT NULL REFERENCES modifier_options (id) ON DELETE CASCADE,
+    -- In IDR minor units, in place of the option's price delta at the store.
+    price_delta BIGINT      NOT NULL,
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_by  VARCHAR(63) NOT NULL,
+
+    PRIMARY KEY (store_id, option_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS store_modifier_option_prices;
+DROP TABLE IF EXISTS store_product_prices;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE products
+    -- Bit set of product.DietaryTag, such as 2 for vegan.
+    ADD COLUMN dietary_tags  BIGINT   NOT NULL DEFAULT 0,
+    -- The names weigh more than the descriptions, and both are stemmed as English and as Indonesian since
+    -- the menu mixes both.
+    ADD COLUMN search_vector TSVECTOR NOT NULL GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', name), 'A') ||
+        setweight(to_tsvector('indonesian', name), 'A') ||
+        setweight(to_tsvector('english', description), 'B') ||
+        setweight(to_tsvector('indonesian', description), 'B')
+        ) STORED;
+
+CREATE INDEX idx_products_search_vector ON products USING GIN (search_vector) WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_products_search_vector;
+
+ALTER TABLE products
+    DROP COLUMN IF EXISTS search_vector,
+    DROP COLUMN IF EXISTS dietary_tags;
+-- +goose StatementEnd
@@ -0,0 +1,90 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"coffee-chain-api/account"
+	"coffee-chain-api/mailer"
+)
+
+// Notification is a message to be delivered to a user through any channel they have opted in to.
+type Notification struct {
+	Category account.NotificationCategory
+	Title    string
+	Body     string
+}
+
+// ChannelSender delivers a notification through a single channel, regardless of the recipient's
+// preferences. Do not call it directly, send notifications through Dispatcher instead.
+type ChannelSender interface {
+	Send(ctx context.Context, recipient account.Account, notification Notification) error
+}
+
+// Dispatcher is the single entry point for sending notifications to users. It consults the recipient's
+// notification preferences, and only delivers through the channels they have opted in to for the
+// notification's category.
+//
+// Account security emails (password reset, email change verification) are not notifications, they are
+// sent through mailer.Mailer directly since the user can not opt out of them.
+type Dispatcher struct {
+	channels map[account.NotificationChannel]ChannelSender
+}
+
+func NewDispatcher(channels map[account.NotificationChannel]ChannelSender) (*Dispatcher, error) {
+	for channel, sender := range channels {
+		if sender == nil {
+			return nil, fmt.Errorf("sender for %s channel is nil", channel)
+		}
+	}
+
+	return &Dispatcher{channels: channels}, nil
+}
+
+// Send delivers the notification through every channel the recipient has opted in to. The recipient
+// should be freshly acquired from accountstore.AccountStore, as a session's account may hold outdated
+// preferences. A failure on one channel does not prevent delivery on the others.
+func (d *Dispatcher) Send(ctx context.Context, recipient account.Account, notification Notification) error {
+	if recipient == nil {
+		return fmt.Errorf("recipient is nil")
+	}
+
+	preferences := recipient.GetProfile().NotificationPreferences
+
+	var errs []error
+	for _, channel := range []account.NotificationChannel{account.NotificationChannelPush, account.NotificationChannelEmail} {
+		sender, ok := d.channels[channel]
+		if !ok || !preferences.Allows(channel, notification.Category) {
+			continue
+		}
+
+		err := sender.Send(ctx, recipient, notification)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("sending through %s channel: %w", channel, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+type emailChannelSender struct {
+	mailer mailer.Mailer
+}
+
+// NewEmailChannelSender implements ChannelSender that delivers notifications as plain text emails.
+func NewEmailChannelSender(mailer mailer.Mailer) (ChannelSender, error) {
+	if mailer == nil {
+		return nil, fmt.Errorf("mailer is nil")
+	}
+
+	return &emailChannelSender{mailer: mailer}, nil
+}
+
+func (e *emailChannelSender) Send(ctx context.Context, recipient account.Account, notification Notification) error {
+	return e.mailer.Send(ctx, mailer.Mail{
+		To:      []string{recipient.GetProfile().Email},
+		Subject: notification.Title,
+		Body:    notification.Body,
+	})
+}
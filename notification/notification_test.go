@@ -0,0 +1,82 @@
+package notification_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"coffee-chain-api/account"
+	"coffee-chain-api/notification"
+)
+
+type recordingSender struct {
+	sent []notification.Notification
+	err  error
+}
+
+func (r *recordingSender) Send(ctx context.Context, recipient account.Account, n notification.Notification) error {
+	r.sent = append(r.sent, n)
+	return r.err
+}
+
+func TestDispatcher_Send(t *testing.T) {
+	push := &recordingSender{}
+	email := &recordingSender{}
+
+	dispatcher, err := notification.NewDispatcher(map[account.NotificationChannel]notification.ChannelSender{
+		account.NotificationChannelPush:  push,
+		account.NotificationChannelEmail: email,
+	})
+	if err != nil {
+		t.Fatalf("initializing dispatcher: %s", err.Error())
+	}
+
+	recipient := account.NewBasicAccount(account.Profile{
+		ID:    1,
+		Email: "john@example.com",
+		NotificationPreferences: account.NotificationPreferences{
+			Push:  account.NotificationCategories{Promotional: true, Transactional: true},
+			Email: account.NotificationCategories{Promotional: false, Transactional: true},
+		},
+	}, account.TypeCustomer, 0)
+
+	ctx := context.Background()
+
+	err = dispatcher.Send(ctx, recipient, notification.Notification{Category: account.NotificationCategoryPromotional, Title: "Promo"})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+
+	err = dispatcher.Send(ctx, recipient, notification.Notification{Category: account.NotificationCategoryTransactional, Title: "Order"})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+
+	if len(push.sent) != 2 {
+		t.Errorf("expecting 2 push notifications, got %d", len(push.sent))
+	}
+	if len(email.sent) != 1 || email.sent[0].Title != "Order" {
+		t.Errorf("expecting only the transactional email notification, got %v", email.sent)
+	}
+
+	t.Run("should deliver to the other channels on failure", func(t *testing.T) {
+		failing := &recordingSender{err: errors.New("push provider is down")}
+		working := &recordingSender{}
+
+		dispatcher, err := notification.NewDispatcher(map[account.NotificationChannel]notification.ChannelSender{
+			account.NotificationChannelPush:  failing,
+			account.NotificationChannelEmail: working,
+		})
+		if err != nil {
+			t.Fatalf("initializing dispatcher: %s", err.Error())
+		}
+
+		err = dispatcher.Send(ctx, recipient, notification.Notification{Category: account.NotificationCategoryTransactional})
+		if err == nil {
+			t.Error("expecting an error, got nil")
+		}
+		if len(working.sent) != 1 {
+			t.Errorf("expecting 1 email notification, got %d", len(working.sent))
+		}
+	})
+}
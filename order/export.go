@@ -0,0 +1,166 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"coffee-chain-api/account"
+	"coffee-chain-api/account/dataexport"
+
+	"github.com/rs/zerolog/log"
+)
+
+type exportedOrder struct {
+	ID      int64               `json:"id"`
+	StoreID int64               `json:"store_id"`
+	Status  string              `json:"status"`
+	Lines   []exportedOrderLine `json:"lines"`
+	// Total is in IDR minor units.
+	Total     int64     `json:"total"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportedOrderLine struct {
+	ProductID int64                 `json:"product_id"`
+	Name      string                `json:"name"`
+	Options   []exportedOrderOption `json:"options"`
+	Quantity  int                   `json:"quantity"`
+	// UnitPrice is in IDR minor units.
+	UnitPrice int64 `json:"unit_price"`
+}
+
+type exportedOrderOption struct {
+	OptionID int64  `json:"option_id"`
+	Name     string `json:"name"`
+	// PriceDelta is in IDR minor units.
+	PriceDelta int64 `json:"price_delta"`
+}
+
+// exportSection is the dataexport.SectionProvider of the orders.
+type exportSection struct {
+	db *sql.DB
+}
+
+// Export returns every order of the account, oldest first, with their lines and options as they were
+// placed.
+func (e *exportSection) Export(ctx context.Context, userAccount account.Account) (any, error) {
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	rows, err := conn.QueryContext(
+		ctx,
+		`SELECT
+				orders.id,
+				orders.store_id,
+				orders.status,
+				orders.total,
+				orders.created_at,
+				order_lines.id,
+				order_lines.product_id,
+				order_lines.product_name,
+				order_lines.quantity,
+				order_lines.unit_price,
+				order_line_options.option_id,
+				order_line_options.option_name,
+				order_line_options.price_delta
+			FROM
+				orders
+				JOIN order_lines ON order_lines.order_id = orders.id
+				LEFT JOIN order_line_options ON order_line_options.order_line_id = order_lines.id
+			WHERE
+				orders.account_id = $1
+			ORDER BY
+				orders.created_at,
+				orders.id,
+				order_lines.position,
+				order_line_options.position`,
+		userAccount.GetProfile().ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("executing select query: %w", err)
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing rows")
+		}
+	}()
+
+	orders := []exportedOrder{}
+	var lastLineId int64
+	for rows.Next() {
+		var order exportedOrder
+		var status Status
+		var lineId int64
+		var line exportedOrderLine
+		var optionId sql.NullInt64
+		var optionName sql.NullString
+		var priceDelta sql.NullInt64
+		err = rows.Scan(
+			&order.ID,
+			&order.StoreID,
+			&status,
+			&order.Total,
+			&order.CreatedAt,
+			&lineId,
+			&line.ProductID,
+			&line.Name,
+			&line.Quantity,
+			&line.UnitPrice,
+			&optionId,
+			&optionName,
+			&priceDelta,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scanning row: %w", err)
+		}
+
+		// The rows come order by order, and line by line within an order.
+		if len(orders) == 0 || orders[len(orders)-1].ID != order.ID {
+			order.Status = status.String()
+			order.Lines = []exportedOrderLine{}
+			orders = append(orders, order)
+		}
+		current := &orders[len(orders)-1]
+		if lineId != lastLineId {
+			line.Options = []exportedOrderOption{}
+			current.Lines = append(current.Lines, line)
+			lastLineId = lineId
+		}
+
+		if optionId.Valid {
+			currentLine := &current.Lines[len(current.Lines)-1]
+			currentLine.Options = append(currentLine.Options, exportedOrderOption{
+				OptionID:   optionId.Int64,
+				Name:       optionName.String,
+				PriceDelta: priceDelta.Int64,
+			})
+		}
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("iterating rows: %w", err)
+	}
+
+	return orders, nil
+}
+
+// NewExportSection creates the provider of the dataexport.SectionOrders section.
+func NewExportSection(db *sql.DB) (dataexport.SectionProvider, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db is nil")
+	}
+
+	return &exportSection{db: db}, nil
+}
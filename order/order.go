@@ -0,0 +1,203 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"coffee-chain-api/product"
+	"coffee-chain-api/store"
+)
+
+// ErrInvalidOrder is matched by every *ValidationError.
+var ErrInvalidOrder = errors.New("invalid order")
+
+// Status is where the order is in its life.
+type Status uint8
+
+const (
+	StatusUnspecified Status = iota
+	// StatusPlaced orders have been placed by the customer, and are waiting to be made by the store.
+	StatusPlaced
+	// StatusCancelled orders are not made, and don't count towards the quantity limits of their products.
+	StatusCancelled
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusPlaced:
+		return "placed"
+	case StatusCancelled:
+		return "cancelled"
+	default:
+		return ""
+	}
+}
+
+type Order struct {
+	ID        int64
+	AccountID int64
+	StoreID   int64
+	Status    Status
+	Lines     []Line
+	// Total is the sum of the subtotals of the lines.
+	Total     product.Price
+	CreatedAt time.Time
+}
+
+// Line is a product configured with its options, priced at the store it's ordered from.
+type Line struct {
+	product.Configuration
+	Quantity int
+}
+
+// Subtotal is the unit price times the quantity.
+func (l Line) Subtotal() product.Price {
+	return l.UnitPrice * product.Price(l.Quantity)
+}
+
+// RawOrder holds what the customer orders. The client never sends prices, they come from the catalog.
+type RawOrder struct {
+	StoreID int64
+	Lines   []RawLine
+}
+
+type RawLine struct {
+	ProductID int64
+	// OptionIDs are the selected options, the groups left out take their default options.
+	OptionIDs []int64
+	Quantity  int
+}
+
+// ValidationError describes the first invalid field of a RawOrder.
+type ValidationError struct {
+	// Field is the snake_cased field name, such as "store_id".
+	Field   string
+	Message string
+}
+
+func (v *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", v.Field, v.Message)
+}
+
+func (v *ValidationError) Is(target error) bool {
+	return target == ErrInvalidOrder
+}
+
+// LineError describes the line of the order that can not be configured, wrapping why: product.ErrNotFound
+// if the product does not exist, or a *product.SelectionError.
+type LineError struct {
+	// Line is the index of the line in the order.
+	Line      int
+	ProductID int64
+	Err       error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+const (
+	maximumLines         = 50
+	maximumLineQuantity  = 100
+	maximumLineOptionIDs = 50
+)
+
+// Validate checks a RawOrder, and returns a *ValidationError for the first invalid field. Whether the
+// store and the products exist is checked by the repository.
+func (o RawOrder) Validate() error {
+	if o.StoreID <= 0 {
+		return &ValidationError{Field: "store_id", Message: "is required"}
+	}
+	if len(o.Lines) == 0 || len(o.Lines) > maximumLines {
+		return &ValidationError{Field: "lines", Message: fmt.Sprintf("must have between 1 and %d lines", maximumLines)}
+	}
+
+	for i, line := range o.Lines {
+		if line.ProductID <= 0 {
+			return &ValidationError{Field: "lines", Message: fmt.Sprintf("product of line %d is required", i)}
+		}
+		if line.Quantity < 1 || line.Quantity > maximumLineQuantity {
+			return &ValidationError{Field: "lines", Message: fmt.Sprintf("quantity of line %d must be between 1 and %d", i, maximumLineQuantity)}
+		}
+		if len(line.OptionIDs) > maximumLineOptionIDs {
+			return &ValidationError{Field: "lines", Message: fmt.Sprintf("line %d must have at most %d options", i, maximumLineOptionIDs)}
+		}
+	}
+
+	return nil
+}
+
+// Catalog is what an order is priced against, read right before the order is placed.
+type Catalog struct {
+	Store store.Store
+	// Products are by their ID, the ones left out do not exist.
+	Products map[int64]product.Product
+	// ModifierGroups are the groups attached to each product, by the product ID.
+	ModifierGroups map[int64][]product.ModifierGroup
+	// Availabilities are those of the store, the products left out of them are available.
+	Availabilities map[int64]product.StoreAvailability
+	Prices         product.StorePrices
+}
+
+// Price checks that the store takes orders at the given time and that the products are available there,
+// and configures every line of a validated RawOrder with product.Configure. It returns
+// store.ErrStoreNotOpen, a *product.UnavailableError, or a *LineError for the first line that can not be
+// configured.
+func (c Catalog) Price(rawOrder RawOrder, now time.Time) ([]Line, error) {
+	err := c.Store.CheckOpen(now)
+	if err != nil {
+		return nil, err
+	}
+
+	products := make([]product.Line, 0, len(rawOrder.Lines))
+	for i, rawLine := range rawOrder.Lines {
+		p, ok := c.Products[rawLine.ProductID]
+		if !ok {
+			return nil, &LineError{Line: i, ProductID: rawLine.ProductID, Err: product.ErrNotFound}
+		}
+
+		products = append(products, product.Line{Product: p, Quantity: rawLine.Quantity})
+	}
+
+	// Inactive products are unavailable too.
+	err = product.CheckAvailability(products, c.Availabilities, now)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]Line, 0, len(rawOrder.Lines))
+	for i, rawLine := range rawOrder.Lines {
+		p := products[i].Product
+		configuration, err := product.Configure(p, c.ModifierGroups[p.ID], rawLine.OptionIDs, c.Prices)
+		if err != nil {
+			return nil, &LineError{Line: i, ProductID: p.ID, Err: err}
+		}
+
+		lines = append(lines, Line{Configuration: configuration, Quantity: rawLine.Quantity})
+	}
+
+	return lines, nil
+}
+
+// productLines returns the products of the lines with their quantities, in the same order.
+func productLines(lines []Line) []product.Line {
+	productLines := make([]product.Line, 0, len(lines))
+	for _, line := range lines {
+		productLines = append(productLines, product.Line{Product: line.Product, Quantity: line.Quantity})
+	}
+
+	return productLines
+}
+
+type OrderRepository interface {
+	// Create prices the order against the latest catalog and places it for the customer. It returns a
+	// *ValidationError, store.ErrStoreNotOpen, a *product.UnavailableError, a *LineError, or a
+	// *product.QuantityLimitError if the order can not be placed as it is.
+	Create(ctx context.Context, accountId int64, rawOrder RawOrder) (Order, error)
+}
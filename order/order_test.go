@@ -0,0 +1,147 @@
+package order_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"coffee-chain-api/order"
+	"coffee-chain-api/product"
+	"coffee-chain-api/store"
+)
+
+func TestRawOrder_Validate(t *testing.T) {
+	line := order.RawLine{ProductID: 1, Quantity: 1}
+
+	testCases := []struct {
+		name     string
+		rawOrder order.RawOrder
+		field    string
+	}{
+		{name: "valid", rawOrder: order.RawOrder{StoreID: 1, Lines: []order.RawLine{line}}, field: ""},
+		{name: "without store", rawOrder: order.RawOrder{Lines: []order.RawLine{line}}, field: "store_id"},
+		{name: "without lines", rawOrder: order.RawOrder{StoreID: 1}, field: "lines"},
+		{name: "without product", rawOrder: order.RawOrder{StoreID: 1, Lines: []order.RawLine{{Quantity: 1}}}, field: "lines"},
+		{name: "zero quantity", rawOrder: order.RawOrder{StoreID: 1, Lines: []order.RawLine{{ProductID: 1}}}, field: "lines"},
+		{name: "quantity too high", rawOrder: order.RawOrder{StoreID: 1, Lines: []order.RawLine{{ProductID: 1, Quantity: 101}}}, field: "lines"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rawOrder.Validate()
+			if tt.field == "" {
+				if err != nil {
+					t.Errorf("expecting no error, got %v instead", err)
+				}
+				return
+			}
+
+			var validationError *order.ValidationError
+			if !errors.As(err, &validationError) || validationError.Field != tt.field {
+				t.Errorf("expecting invalid %s, got %v instead", tt.field, err)
+			}
+		})
+	}
+}
+
+func TestCatalog_Price(t *testing.T) {
+	now := time.Date(2024, 4, 8, 10, 0, 0, 0, time.UTC)
+	openStore := store.Store{ID: 1, Status: store.StatusActive, State: store.StateOpen}
+
+	latte := product.Product{ID: 1, Name: "Latte", BasePrice: 30_000_00, Active: true}
+	croissant := product.Product{ID: 2, Name: "Croissant", BasePrice: 25_000_00, Active: true}
+	retired := product.Product{ID: 3, Name: "Retired", BasePrice: 20_000_00}
+	muffin := product.Product{ID: 4, Name: "Muffin", BasePrice: 20_000_00, Active: true}
+	milk := product.ModifierGroup{ID: 10, Name: "Milk", MinSelections: 1, MaxSelections: 1, Options: []product.ModifierOption{
+		{ID: 100, Name: "Whole", Default: true},
+		{ID: 101, Name: "Oat", PriceDelta: 5_000_00},
+	}}
+
+	catalog := order.Catalog{
+		Store:          openStore,
+		Products:       map[int64]product.Product{latte.ID: latte, croissant.ID: croissant, retired.ID: retired, muffin.ID: muffin},
+		ModifierGroups: map[int64][]product.ModifierGroup{latte.ID: {milk}},
+		Availabilities: map[int64]product.StoreAvailability{
+			croissant.ID: {StoreID: 1, ProductID: croissant.ID, Availability: product.AvailabilityAvailable},
+			muffin.ID:    {StoreID: 1, ProductID: muffin.ID, Availability: product.AvailabilitySoldOut},
+		},
+		// Lattes cost more at this store.
+		Prices: product.StorePrices{StoreID: 1, BasePrices: map[int64]product.Price{latte.ID: 32_000_00}},
+	}
+
+	t.Run("priced at the store", func(t *testing.T) {
+		lines, err := catalog.Price(order.RawOrder{StoreID: 1, Lines: []order.RawLine{
+			{ProductID: latte.ID, OptionIDs: []int64{101}, Quantity: 2},
+			{ProductID: croissant.ID, Quantity: 1},
+		}}, now)
+		if err != nil {
+			t.Fatalf("expecting no error, got %v instead", err)
+		}
+
+		if len(lines) != 2 {
+			t.Fatalf("expecting 2 lines, got %d instead", len(lines))
+		}
+		if lines[0].UnitPrice != 37_000_00 || lines[0].Subtotal() != 74_000_00 {
+			t.Errorf("expecting the latte at IDR 37,000 each, got %s and %s instead", lines[0].UnitPrice, lines[0].Subtotal())
+		}
+		if lines[1].Subtotal() != 25_000_00 {
+			t.Errorf("expecting the croissant at IDR 25,000, got %s instead", lines[1].Subtotal())
+		}
+	})
+
+	t.Run("store not open", func(t *testing.T) {
+		closed := catalog
+		closed.Store.State = store.StateClosed
+
+		_, err := closed.Price(order.RawOrder{StoreID: 1, Lines: []order.RawLine{{ProductID: latte.ID, Quantity: 1}}}, now)
+		if !errors.Is(err, store.ErrStoreNotOpen) {
+			t.Errorf("expecting ErrStoreNotOpen, got %v instead", err)
+		}
+	})
+
+	unavailable := []struct {
+		name         string
+		productId    int64
+		availability product.Availability
+	}{
+		{name: "sold out", productId: muffin.ID, availability: product.AvailabilitySoldOut},
+		{name: "inactive", productId: retired.ID, availability: product.AvailabilityHidden},
+	}
+
+	for _, tt := range unavailable {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := catalog.Price(order.RawOrder{StoreID: 1, Lines: []order.RawLine{
+				{ProductID: croissant.ID, Quantity: 1},
+				{ProductID: tt.productId, Quantity: 1},
+			}}, now)
+
+			var unavailableError *product.UnavailableError
+			if !errors.As(err, &unavailableError) || unavailableError.Line != 1 || unavailableError.Availability != tt.availability {
+				t.Errorf("expecting line 1 to be %s, got %v instead", tt.availability, err)
+			}
+		})
+	}
+
+	lineErrors := []struct {
+		name    string
+		rawLine order.RawLine
+		expect  error
+	}{
+		{name: "missing product", rawLine: order.RawLine{ProductID: 9, Quantity: 1}, expect: product.ErrNotFound},
+		{name: "invalid selection", rawLine: order.RawLine{ProductID: latte.ID, OptionIDs: []int64{100, 101}, Quantity: 1}, expect: product.ErrInvalidSelection},
+	}
+
+	for _, tt := range lineErrors {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := catalog.Price(order.RawOrder{StoreID: 1, Lines: []order.RawLine{
+				{ProductID: croissant.ID, Quantity: 1},
+				tt.rawLine,
+			}}, now)
+
+			var lineError *order.LineError
+			if !errors.As(err, &lineError) || lineError.Line != 1 || !errors.Is(err, tt.expect) {
+				t.Errorf("expecting line 1 to fail with %v, got %v instead", tt.expect, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"coffee-chain-api/account"
+	"coffee-chain-api/product"
+	"coffee-chain-api/store"
+
+	"github.com/rs/zerolog/log"
+)
+
+type repository struct {
+	db       *sql.DB
+	stores   store.StoreRepository
+	products product.ProductRepository
+}
+
+func (r *repository) Create(ctx context.Context, accountId int64, rawOrder RawOrder) (Order, error) {
+	err := rawOrder.Validate()
+	if err != nil {
+		return Order{}, err
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return Order{}, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	// Read committed, so the catalog and the orders placed by whoever held the account lock before are seen
+	// once it's acquired. A repeatable read snapshot would be taken before waiting for the lock.
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		return Order{}, fmt.Errorf("creating transaction: %w", err)
+	}
+
+	order, err := r.placeOrder(ctx, tx, accountId, rawOrder)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return Order{}, fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		return Order{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return Order{}, fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return order, nil
+}
+
+// placeOrder prices the order, checks the quantity limits of its lines for the customer, and inserts it. The
+// account is locked first, so the concurrent orders of the same customer are checked one after another, and
+// can not both slip under a limit.
+func (r *repository) placeOrder(ctx context.Context, tx *sql.Tx, accountId int64, rawOrder RawOrder) (Order, error) {
+	_, err := tx.ExecContext(ctx, `SELECT id FROM user_accounts WHERE id = $1 FOR UPDATE`, accountId)
+	if err != nil {
+		return Order{}, fmt.Errorf("locking account: %w", err)
+	}
+
+	catalog, err := r.catalog(ctx, tx, rawOrder)
+	if err != nil {
+		return Order{}, err
+	}
+
+	now := time.Now()
+	lines, err := catalog.Price(rawOrder, now)
+	if err != nil {
+		return Order{}, err
+	}
+
+	err = product.CheckQuantityLimits(ctx, purchaseHistory{tx: tx}, accountId, productLines(lines), now)
+	if err != nil {
+		return Order{}, err
+	}
+
+	return insertOrder(ctx, tx, accountId, rawOrder.StoreID, lines, now)
+}
+
+// catalog reads the store and the latest products of the order in tx, with their modifier groups, and the
+// availabilities and prices at the store. The store is locked FOR SHARE, so it can not close before the
+// order is inserted.
+func (r *repository) catalog(ctx context.Context, tx *sql.Tx, rawOrder RawOrder) (Catalog, error) {
+	orderedStore, err := r.stores.GetByIDForShare(ctx, tx, rawOrder.StoreID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return Catalog{}, &ValidationError{Field: "store_id", Message: "does not exist"}
+		}
+
+		return Catalog{}, fmt.Errorf("acquiring store: %w", err)
+	}
+
+	productIds := make([]int64, 0, len(rawOrder.Lines))
+	for _, rawLine := range rawOrder.Lines {
+		productIds = append(productIds, rawLine.ProductID)
+	}
+
+	products, err := r.products.GetOrderCatalog(ctx, tx, orderedStore.ID, productIds)
+	if err != nil {
+		return Catalog{}, fmt.Errorf("acquiring products: %w", err)
+	}
+
+	return Catalog{
+		Store:          orderedStore,
+		Products:       products.Products,
+		ModifierGroups: products.ModifierGroups,
+		Availabilities: products.Availabilities,
+		Prices:         products.Prices,
+	}, nil
+}
+
+// insertOrder inserts the order, with its lines and their options.
+func insertOrder(ctx context.Context, tx *sql.Tx, accountId int64, storeId int64, lines []Line, now time.Time) (Order, error) {
+	order := Order{
+		AccountID: accountId,
+		StoreID:   storeId,
+		Status:    StatusPlaced,
+		Lines:     lines,
+		CreatedAt: now,
+	}
+	for _, line := range lines {
+		order.Total += line.Subtotal()
+	}
+
+	err := tx.QueryRowContext(
+		ctx,
+		`INSERT INTO
+			orders
+			(account_id,
+			 store_id,
+			 status,
+			 total,
+			 created_at,
+			 created_by,
+			 updated_at,
+			 updated_by
+			 )
+		VALUES
+			($1, $2, $3, $4, $5, $6, $5, $6)
+		RETURNING id`,
+		order.AccountID,
+		order.StoreID,
+		order.Status,
+		order.Total,
+		order.CreatedAt,
+		account.ActorIdentifier(ctx),
+	).Scan(&order.ID)
+	if err != nil {
+		return Order{}, fmt.Errorf("executing insert query: %w", err)
+	}
+
+	for i, line := range lines {
+		var lineId int64
+		err = tx.QueryRowContext(
+			ctx,
+			`INSERT INTO
+				order_lines
+				(order_id,
+				 position,
+				 product_id,
+				 product_name,
+				 quantity,
+				 unit_price
+				 )
+			VALUES
+				($1, $2, $3, $4, $5, $6)
+			RETURNING id`,
+			order.ID,
+			i,
+			line.Product.ID,
+			line.Product.Name,
+			line.Quantity,
+			line.UnitPrice,
+		).Scan(&lineId)
+		if err != nil {
+			return Order{}, fmt.Errorf("executing insert query: %w", err)
+		}
+
+		for j, option := range line.Options {
+			_, err = tx.ExecContext(
+				ctx,
+				`INSERT INTO
+					order_line_options
+					(order_line_id,
+					 position,
+					 option_id,
+					 option_name,
+					 price_delta
+					 )
+				VALUES
+					($1, $2, $3, $4, $5)`,
+				lineId,
+				j,
+				option.ID,
+				option.Name,
+				option.PriceDelta,
+			)
+			if err != nil {
+				return Order{}, fmt.Errorf("executing insert query: %w", err)
+			}
+		}
+	}
+
+	return order, nil
+}
+
+// purchaseHistory is the product.PurchaseHistory of the orders, read in the transaction placing the next
+// order.
+type purchaseHistory struct {
+	tx *sql.Tx
+}
+
+func (h purchaseHistory) QuantityOrdered(ctx context.Context, accountId int64, productId int64, since time.Time) (int, error) {
+	var quantity int
+	err := h.tx.QueryRowContext(
+		ctx,
+		`SELECT
+				COALESCE(SUM(order_lines.quantity), 0)
+			FROM
+				order_lines
+				JOIN orders ON orders.id = order_lines.order_id
+			WHERE
+				orders.account_id = $1
+				AND orders.created_at >= $2
+				AND orders.status <> $3
+				AND order_lines.product_id = $4`,
+		accountId,
+		since,
+		StatusCancelled,
+		productId,
+	).Scan(&quantity)
+	if err != nil {
+		return 0, fmt.Errorf("executing select query: %w", err)
+	}
+
+	return quantity, nil
+}
+
+func NewRepository(db *sql.DB, stores store.StoreRepository, products product.ProductRepository) (OrderRepository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db is nil")
+	}
+	if stores == nil {
+		return nil, fmt.Errorf("stores is nil")
+	}
+	if products == nil {
+		return nil, fmt.Errorf("products is nil")
+	}
+
+	return &repository{db: db, stores: stores, products: products}, nil
+}
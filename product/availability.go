@@ -0,0 +1,162 @@
+package product
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrProductUnavailable is matched by every *UnavailableError.
+var ErrProductUnavailable = errors.New("product is unavailable")
+
+// Availability is whether a product can be ordered at a store, as set by the cashiers of the store.
+type Availability uint8
+
+const (
+	// AvailabilityAvailable is the availability of every product a store has not set otherwise.
+	AvailabilityAvailable Availability = iota
+	// AvailabilitySoldOut products are still shown but can not be ordered, such as when the store runs out
+	// of croissants. They become available again by themselves at their sold out time, if there is one.
+	AvailabilitySoldOut
+	// AvailabilityHidden products are not shown at the store at all, such as a product the store does not
+	// have the equipment for.
+	AvailabilityHidden
+)
+
+func (a Availability) String() string {
+	switch a {
+	case AvailabilityAvailable:
+		return "available"
+	case AvailabilitySoldOut:
+		return "sold_out"
+	case AvailabilityHidden:
+		return "hidden"
+	default:
+		return ""
+	}
+}
+
+// ParseAvailability parses the availability from its name, e.g. "sold_out".
+func ParseAvailability(s string) (Availability, bool) {
+	switch s {
+	case "available":
+		return AvailabilityAvailable, true
+	case "sold_out":
+		return AvailabilitySoldOut, true
+	case "hidden":
+		return AvailabilityHidden, true
+	default:
+		return 0, false
+	}
+}
+
+// MaximumSoldOutDuration is how far ahead a product can be set to become available again. Anything longer
+// should be sold out until a cashier sets it back.
+const MaximumSoldOutDuration = time.Hour * 24 * 7
+
+// StoreAvailability is the availability of a product at a store.
+type StoreAvailability struct {
+	StoreID      int64
+	ProductID    int64
+	Availability Availability
+	// SoldOutUntil is when a sold out product becomes available again. It's zero if the product stays
+	// sold out until a cashier sets it back, and for the other availabilities.
+	SoldOutUntil time.Time
+	UpdatedAt    time.Time
+	UpdatedBy    string
+}
+
+// AvailabilityAt returns the availability at the given time, with expired sold outs available again.
+func (a StoreAvailability) AvailabilityAt(now time.Time) Availability {
+	if a.Availability == AvailabilitySoldOut && !a.SoldOutUntil.IsZero() && !a.SoldOutUntil.After(now) {
+		return AvailabilityAvailable
+	}
+
+	return a.Availability
+}
+
+// AvailabilityChange is a request to set the availability of a product at a store.
+type AvailabilityChange struct {
+	Availability Availability
+	// SoldOutUntil is optional when selling out and ignored otherwise.
+	SoldOutUntil time.Time
+}
+
+// normalize returns the change without the sold out time if it does not apply.
+func (c AvailabilityChange) normalize() AvailabilityChange {
+	if c.Availability != AvailabilitySoldOut {
+		c.SoldOutUntil = time.Time{}
+	}
+
+	return c
+}
+
+// validate returns a *ValidationError if the change is invalid at the given time.
+func (c AvailabilityChange) validate(now time.Time) error {
+	switch c.Availability {
+	case AvailabilityAvailable, AvailabilityHidden:
+		return nil
+	case AvailabilitySoldOut:
+		if !c.SoldOutUntil.IsZero() && (!c.SoldOutUntil.After(now) || c.SoldOutUntil.Sub(now) > MaximumSoldOutDuration) {
+			return &ValidationError{Field: "sold_out_until", Message: fmt.Sprintf("must be within the next %d days", int(MaximumSoldOutDuration.Hours()/24))}
+		}
+
+		return nil
+	default:
+		return &ValidationError{Field: "availability", Message: "must be one of available, sold_out, or hidden"}
+	}
+}
+
+// UnavailableError describes the line of the order whose product can not be ordered at the store.
+type UnavailableError struct {
+	// Line is the index of the line in the order.
+	Line      int
+	ProductID int64
+	// Availability is hidden for the products that are inactive or hidden at the store.
+	Availability Availability
+	// SoldOutUntil is when a sold out product becomes available again, it's zero if it's not known.
+	SoldOutUntil time.Time
+}
+
+func (e *UnavailableError) Error() string {
+	if e.Availability == AvailabilitySoldOut {
+		if !e.SoldOutUntil.IsZero() {
+			return fmt.Sprintf("line %d: product %d is sold out until %s", e.Line, e.ProductID, e.SoldOutUntil.Format(time.RFC3339))
+		}
+
+		return fmt.Sprintf("line %d: product %d is sold out", e.Line, e.ProductID)
+	}
+
+	return fmt.Sprintf("line %d: product %d is not available", e.Line, e.ProductID)
+}
+
+func (e *UnavailableError) Is(target error) bool {
+	return target == ErrProductUnavailable
+}
+
+// CheckAvailability returns an *UnavailableError if the product of any line can not be ordered at the store
+// at the given time. The availabilities are those of the store, from
+// ProductRepository.GetStoreAvailabilities, and the products left out of them are available.
+func CheckAvailability(lines []Line, availabilities map[int64]StoreAvailability, now time.Time) error {
+	for i, line := range lines {
+		if !line.Product.Active {
+			return &UnavailableError{Line: i, ProductID: line.Product.ID, Availability: AvailabilityHidden}
+		}
+
+		availability, ok := availabilities[line.Product.ID]
+		if !ok {
+			continue
+		}
+
+		switch availability.AvailabilityAt(now) {
+		case AvailabilityAvailable:
+			continue
+		case AvailabilitySoldOut:
+			return &UnavailableError{Line: i, ProductID: line.Product.ID, Availability: AvailabilitySoldOut, SoldOutUntil: availability.SoldOutUntil}
+		default:
+			return &UnavailableError{Line: i, ProductID: line.Product.ID, Availability: AvailabilityHidden}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package product
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"coffee-chain-api/account"
+
+	"github.com/rs/zerolog/log"
+)
+
+type productAvailabilityTable struct {
+	StoreID      int64
+	ProductID    int64
+	Availability int8
+	SoldOutUntil sql.NullTime
+	UpdatedAt    time.Time
+	UpdatedBy    string
+}
+
+// availabilityColumns is the column list for selecting productAvailabilityTable, in the same order as
+// productAvailabilityTable.scanDestinations.
+const availabilityColumns = `store_id,
+				product_id,
+				availability,
+				sold_out_until,
+				updated_at,
+				updated_by`
+
+func (a *productAvailabilityTable) scanDestinations() []any {
+	return []any{
+		&a.StoreID,
+		&a.ProductID,
+		&a.Availability,
+		&a.SoldOutUntil,
+		&a.UpdatedAt,
+		&a.UpdatedBy,
+	}
+}
+
+func (a *productAvailabilityTable) storeAvailability() StoreAvailability {
+	return StoreAvailability{
+		StoreID:      a.StoreID,
+		ProductID:    a.ProductID,
+		Availability: Availability(a.Availability),
+		SoldOutUntil: a.SoldOutUntil.Time,
+		UpdatedAt:    a.UpdatedAt,
+		UpdatedBy:    a.UpdatedBy,
+	}
+}
+
+func (r *repository) GetStoreAvailabilities(ctx context.Context, storeId int64, productIds []int64) (map[int64]StoreAvailability, error) {
+	if storeId <= 0 || len(productIds) == 0 {
+		return make(map[int64]StoreAvailability), nil
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	return getStoreAvailabilities(ctx, conn, storeId, productIds)
+}
+
+func getStoreAvailabilities(ctx context.Context, q queryer, storeId int64, productIds []int64) (map[int64]StoreAvailability, error) {
+	availabilities := make(map[int64]StoreAvailability)
+	if storeId <= 0 || len(productIds) == 0 {
+		return availabilities, nil
+	}
+
+	args := []any{storeId}
+	placeholders := make([]string, 0, len(productIds))
+	for _, productId := range productIds {
+		args = append(args, productId)
+		placeholders = append(placeholders, "$"+strconv.Itoa(len(args)))
+	}
+
+	rows, err := q.QueryContext(
+		ctx,
+		`SELECT
+				`+availabilityColumns+`
+			FROM
+				product_availability
+			WHERE
+				store_id = $1
+				AND product_id IN (`+strings.Join(placeholders, ", ")+`)`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("executing select query: %w", err)
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing rows")
+		}
+	}()
+
+	for rows.Next() {
+		var availability productAvailabilityTable
+		err = rows.Scan(availability.scanDestinations()...)
+		if err != nil {
+			return nil, fmt.Errorf("scanning row: %w", err)
+		}
+
+		availabilities[availability.ProductID] = availability.storeAvailability()
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("iterating rows: %w", err)
+	}
+
+	return availabilities, nil
+}
+
+func (r *repository) SetStoreAvailability(ctx context.Context, storeId int64, productId int64, change AvailabilityChange) (StoreAvailability, error) {
+	if productId <= 0 {
+		return StoreAvailability{}, ErrNotFound
+	}
+
+	now := time.Now()
+	change = change.normalize()
+	err := change.validate(now)
+	if err != nil {
+		return StoreAvailability{}, err
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return StoreAvailability{}, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	// Selecting from products leaves nothing to insert if the product does not exist.
+	var availability productAvailabilityTable
+	err = conn.QueryRowContext(
+		ctx,
+		`INSERT INTO
+			product_availability
+			(store_id,
+			 product_id,
+			 availability,
+			 sold_out_until,
+			 updated_at,
+			 updated_by
+			 )
+		SELECT
+			$1, id, $2, $3, $4, $5
+		FROM
+			products
+		WHERE
+			id = $6
+			AND deleted_at IS NULL
+		ON CONFLICT (store_id, product_id) DO UPDATE SET
+			availability = EXCLUDED.availability,
+			sold_out_until = EXCLUDED.sold_out_until,
+			updated_at = EXCLUDED.updated_at,
+			updated_by = EXCLUDED.updated_by
+		RETURNING
+			`+availabilityColumns,
+		storeId,
+		change.Availability,
+		sql.NullTime{Time: change.SoldOutUntil, Valid: !change.SoldOutUntil.IsZero()},
+		now,
+		account.ActorIdentifier(ctx),
+		productId,
+	).Scan(availability.scanDestinations()...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return StoreAvailability{}, ErrNotFound
+		}
+
+		return StoreAvailability{}, fmt.Errorf("executing insert query: %w", err)
+	}
+
+	return availability.storeAvailability(), nil
+}
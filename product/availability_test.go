@@ -0,0 +1,118 @@
+package product_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"coffee-chain-api/product"
+)
+
+func TestParseAvailability(t *testing.T) {
+	for _, availability := range []product.Availability{product.AvailabilityAvailable, product.AvailabilitySoldOut, product.AvailabilityHidden} {
+		parsed, ok := product.ParseAvailability(availability.String())
+		if !ok || parsed != availability {
+			t.Errorf("expecting %s to parse back, got %s (%t) instead", availability, parsed, ok)
+		}
+	}
+
+	if _, ok := product.ParseAvailability("out_of_stock"); ok {
+		t.Errorf("expecting an unknown availability not to parse")
+	}
+}
+
+func TestStoreAvailability_AvailabilityAt(t *testing.T) {
+	now := time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name         string
+		availability product.StoreAvailability
+		expect       product.Availability
+	}{
+		{name: "not set", availability: product.StoreAvailability{}, expect: product.AvailabilityAvailable},
+		{name: "sold out indefinitely", availability: product.StoreAvailability{Availability: product.AvailabilitySoldOut}, expect: product.AvailabilitySoldOut},
+		{name: "sold out until later", availability: product.StoreAvailability{Availability: product.AvailabilitySoldOut, SoldOutUntil: now.Add(time.Hour)}, expect: product.AvailabilitySoldOut},
+		{name: "sold out until now", availability: product.StoreAvailability{Availability: product.AvailabilitySoldOut, SoldOutUntil: now}, expect: product.AvailabilityAvailable},
+		{name: "hidden", availability: product.StoreAvailability{Availability: product.AvailabilityHidden}, expect: product.AvailabilityHidden},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.availability.AvailabilityAt(now)
+			if got != tt.expect {
+				t.Errorf("expecting %s, got %s instead", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestCheckAvailability(t *testing.T) {
+	now := time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC)
+	latte := product.Product{ID: 1, Active: true}
+	croissant := product.Product{ID: 2, Active: true}
+	retired := product.Product{ID: 3, Active: false}
+
+	testCases := []struct {
+		name           string
+		lines          []product.Line
+		availabilities map[int64]product.StoreAvailability
+		// expect is nil if every line is available.
+		expect *product.UnavailableError
+	}{
+		{
+			name:  "never set",
+			lines: []product.Line{{Product: latte, Quantity: 1}, {Product: croissant, Quantity: 2}},
+		},
+		{
+			name:  "sold out expired",
+			lines: []product.Line{{Product: croissant, Quantity: 1}},
+			availabilities: map[int64]product.StoreAvailability{
+				2: {ProductID: 2, Availability: product.AvailabilitySoldOut, SoldOutUntil: now.Add(-time.Minute)},
+			},
+		},
+		{
+			name:  "sold out",
+			lines: []product.Line{{Product: latte, Quantity: 1}, {Product: croissant, Quantity: 1}},
+			availabilities: map[int64]product.StoreAvailability{
+				2: {ProductID: 2, Availability: product.AvailabilitySoldOut, SoldOutUntil: now.Add(time.Hour)},
+			},
+			expect: &product.UnavailableError{Line: 1, ProductID: 2, Availability: product.AvailabilitySoldOut, SoldOutUntil: now.Add(time.Hour)},
+		},
+		{
+			name:  "hidden",
+			lines: []product.Line{{Product: latte, Quantity: 1}},
+			availabilities: map[int64]product.StoreAvailability{
+				1: {ProductID: 1, Availability: product.AvailabilityHidden},
+			},
+			expect: &product.UnavailableError{Line: 0, ProductID: 1, Availability: product.AvailabilityHidden},
+		},
+		{
+			name:   "inactive",
+			lines:  []product.Line{{Product: latte, Quantity: 1}, {Product: retired, Quantity: 1}},
+			expect: &product.UnavailableError{Line: 1, ProductID: 3, Availability: product.AvailabilityHidden},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := product.CheckAvailability(tt.lines, tt.availabilities, now)
+			if tt.expect == nil {
+				if err != nil {
+					t.Errorf("expecting no error, got %v instead", err)
+				}
+				return
+			}
+
+			var unavailableError *product.UnavailableError
+			if !errors.As(err, &unavailableError) {
+				t.Fatalf("expecting an unavailable error, got %v instead", err)
+			}
+			if *unavailableError != *tt.expect {
+				t.Errorf("expecting %+v, got %+v instead", *tt.expect, *unavailableError)
+			}
+			if !errors.Is(err, product.ErrProductUnavailable) {
+				t.Errorf("expecting error to match ErrProductUnavailable")
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package product
+
+// DietaryTag is a dietary property of a product, such as vegan, for customers to filter the menu by.
+type DietaryTag uint8
+
+// The values of the tags are the bits of DietaryTags, and are stored, so they must never change.
+const (
+	DietaryTagVegetarian DietaryTag = iota
+	DietaryTagVegan
+	DietaryTagGlutenFree
+	DietaryTagDairyFree
+	DietaryTagNutFree
+	DietaryTagHalal
+	DietaryTagCaffeineFree
+	dietaryTagCount
+)
+
+func (t DietaryTag) String() string {
+	switch t {
+	case DietaryTagVegetarian:
+		return "vegetarian"
+	case DietaryTagVegan:
+		return "vegan"
+	case DietaryTagGlutenFree:
+		return "gluten_free"
+	case DietaryTagDairyFree:
+		return "dairy_free"
+	case DietaryTagNutFree:
+		return "nut_free"
+	case DietaryTagHalal:
+		return "halal"
+	case DietaryTagCaffeineFree:
+		return "caffeine_free"
+	default:
+		return ""
+	}
+}
+
+// ParseDietaryTag parses the tag from its name, e.g. "gluten_free".
+func ParseDietaryTag(s string) (DietaryTag, bool) {
+	for t := DietaryTag(0); t < dietaryTagCount; t++ {
+		if t.String() == s {
+			return t, true
+		}
+	}
+
+	return 0, false
+}
+
+// DietaryTags is a set of dietary tags, each tag is a bit.
+type DietaryTags uint64
+
+// NewDietaryTags returns the set of the given tags.
+func NewDietaryTags(tags ...DietaryTag) DietaryTags {
+	var set DietaryTags
+	for _, t := range tags {
+		set |= 1 << t
+	}
+
+	return set
+}
+
+// ParseDietaryTags parses the set from the names of its tags. It returns false if any of them is unknown.
+func ParseDietaryTags(names []string) (DietaryTags, bool) {
+	var set DietaryTags
+	for _, name := range names {
+		t, ok := ParseDietaryTag(name)
+		if !ok {
+			return 0, false
+		}
+		set |= 1 << t
+	}
+
+	return set, true
+}
+
+// Has reports whether the set has every tag of the other set.
+func (s DietaryTags) Has(other DietaryTags) bool {
+	return s&other == other
+}
+
+// Tags returns the tags of the set, in their order.
+func (s DietaryTags) Tags() []DietaryTag {
+	var tags []DietaryTag
+	for t := DietaryTag(0); t < dietaryTagCount; t++ {
+		if s&(1<<t) != 0 {
+			tags = append(tags, t)
+		}
+	}
+
+	return tags
+}
+
+// Names returns the names of the tags of the set, in their order.
+func (s DietaryTags) Names() []string {
+	names := make([]string, 0, len(s.Tags()))
+	for _, t := range s.Tags() {
+		names = append(names, t.String())
+	}
+
+	return names
+}
+
+// valid reports whether the set only has known tags.
+func (s DietaryTags) valid() bool {
+	return s < 1<<dietaryTagCount
+}
@@ -0,0 +1,247 @@
+package product
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var ErrModifierGroupNotFound = errors.New("modifier group not found")
+
+// ErrModifierGroupInUse indicates that the group is still attached to products, it must be detached first.
+var ErrModifierGroupInUse = errors.New("modifier group is attached to products")
+
+// ErrInvalidSelection is matched by every *SelectionError.
+var ErrInvalidSelection = errors.New("invalid modifier selection")
+
+// ModifierGroup is a choice the customer makes about a product, such as the sugar level or extra espresso
+// shots. The same group can be attached to many products.
+type ModifierGroup struct {
+	ID   int64
+	Name string
+	// MinSelections is the fewest options the customer must select, the group is optional if it's zero.
+	MinSelections int
+	// MaxSelections is the most options the customer can select.
+	MaxSelections int
+	// Options are ordered by their position.
+	Options   []ModifierOption
+	CreatedAt time.Time
+	CreatedBy string
+	UpdatedAt time.Time
+	UpdatedBy string
+}
+
+// Required reports whether the customer must select at least one option.
+func (g ModifierGroup) Required() bool {
+	return g.MinSelections > 0
+}
+
+// ModifierOption is one of the options of a group, such as "Less sugar".
+type ModifierOption struct {
+	ID      int64
+	GroupID int64
+	Name    string
+	// PriceDelta is added to the base price of the product, it's negative for options that cost less.
+	PriceDelta Price
+	// Default options are selected if the customer selects nothing in the group.
+	Default bool
+}
+
+// RawModifierGroup holds the values of a modifier group to be inserted or to replace an existing one.
+type RawModifierGroup struct {
+	Name          string
+	MinSelections int
+	MaxSelections int
+	// Options replaces every option of the group, in their order. Options with the ID of an existing option
+	// update it and keep their ID, the existing options that are left out are removed.
+	Options []RawModifierOption
+}
+
+type RawModifierOption struct {
+	// ID is zero for a new option.
+	ID         int64
+	Name       string
+	PriceDelta Price
+	Default    bool
+}
+
+const (
+	maximumOptionsPerGroup = 50
+	// maximumPriceDelta bounds the price delta of an option either way.
+	maximumPriceDelta Price = 1_000_000 * MinorUnitsPerRupiah
+)
+
+// Normalize trims the text fields.
+func (g RawModifierGroup) Normalize() RawModifierGroup {
+	g.Name = strings.TrimSpace(g.Name)
+
+	options := make([]RawModifierOption, len(g.Options))
+	for i, option := range g.Options {
+		option.Name = strings.TrimSpace(option.Name)
+		options[i] = option
+	}
+	g.Options = options
+
+	return g
+}
+
+// Validate checks a normalized RawModifierGroup, and returns a *ValidationError for the first invalid
+// field. The default options, if there are any, must be a valid selection by themselves.
+func (g RawModifierGroup) Validate() error {
+	if g.Name == "" || utf8.RuneCountInString(g.Name) > maximumNameLength {
+		return &ValidationError{Field: "name", Message: fmt.Sprintf("must be between 1 and %d characters", maximumNameLength)}
+	}
+	if len(g.Options) == 0 || len(g.Options) > maximumOptionsPerGroup {
+		return &ValidationError{Field: "options", Message: fmt.Sprintf("must have between 1 and %d options", maximumOptionsPerGroup)}
+	}
+	if g.MaxSelections < 1 || g.MaxSelections > len(g.Options) {
+		return &ValidationError{Field: "max_selections", Message: "must be between 1 and the number of options"}
+	}
+	if g.MinSelections < 0 || g.MinSelections > g.MaxSelections {
+		return &ValidationError{Field: "min_selections", Message: "must be between 0 and max_selections"}
+	}
+
+	names := make(map[string]bool, len(g.Options))
+	ids := make(map[int64]bool, len(g.Options))
+	defaults := 0
+	for _, option := range g.Options {
+		if option.Name == "" || utf8.RuneCountInString(option.Name) > maximumNameLength {
+			return &ValidationError{Field: "options", Message: fmt.Sprintf("option names must be between 1 and %d characters", maximumNameLength)}
+		}
+		if names[strings.ToLower(option.Name)] {
+			return &ValidationError{Field: "options", Message: fmt.Sprintf("%q is listed more than once", option.Name)}
+		}
+		names[strings.ToLower(option.Name)] = true
+
+		if option.ID < 0 || (option.ID > 0 && ids[option.ID]) {
+			return &ValidationError{Field: "options", Message: fmt.Sprintf("option ID %d is invalid", option.ID)}
+		}
+		ids[option.ID] = true
+
+		if option.PriceDelta < -maximumPriceDelta || option.PriceDelta > maximumPriceDelta {
+			return &ValidationError{Field: "options", Message: fmt.Sprintf("price delta of %q must be between -%d and %d", option.Name, maximumPriceDelta, maximumPriceDelta)}
+		}
+
+		if option.Default {
+			defaults++
+		}
+	}
+
+	if defaults > 0 && (defaults < g.MinSelections || defaults > g.MaxSelections) {
+		return &ValidationError{Field: "options", Message: fmt.Sprintf("must have between %d and %d default options, or none", g.MinSelections, g.MaxSelections)}
+	}
+
+	return nil
+}
+
+// SelectionError describes why a selection of modifier options is not allowed.
+type SelectionError struct {
+	// GroupID is the group the error is about, zero if it's about an option outside of the groups.
+	GroupID int64
+	// OptionID is the option the error is about, zero if it's about the group as a whole.
+	OptionID int64
+	Message  string
+}
+
+func (e *SelectionError) Error() string {
+	switch {
+	case e.OptionID != 0:
+		return fmt.Sprintf("option %d: %s", e.OptionID, e.Message)
+	case e.GroupID != 0:
+		return fmt.Sprintf("modifier group %d: %s", e.GroupID, e.Message)
+	default:
+		return e.Message
+	}
+}
+
+func (e *SelectionError) Is(target error) bool {
+	return target == ErrInvalidSelection
+}
+
+// Configuration is a product with the options the customer selected, priced by the server.
+type Configuration struct {
+	Product Product
+	// Options are the selected ones, along with the defaults of the groups the customer selected nothing in,
+	// in the order of their groups and then of the options.
+	Options []ModifierOption
+	// UnitPrice is the base price plus the price deltas of the options, never below zero.
+	UnitPrice Price
+}
+
+// Configure validates the options the customer selected for the product against its modifier groups, and
+// prices it. Groups the customer selected nothing in take their default options. It returns a
+// *SelectionError if an option is not one of the groups, is selected more than once, or a group ends up
+// with too few or too many options.
+//
+// The prices come only from the product and its groups, resolved with the prices of the store the product
+// is ordered from, never from the client.
+func Configure(p Product, groups []ModifierGroup, optionIds []int64, prices StorePrices) (Configuration, error) {
+	p, groups = prices.Resolve(p, groups)
+
+	selected := make(map[int64]bool, len(optionIds))
+	for _, optionId := range optionIds {
+		if selected[optionId] {
+			return Configuration{}, &SelectionError{OptionID: optionId, Message: "is selected more than once"}
+		}
+		selected[optionId] = true
+	}
+
+	configuration := Configuration{Product: p, UnitPrice: p.BasePrice}
+	found := 0
+
+	for _, group := range groups {
+		var options []ModifierOption
+		for _, option := range group.Options {
+			if selected[option.ID] {
+				options = append(options, option)
+			}
+		}
+		found += len(options)
+
+		if len(options) == 0 {
+			for _, option := range group.Options {
+				if option.Default {
+					options = append(options, option)
+				}
+			}
+		}
+
+		if len(options) < group.MinSelections {
+			return Configuration{}, &SelectionError{GroupID: group.ID, Message: fmt.Sprintf("%s requires at least %d options", group.Name, group.MinSelections)}
+		}
+		if len(options) > group.MaxSelections {
+			return Configuration{}, &SelectionError{GroupID: group.ID, Message: fmt.Sprintf("%s allows at most %d options", group.Name, group.MaxSelections)}
+		}
+
+		for _, option := range options {
+			configuration.Options = append(configuration.Options, option)
+			configuration.UnitPrice += option.PriceDelta
+		}
+	}
+
+	if found < len(selected) {
+		for _, optionId := range optionIds {
+			if !configuration.hasOption(optionId) {
+				return Configuration{}, &SelectionError{OptionID: optionId, Message: "is not an option of the product"}
+			}
+		}
+	}
+
+	if configuration.UnitPrice < 0 {
+		configuration.UnitPrice = 0
+	}
+
+	return configuration, nil
+}
+
+func (c Configuration) hasOption(optionId int64) bool {
+	for _, option := range c.Options {
+		if option.ID == optionId {
+			return true
+		}
+	}
+
+	return false
+}
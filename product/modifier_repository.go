@@ -0,0 +1,753 @@
+package product
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"coffee-chain-api/account"
+
+	"github.com/rs/zerolog/log"
+)
+
+type modifierGroupsTable struct {
+	ID            int64
+	Name          string
+	MinSelections int
+	MaxSelections int
+	CreatedAt     time.Time
+	CreatedBy     string
+	UpdatedAt     time.Time
+	UpdatedBy     string
+}
+
+// modifierGroupsColumns is the column list for selecting modifierGroupsTable, in the same order as
+// modifierGroupsTable.scanDestinations. The columns are qualified, for the queries that join the table.
+const modifierGroupsColumns = `modifier_groups.id,
+				modifier_groups.name,
+				modifier_groups.min_selections,
+				modifier_groups.max_selections,
+				modifier_groups.created_at,
+				modifier_groups.created_by,
+				modifier_groups.updated_at,
+				modifier_groups.updated_by`
+
+func (g *modifierGroupsTable) scanDestinations() []any {
+	return []any{
+		&g.ID,
+		&g.Name,
+		&g.MinSelections,
+		&g.MaxSelections,
+		&g.CreatedAt,
+		&g.CreatedBy,
+		&g.UpdatedAt,
+		&g.UpdatedBy,
+	}
+}
+
+func (g *modifierGroupsTable) modifierGroup() ModifierGroup {
+	return ModifierGroup{
+		ID:            g.ID,
+		Name:          g.Name,
+		MinSelections: g.MinSelections,
+		MaxSelections: g.MaxSelections,
+		CreatedAt:     g.CreatedAt,
+		CreatedBy:     g.CreatedBy,
+		UpdatedAt:     g.UpdatedAt,
+		UpdatedBy:     g.UpdatedBy,
+	}
+}
+
+// queryer is either a connection or a transaction.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// selectModifierGroups runs the query, which selects modifierGroupsColumns, and returns the groups along
+// with their options.
+func selectModifierGroups(ctx context.Context, q queryer, query string, args ...any) ([]ModifierGroup, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("executing select query: %w", err)
+	}
+
+	var groups []ModifierGroup
+	for rows.Next() {
+		var group modifierGroupsTable
+		err = rows.Scan(group.scanDestinations()...)
+		if err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("scanning row: %w", err)
+		}
+
+		groups = append(groups, group.modifierGroup())
+	}
+
+	err = rows.Err()
+	if err != nil {
+		_ = rows.Close()
+		return nil, fmt.Errorf("iterating rows: %w", err)
+	}
+
+	err = rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("closing rows: %w", err)
+	}
+
+	if len(groups) == 0 {
+		return groups, nil
+	}
+
+	placeholders := make([]string, 0, len(groups))
+	optionArgs := make([]any, 0, len(groups))
+	indexes := make(map[int64]int, len(groups))
+	for i, group := range groups {
+		optionArgs = append(optionArgs, group.ID)
+		placeholders = append(placeholders, "$"+strconv.Itoa(len(optionArgs)))
+		indexes[group.ID] = i
+	}
+
+	rows, err = q.QueryContext(
+		ctx,
+		`SELECT
+				id,
+				group_id,
+				name,
+				price_delta,
+				is_default
+			FROM
+				modifier_options
+			WHERE
+				group_id IN (`+strings.Join(placeholders, ", ")+`)
+				AND deleted_at IS NULL
+			ORDER BY
+				group_id, position, id`,
+		optionArgs...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("executing select query: %w", err)
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing rows")
+		}
+	}()
+
+	for rows.Next() {
+		var option ModifierOption
+		err = rows.Scan(&option.ID, &option.GroupID, &option.Name, &option.PriceDelta, &option.Default)
+		if err != nil {
+			return nil, fmt.Errorf("scanning row: %w", err)
+		}
+
+		i := indexes[option.GroupID]
+		groups[i].Options = append(groups[i].Options, option)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("iterating rows: %w", err)
+	}
+
+	return groups, nil
+}
+
+func (r *repository) GetModifierGroup(ctx context.Context, id int64) (ModifierGroup, error) {
+	if id <= 0 {
+		return ModifierGroup{}, ErrModifierGroupNotFound
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return ModifierGroup{}, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	groups, err := selectModifierGroups(
+		ctx,
+		conn,
+		`SELECT
+				`+modifierGroupsColumns+`
+			FROM
+				modifier_groups
+			WHERE
+				id = $1
+				AND deleted_at IS NULL`,
+		id,
+	)
+	if err != nil {
+		return ModifierGroup{}, fmt.Errorf("getting modifier group by id: %w", err)
+	}
+
+	if len(groups) == 0 {
+		return ModifierGroup{}, ErrModifierGroupNotFound
+	}
+
+	return groups[0], nil
+}
+
+func (r *repository) ListModifierGroups(ctx context.Context) ([]ModifierGroup, error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	return selectModifierGroups(
+		ctx,
+		conn,
+		`SELECT
+				`+modifierGroupsColumns+`
+			FROM
+				modifier_groups
+			WHERE
+				deleted_at IS NULL
+			ORDER BY
+				name ASC,
+				id ASC`,
+	)
+}
+
+func (r *repository) InsertModifierGroup(ctx context.Context, rawGroup RawModifierGroup) (ModifierGroup, error) {
+	rawGroup = rawGroup.Normalize()
+	err := rawGroup.Validate()
+	if err != nil {
+		return ModifierGroup{}, err
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return ModifierGroup{}, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		return ModifierGroup{}, fmt.Errorf("creating transaction: %w", err)
+	}
+
+	now := time.Now()
+	var id int64
+	err = tx.QueryRowContext(
+		ctx,
+		`INSERT INTO
+			modifier_groups
+			(name,
+			 min_selections,
+			 max_selections,
+			 created_at,
+			 created_by,
+			 updated_at,
+			 updated_by
+			 )
+		VALUES
+			($1, $2, $3, $4, $5, $6, $7)
+		RETURNING
+			id`,
+		rawGroup.Name,
+		rawGroup.MinSelections,
+		rawGroup.MaxSelections,
+		now,
+		account.ActorIdentifier(ctx),
+		now,
+		account.ActorIdentifier(ctx),
+	).Scan(&id)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return ModifierGroup{}, fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		return ModifierGroup{}, fmt.Errorf("executing insert query: %w", err)
+	}
+
+	group, err := replaceModifierOptions(ctx, tx, id, rawGroup.Options)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return ModifierGroup{}, fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		return ModifierGroup{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return ModifierGroup{}, fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return group, nil
+}
+
+func (r *repository) UpdateModifierGroup(ctx context.Context, id int64, rawGroup RawModifierGroup) (ModifierGroup, error) {
+	if id <= 0 {
+		return ModifierGroup{}, ErrModifierGroupNotFound
+	}
+
+	rawGroup = rawGroup.Normalize()
+	err := rawGroup.Validate()
+	if err != nil {
+		return ModifierGroup{}, err
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return ModifierGroup{}, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		return ModifierGroup{}, fmt.Errorf("creating transaction: %w", err)
+	}
+
+	result, err := tx.ExecContext(
+		ctx,
+		`UPDATE
+			modifier_groups
+		SET
+			name = $1,
+			min_selections = $2,
+			max_selections = $3,
+			updated_at = $4,
+			updated_by = $5
+		WHERE
+			id = $6
+			AND deleted_at IS NULL`,
+		rawGroup.Name,
+		rawGroup.MinSelections,
+		rawGroup.MaxSelections,
+		time.Now(),
+		account.ActorIdentifier(ctx),
+		id,
+	)
+	if err == nil {
+		var affected int64
+		affected, err = result.RowsAffected()
+		if err == nil && affected == 0 {
+			err = ErrModifierGroupNotFound
+		}
+	}
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return ModifierGroup{}, fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		if errors.Is(err, ErrModifierGroupNotFound) {
+			return ModifierGroup{}, err
+		}
+
+		return ModifierGroup{}, fmt.Errorf("executing update query: %w", err)
+	}
+
+	group, err := replaceModifierOptions(ctx, tx, id, rawGroup.Options)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return ModifierGroup{}, fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		return ModifierGroup{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return ModifierGroup{}, fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return group, nil
+}
+
+// replaceModifierOptions replaces the options of the group, see RawModifierGroup.Options, and returns the
+// group as updated.
+func replaceModifierOptions(ctx context.Context, tx *sql.Tx, groupId int64, options []RawModifierOption) (ModifierGroup, error) {
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT id FROM modifier_options WHERE group_id = $1 AND deleted_at IS NULL`,
+		groupId,
+	)
+	if err != nil {
+		return ModifierGroup{}, fmt.Errorf("executing select query: %w", err)
+	}
+
+	existing := make(map[int64]bool)
+	for rows.Next() {
+		var id int64
+		err = rows.Scan(&id)
+		if err != nil {
+			_ = rows.Close()
+			return ModifierGroup{}, fmt.Errorf("scanning row: %w", err)
+		}
+		existing[id] = true
+	}
+
+	err = rows.Err()
+	if err != nil {
+		_ = rows.Close()
+		return ModifierGroup{}, fmt.Errorf("iterating rows: %w", err)
+	}
+
+	err = rows.Close()
+	if err != nil {
+		return ModifierGroup{}, fmt.Errorf("closing rows: %w", err)
+	}
+
+	now := time.Now()
+	for position, option := range options {
+		if option.ID == 0 {
+			_, err = tx.ExecContext(
+				ctx,
+				`INSERT INTO
+					modifier_options
+					(group_id,
+					 name,
+					 price_delta,
+					 is_default,
+					 position,
+					 created_at
+					 )
+				VALUES
+					($1, $2, $3, $4, $5, $6)`,
+				groupId,
+				option.Name,
+				option.PriceDelta,
+				option.Default,
+				position,
+				now,
+			)
+			if err != nil {
+				return ModifierGroup{}, fmt.Errorf("executing insert query: %w", err)
+			}
+
+			continue
+		}
+
+		if !existing[option.ID] {
+			return ModifierGroup{}, &ValidationError{Field: "options", Message: fmt.Sprintf("option %d is not an option of the group", option.ID)}
+		}
+		delete(existing, option.ID)
+
+		_, err = tx.ExecContext(
+			ctx,
+			`UPDATE
+				modifier_options
+			SET
+				name = $1,
+				price_delta = $2,
+				is_default = $3,
+				position = $4
+			WHERE
+				id = $5`,
+			option.Name,
+			option.PriceDelta,
+			option.Default,
+			position,
+			option.ID,
+		)
+		if err != nil {
+			return ModifierGroup{}, fmt.Errorf("executing update query: %w", err)
+		}
+	}
+
+	// The options left out are soft-deleted, so their IDs are never reused.
+	for id := range existing {
+		_, err = tx.ExecContext(
+			ctx,
+			`UPDATE modifier_options SET deleted_at = $1 WHERE id = $2`,
+			now,
+			id,
+		)
+		if err != nil {
+			return ModifierGroup{}, fmt.Errorf("executing update query: %w", err)
+		}
+	}
+
+	groups, err := selectModifierGroups(
+		ctx,
+		tx,
+		`SELECT
+				`+modifierGroupsColumns+`
+			FROM
+				modifier_groups
+			WHERE
+				id = $1`,
+		groupId,
+	)
+	if err != nil {
+		return ModifierGroup{}, fmt.Errorf("getting modifier group by id: %w", err)
+	}
+
+	if len(groups) == 0 {
+		return ModifierGroup{}, ErrModifierGroupNotFound
+	}
+
+	return groups[0], nil
+}
+
+func (r *repository) DeleteModifierGroup(ctx context.Context, id int64) error {
+	if id <= 0 {
+		return ErrModifierGroupNotFound
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		return fmt.Errorf("creating transaction: %w", err)
+	}
+
+	var inUse bool
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT
+				EXISTS (
+					SELECT
+						1
+					FROM
+						product_modifier_groups
+						JOIN products ON products.id = product_modifier_groups.product_id
+					WHERE
+						product_modifier_groups.modifier_group_id = modifier_groups.id
+						AND products.deleted_at IS NULL
+				)
+			FROM
+				modifier_groups
+			WHERE
+				id = $1
+				AND deleted_at IS NULL
+			FOR UPDATE OF modifier_groups`,
+		id,
+	).Scan(&inUse)
+	if err == nil && inUse {
+		err = ErrModifierGroupInUse
+	}
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrModifierGroupNotFound
+		}
+		if errors.Is(err, ErrModifierGroupInUse) {
+			return err
+		}
+
+		return fmt.Errorf("getting modifier group by id: %w", err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE
+			modifier_groups
+		SET
+			deleted_at = $1,
+			updated_at = $1,
+			updated_by = $2
+		WHERE
+			id = $3`,
+		time.Now(),
+		account.ActorIdentifier(ctx),
+		id,
+	)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		return fmt.Errorf("executing update query: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repository) GetProductModifierGroups(ctx context.Context, productId int64) ([]ModifierGroup, error) {
+	if productId <= 0 {
+		return nil, nil
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	return getProductModifierGroups(ctx, conn, productId)
+}
+
+func getProductModifierGroups(ctx context.Context, q queryer, productId int64) ([]ModifierGroup, error) {
+	return selectModifierGroups(
+		ctx,
+		q,
+		`SELECT
+				`+modifierGroupsColumns+`
+			FROM
+				product_modifier_groups
+				JOIN modifier_groups ON modifier_groups.id = product_modifier_groups.modifier_group_id
+			WHERE
+				product_modifier_groups.product_id = $1
+				AND modifier_groups.deleted_at IS NULL
+			ORDER BY
+				product_modifier_groups.position ASC`,
+		productId,
+	)
+}
+
+func (r *repository) SetProductModifierGroups(ctx context.Context, productId int64, groupIds []int64) error {
+	if productId <= 0 {
+		return ErrNotFound
+	}
+
+	seen := make(map[int64]bool, len(groupIds))
+	for _, groupId := range groupIds {
+		if groupId <= 0 || seen[groupId] {
+			return &ValidationError{Field: "modifier_group_ids", Message: "must be distinct modifier group IDs"}
+		}
+		seen[groupId] = true
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		return fmt.Errorf("creating transaction: %w", err)
+	}
+
+	err = setProductModifierGroups(ctx, tx, productId, groupIds)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+func setProductModifierGroups(ctx context.Context, tx *sql.Tx, productId int64, groupIds []int64) error {
+	var id int64
+	err := tx.QueryRowContext(
+		ctx,
+		`SELECT id FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`,
+		productId,
+	).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+
+		return fmt.Errorf("getting product by id: %w", err)
+	}
+
+	for _, groupId := range groupIds {
+		// Keep the group from being deleted until the transaction is done.
+		err = tx.QueryRowContext(
+			ctx,
+			`SELECT id FROM modifier_groups WHERE id = $1 AND deleted_at IS NULL FOR SHARE`,
+			groupId,
+		).Scan(&id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrModifierGroupNotFound
+			}
+
+			return fmt.Errorf("getting modifier group by id: %w", err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM product_modifier_groups WHERE product_id = $1`, productId)
+	if err != nil {
+		return fmt.Errorf("executing delete query: %w", err)
+	}
+
+	for position, groupId := range groupIds {
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO
+				product_modifier_groups
+				(product_id,
+				 modifier_group_id,
+				 position
+				 )
+			VALUES
+				($1, $2, $3)`,
+			productId,
+			groupId,
+			position,
+		)
+		if err != nil {
+			return fmt.Errorf("executing insert query: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,213 @@
+package product_test
+
+import (
+	"errors"
+	"testing"
+
+	"coffee-chain-api/product"
+)
+
+func TestRawModifierGroup_Validate(t *testing.T) {
+	valid := product.RawModifierGroup{
+		Name:          " Sugar ",
+		MinSelections: 1,
+		MaxSelections: 1,
+		Options: []product.RawModifierOption{
+			{Name: "Normal sugar", Default: true},
+			{Name: "Less sugar"},
+			{Name: "No sugar"},
+		},
+	}
+
+	testCases := []struct {
+		name   string
+		modify func(g *product.RawModifierGroup)
+		field  string
+	}{
+		{name: "valid", modify: func(g *product.RawModifierGroup) {}, field: ""},
+		{name: "empty name", modify: func(g *product.RawModifierGroup) { g.Name = "" }, field: "name"},
+		{name: "no options", modify: func(g *product.RawModifierGroup) { g.Options = nil }, field: "options"},
+		{name: "zero max", modify: func(g *product.RawModifierGroup) { g.MaxSelections = 0 }, field: "max_selections"},
+		{name: "max above the options", modify: func(g *product.RawModifierGroup) { g.MaxSelections = 4 }, field: "max_selections"},
+		{name: "min above max", modify: func(g *product.RawModifierGroup) { g.MinSelections = 2 }, field: "min_selections"},
+		{name: "negative min", modify: func(g *product.RawModifierGroup) { g.MinSelections = -1 }, field: "min_selections"},
+		{name: "optional", modify: func(g *product.RawModifierGroup) { g.MinSelections = 0 }, field: ""},
+		{name: "no defaults", modify: func(g *product.RawModifierGroup) { g.Options[0].Default = false }, field: ""},
+		{name: "more defaults than max", modify: func(g *product.RawModifierGroup) { g.Options[1].Default = true }, field: "options"},
+		{name: "fewer defaults than min", modify: func(g *product.RawModifierGroup) {
+			g.MinSelections, g.MaxSelections = 2, 3
+		}, field: "options"},
+		{name: "same option twice", modify: func(g *product.RawModifierGroup) { g.Options[2].Name = "less SUGAR" }, field: "options"},
+		{name: "empty option name", modify: func(g *product.RawModifierGroup) { g.Options[2].Name = " " }, field: "options"},
+		{name: "same option ID twice", modify: func(g *product.RawModifierGroup) {
+			g.Options[0].ID, g.Options[1].ID = 7, 7
+		}, field: "options"},
+		{name: "negative price delta", modify: func(g *product.RawModifierGroup) { g.Options[2].PriceDelta = -500 }, field: ""},
+		{name: "price delta too high", modify: func(g *product.RawModifierGroup) {
+			g.Options[2].PriceDelta = 1_000_000*product.MinorUnitsPerRupiah + 1
+		}, field: "options"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			rawGroup := valid
+			rawGroup.Options = append([]product.RawModifierOption(nil), valid.Options...)
+			tt.modify(&rawGroup)
+
+			err := rawGroup.Normalize().Validate()
+			if tt.field == "" {
+				if err != nil {
+					t.Errorf("expecting no error, got %v instead", err)
+				}
+				return
+			}
+
+			var validationError *product.ValidationError
+			if !errors.As(err, &validationError) {
+				t.Fatalf("expecting a validation error, got %v instead", err)
+			}
+			if validationError.Field != tt.field {
+				t.Errorf("expecting invalid %s, got invalid %s instead", tt.field, validationError.Field)
+			}
+		})
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	latte := product.Product{ID: 1, Name: "Caffe Latte", BasePrice: 30_000_00}
+
+	// Required single choice with a default.
+	sugar := product.ModifierGroup{ID: 10, Name: "Sugar", MinSelections: 1, MaxSelections: 1, Options: []product.ModifierOption{
+		{ID: 100, GroupID: 10, Name: "Normal sugar", Default: true},
+		{ID: 101, GroupID: 10, Name: "Less sugar"},
+		{ID: 102, GroupID: 10, Name: "Palm sugar", PriceDelta: 3_000_00},
+	}}
+	// Optional, up to two.
+	extras := product.ModifierGroup{ID: 20, Name: "Extras", MinSelections: 0, MaxSelections: 2, Options: []product.ModifierOption{
+		{ID: 200, GroupID: 20, Name: "Extra espresso", PriceDelta: 5_000_00},
+		{ID: 201, GroupID: 20, Name: "Oat milk", PriceDelta: 8_000_00},
+		{ID: 202, GroupID: 20, Name: "Whipped cream", PriceDelta: 4_000_00},
+	}}
+	// Required without a default.
+	temperature := product.ModifierGroup{ID: 30, Name: "Temperature", MinSelections: 1, MaxSelections: 1, Options: []product.ModifierOption{
+		{ID: 300, GroupID: 30, Name: "Hot"},
+		{ID: 301, GroupID: 30, Name: "Iced", PriceDelta: 2_000_00},
+	}}
+	// Optional discount, to push the price below zero.
+	bring := product.ModifierGroup{ID: 40, Name: "Own cup", MinSelections: 0, MaxSelections: 1, Options: []product.ModifierOption{
+		{ID: 400, GroupID: 40, Name: "Bring your own cup", PriceDelta: -50_000_00},
+	}}
+
+	testCases := []struct {
+		name      string
+		groups    []product.ModifierGroup
+		optionIds []int64
+		expect    []int64
+		price     product.Price
+		groupId   int64
+		optionId  int64
+	}{
+		{
+			name:   "no groups",
+			expect: nil,
+			price:  30_000_00,
+		},
+		{
+			name:   "defaults",
+			groups: []product.ModifierGroup{sugar, extras},
+			expect: []int64{100},
+			price:  30_000_00,
+		},
+		{
+			name:      "selection replaces the default",
+			groups:    []product.ModifierGroup{sugar, extras},
+			optionIds: []int64{102, 200},
+			expect:    []int64{102, 200},
+			price:     38_000_00,
+		},
+		{
+			name:      "in the order of the groups and options",
+			groups:    []product.ModifierGroup{sugar, extras, temperature},
+			optionIds: []int64{301, 201, 200},
+			expect:    []int64{100, 200, 201, 301},
+			price:     45_000_00,
+		},
+		{
+			name:     "required without a default",
+			groups:   []product.ModifierGroup{sugar, temperature},
+			groupId:  30,
+			optionId: 0,
+		},
+		{
+			name:      "two of a single choice",
+			groups:    []product.ModifierGroup{sugar},
+			optionIds: []int64{100, 101},
+			groupId:   10,
+		},
+		{
+			name:      "above the maximum",
+			groups:    []product.ModifierGroup{extras},
+			optionIds: []int64{200, 201, 202},
+			groupId:   20,
+		},
+		{
+			name:      "option of another product",
+			groups:    []product.ModifierGroup{sugar},
+			optionIds: []int64{200},
+			optionId:  200,
+		},
+		{
+			name:      "same option twice",
+			groups:    []product.ModifierGroup{extras},
+			optionIds: []int64{200, 200},
+			optionId:  200,
+		},
+		{
+			name:      "never below zero",
+			groups:    []product.ModifierGroup{bring},
+			optionIds: []int64{400},
+			expect:    []int64{400},
+			price:     0,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := product.Configure(latte, tt.groups, tt.optionIds, product.StorePrices{})
+			if tt.groupId != 0 || tt.optionId != 0 {
+				var selectionError *product.SelectionError
+				if !errors.As(err, &selectionError) {
+					t.Fatalf("expecting a selection error, got %v instead", err)
+				}
+				if selectionError.GroupID != tt.groupId || selectionError.OptionID != tt.optionId {
+					t.Errorf("expecting error on group %d option %d, got group %d option %d instead", tt.groupId, tt.optionId, selectionError.GroupID, selectionError.OptionID)
+				}
+				if !errors.Is(err, product.ErrInvalidSelection) {
+					t.Errorf("expecting error to match ErrInvalidSelection")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expecting no error, got %v instead", err)
+			}
+
+			var ids []int64
+			for _, option := range got.Options {
+				ids = append(ids, option.ID)
+			}
+			if len(ids) != len(tt.expect) {
+				t.Fatalf("expecting options %v, got %v instead", tt.expect, ids)
+			}
+			for i := range ids {
+				if ids[i] != tt.expect[i] {
+					t.Fatalf("expecting options %v, got %v instead", tt.expect, ids)
+				}
+			}
+
+			if got.UnitPrice != tt.price {
+				t.Errorf("expecting unit price %s, got %s instead", tt.price, got.UnitPrice)
+			}
+		})
+	}
+}
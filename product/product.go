@@ -0,0 +1,280 @@
+package product
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var ErrNotFound = errors.New("product not found")
+
+var ErrCategoryNotFound = errors.New("product category not found")
+
+// ErrCategoryInUse indicates that the category still has products, they must be moved or deleted first.
+var ErrCategoryInUse = errors.New("product category has products")
+
+// ErrInvalidProduct is matched by every *ValidationError.
+var ErrInvalidProduct = errors.New("invalid product")
+
+// Price is an amount of Indonesian Rupiah in minor units, 100 to the Rupiah. Amounts are never floats, so
+// they add up exactly.
+type Price int64
+
+// MinorUnitsPerRupiah is the number of minor units in a Rupiah.
+const MinorUnitsPerRupiah = 100
+
+// MaximumPrice is the highest base price of a product, IDR 10,000,000.
+const MaximumPrice Price = 10_000_000 * MinorUnitsPerRupiah
+
+// Rupiah returns the price in whole Rupiah, rounded down.
+func (p Price) Rupiah() int64 {
+	return int64(p) / MinorUnitsPerRupiah
+}
+
+func (p Price) String() string {
+	return fmt.Sprintf("IDR %d.%02d", int64(p)/MinorUnitsPerRupiah, int64(p)%MinorUnitsPerRupiah)
+}
+
+// Category groups the products on the menu, such as "Coffee" or "Pastry".
+type Category struct {
+	ID   int64
+	Name string
+	// Position orders the categories on the menu, lowest first. Categories at the same position are
+	// ordered by their ID.
+	Position  int
+	CreatedAt time.Time
+	CreatedBy string
+	UpdatedAt time.Time
+	UpdatedBy string
+}
+
+type Product struct {
+	ID          int64
+	CategoryID  int64
+	Name        string
+	Description string
+	// ImageURL is empty if the product has no image.
+	ImageURL  string
+	BasePrice Price
+	// Active products are shown to the customers and can be ordered.
+	Active        bool
+	QuantityLimit QuantityLimit
+	DietaryTags   DietaryTags
+	CreatedAt     time.Time
+	CreatedBy     string
+	UpdatedAt     time.Time
+	UpdatedBy     string
+}
+
+// RawCategory holds the values of a category to be inserted or to replace an existing one.
+type RawCategory struct {
+	Name     string
+	Position int
+}
+
+// RawProduct holds the values of a product to be inserted or to replace an existing one.
+type RawProduct struct {
+	CategoryID  int64
+	Name        string
+	Description string
+	ImageURL    string
+	BasePrice   Price
+	Active      bool
+	// QuantityLimit is not limited if it's the zero value.
+	QuantityLimit QuantityLimit
+	DietaryTags   DietaryTags
+}
+
+// ValidationError describes the first invalid field of a RawProduct or a RawCategory.
+type ValidationError struct {
+	// Field is the snake_cased field name, such as "base_price".
+	Field   string
+	Message string
+}
+
+func (v *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", v.Field, v.Message)
+}
+
+func (v *ValidationError) Is(target error) bool {
+	return target == ErrInvalidProduct
+}
+
+const (
+	maximumNameLength        = 255
+	maximumDescriptionLength = 2047
+	maximumImageURLLength    = 2047
+	maximumPosition          = 10_000
+)
+
+// Normalize trims the text fields.
+func (c RawCategory) Normalize() RawCategory {
+	c.Name = strings.TrimSpace(c.Name)
+
+	return c
+}
+
+// Validate checks a normalized RawCategory, and returns a *ValidationError for the first invalid field.
+func (c RawCategory) Validate() error {
+	if c.Name == "" || utf8.RuneCountInString(c.Name) > maximumNameLength {
+		return &ValidationError{Field: "name", Message: fmt.Sprintf("must be between 1 and %d characters", maximumNameLength)}
+	}
+	if c.Position < 0 || c.Position > maximumPosition {
+		return &ValidationError{Field: "position", Message: fmt.Sprintf("must be between 0 and %d", maximumPosition)}
+	}
+
+	return nil
+}
+
+// Normalize trims the text fields.
+func (p RawProduct) Normalize() RawProduct {
+	p.Name = strings.TrimSpace(p.Name)
+	p.Description = strings.TrimSpace(p.Description)
+	p.ImageURL = strings.TrimSpace(p.ImageURL)
+
+	return p
+}
+
+// Validate checks a normalized RawProduct, and returns a *ValidationError for the first invalid field.
+// Whether the category exists is checked by the repository.
+func (p RawProduct) Validate() error {
+	if p.CategoryID <= 0 {
+		return &ValidationError{Field: "category_id", Message: "is required"}
+	}
+	if p.Name == "" || utf8.RuneCountInString(p.Name) > maximumNameLength {
+		return &ValidationError{Field: "name", Message: fmt.Sprintf("must be between 1 and %d characters", maximumNameLength)}
+	}
+	if utf8.RuneCountInString(p.Description) > maximumDescriptionLength {
+		return &ValidationError{Field: "description", Message: fmt.Sprintf("must be at most %d characters", maximumDescriptionLength)}
+	}
+	if p.ImageURL != "" && !validImageURL(p.ImageURL) {
+		return &ValidationError{Field: "image_url", Message: fmt.Sprintf("must be an absolute http or https URL of at most %d characters", maximumImageURLLength)}
+	}
+	if p.BasePrice < 0 || p.BasePrice > MaximumPrice {
+		return &ValidationError{Field: "base_price", Message: fmt.Sprintf("must be between 0 and %d", MaximumPrice)}
+	}
+	if !p.DietaryTags.valid() {
+		return &ValidationError{Field: "dietary_tags", Message: "must be known dietary tags"}
+	}
+
+	return p.QuantityLimit.validate()
+}
+
+func validImageURL(s string) bool {
+	if len(s) > maximumImageURLLength {
+		return false
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
+
+const (
+	// DefaultListLimit is the page size of ProductRepository.List if ListFilter.Limit is not set.
+	DefaultListLimit = 50
+	// MaximumListLimit is the largest page size of ProductRepository.List.
+	MaximumListLimit = 200
+)
+
+// ListFilter narrows down the products returned by ProductRepository.List. Zero values are not filtered on.
+type ListFilter struct {
+	CategoryID int64
+	// ActiveOnly leaves out the inactive products.
+	ActiveOnly bool
+	// Name is matched case-insensitively against any part of the product's name.
+	Name string
+	// VisibleAtStore leaves out the products hidden at the store.
+	VisibleAtStore int64
+	// Cursor is the NextCursor of the previous page, or zero for the first page.
+	Cursor int64
+	Limit  int
+}
+
+// OrderCatalog is what an order at a store needs of its products.
+type OrderCatalog struct {
+	// Products are by their ID, the ones left out do not exist.
+	Products map[int64]Product
+	// ModifierGroups are the groups attached to each product, by the product ID.
+	ModifierGroups map[int64][]ModifierGroup
+	// Availabilities are those of the store, the products left out of them are available.
+	Availabilities map[int64]StoreAvailability
+	Prices         StorePrices
+}
+
+type ListResult struct {
+	Products []Product
+	// NextCursor is zero if there is no next page.
+	NextCursor int64
+}
+
+type ProductRepository interface {
+	GetByID(ctx context.Context, id int64) (Product, error)
+	// List returns products ordered by their ID, paginated with the ID as the cursor.
+	List(ctx context.Context, filter ListFilter) (ListResult, error)
+	// Search returns the active products matching the filter, the most relevant first, paginated with the
+	// relevance and the ID as the cursor.
+	Search(ctx context.Context, filter SearchFilter) (SearchResult, error)
+	// Insert validates and inserts a new product, and returns it. It returns ErrCategoryNotFound if the
+	// category does not exist.
+	Insert(ctx context.Context, rawProduct RawProduct) (Product, error)
+	// Update validates and replaces every field of the product, and returns the updated product. It returns
+	// ErrCategoryNotFound if the category does not exist.
+	Update(ctx context.Context, id int64, rawProduct RawProduct) (Product, error)
+	// Delete soft-deletes the product, it can no longer be acquired nor listed.
+	Delete(ctx context.Context, id int64) error
+
+	GetCategory(ctx context.Context, id int64) (Category, error)
+	// ListCategories returns every category ordered by their position. There are few enough of them not
+	// to be paginated.
+	ListCategories(ctx context.Context) ([]Category, error)
+	// InsertCategory validates and inserts a new category, and returns it.
+	InsertCategory(ctx context.Context, rawCategory RawCategory) (Category, error)
+	// UpdateCategory validates and replaces every field of the category, and returns the updated category.
+	UpdateCategory(ctx context.Context, id int64, rawCategory RawCategory) (Category, error)
+	// DeleteCategory soft-deletes the category. It returns ErrCategoryInUse if it still has products.
+	DeleteCategory(ctx context.Context, id int64) error
+
+	GetModifierGroup(ctx context.Context, id int64) (ModifierGroup, error)
+	// ListModifierGroups returns every modifier group ordered by their name, along with their options.
+	ListModifierGroups(ctx context.Context) ([]ModifierGroup, error)
+	// InsertModifierGroup validates and inserts a new modifier group with its options, and returns it.
+	InsertModifierGroup(ctx context.Context, rawGroup RawModifierGroup) (ModifierGroup, error)
+	// UpdateModifierGroup validates and replaces the group and its options, and returns the updated group.
+	// The changes apply to every product the group is attached to.
+	UpdateModifierGroup(ctx context.Context, id int64, rawGroup RawModifierGroup) (ModifierGroup, error)
+	// DeleteModifierGroup soft-deletes the group. It returns ErrModifierGroupInUse if it's still attached
+	// to any product.
+	DeleteModifierGroup(ctx context.Context, id int64) error
+	// GetProductModifierGroups returns the groups attached to the product in their order, the product
+	// itself is not checked.
+	GetProductModifierGroups(ctx context.Context, productId int64) ([]ModifierGroup, error)
+	// SetProductModifierGroups replaces the groups attached to the product, in their order. It returns
+	// ErrModifierGroupNotFound if any of them does not exist.
+	SetProductModifierGroups(ctx context.Context, productId int64, groupIds []int64) error
+
+	// GetStoreAvailabilities returns the availabilities of the products at the store, by their product ID.
+	// The products the store has never set are left out, they're available.
+	GetStoreAvailabilities(ctx context.Context, storeId int64, productIds []int64) (map[int64]StoreAvailability, error)
+	// SetStoreAvailability validates and sets the availability of the product at the store, and returns it.
+	// The store itself is not checked.
+	SetStoreAvailability(ctx context.Context, storeId int64, productId int64, change AvailabilityChange) (StoreAvailability, error)
+
+	// GetStorePrices returns the prices the store overrides, which are none for most stores.
+	GetStorePrices(ctx context.Context, storeId int64) (StorePrices, error)
+	// SetStorePrices validates and replaces every price the store overrides, the ones left out go back to
+	// the usual prices. The store itself is not checked.
+	SetStorePrices(ctx context.Context, prices StorePrices) error
+
+	// GetOrderCatalog reads the products in tx, with their modifier groups and their availabilities and
+	// prices at the store, so that an order is priced in the transaction that inserts it.
+	GetOrderCatalog(ctx context.Context, tx *sql.Tx, storeId int64, productIds []int64) (OrderCatalog, error)
+}
@@ -0,0 +1,119 @@
+package product_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"coffee-chain-api/product"
+)
+
+func TestRawProduct_Validate(t *testing.T) {
+	valid := product.RawProduct{
+		CategoryID:  1,
+		Name:        "  Kopi Susu Gula Aren ",
+		Description: "Espresso, fresh milk, and palm sugar.",
+		ImageURL:    "https://cdn.example.com/products/kopi-susu.jpg",
+		BasePrice:   25_000 * product.MinorUnitsPerRupiah,
+		Active:      true,
+	}
+
+	testCases := []struct {
+		name   string
+		modify func(p *product.RawProduct)
+		field  string
+	}{
+		{name: "valid", modify: func(p *product.RawProduct) {}, field: ""},
+		{name: "missing category", modify: func(p *product.RawProduct) { p.CategoryID = 0 }, field: "category_id"},
+		{name: "empty name", modify: func(p *product.RawProduct) { p.Name = "  " }, field: "name"},
+		{name: "long name", modify: func(p *product.RawProduct) { p.Name = strings.Repeat("a", 256) }, field: "name"},
+		{name: "empty description", modify: func(p *product.RawProduct) { p.Description = "" }, field: ""},
+		{name: "long description", modify: func(p *product.RawProduct) { p.Description = strings.Repeat("a", 2048) }, field: "description"},
+		{name: "empty image", modify: func(p *product.RawProduct) { p.ImageURL = "" }, field: ""},
+		{name: "relative image", modify: func(p *product.RawProduct) { p.ImageURL = "/products/kopi-susu.jpg" }, field: "image_url"},
+		{name: "image on another scheme", modify: func(p *product.RawProduct) { p.ImageURL = "javascript:alert(1)" }, field: "image_url"},
+		{name: "free", modify: func(p *product.RawProduct) { p.BasePrice = 0 }, field: ""},
+		{name: "negative price", modify: func(p *product.RawProduct) { p.BasePrice = -1 }, field: "base_price"},
+		{name: "maximum price", modify: func(p *product.RawProduct) { p.BasePrice = product.MaximumPrice }, field: ""},
+		{name: "above maximum price", modify: func(p *product.RawProduct) { p.BasePrice = product.MaximumPrice + 1 }, field: "base_price"},
+		{name: "inactive", modify: func(p *product.RawProduct) { p.Active = false }, field: ""},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			rawProduct := valid
+			tt.modify(&rawProduct)
+
+			err := rawProduct.Normalize().Validate()
+			if tt.field == "" {
+				if err != nil {
+					t.Errorf("expecting no error, got %v instead", err)
+				}
+				return
+			}
+
+			var validationError *product.ValidationError
+			if !errors.As(err, &validationError) {
+				t.Fatalf("expecting a validation error, got %v instead", err)
+			}
+			if validationError.Field != tt.field {
+				t.Errorf("expecting invalid %s, got invalid %s instead", tt.field, validationError.Field)
+			}
+			if !errors.Is(err, product.ErrInvalidProduct) {
+				t.Errorf("expecting error to match ErrInvalidProduct")
+			}
+		})
+	}
+}
+
+func TestRawCategory_Validate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		category product.RawCategory
+		field    string
+	}{
+		{name: "valid", category: product.RawCategory{Name: " Coffee ", Position: 1}, field: ""},
+		{name: "empty name", category: product.RawCategory{Name: " "}, field: "name"},
+		{name: "negative position", category: product.RawCategory{Name: "Coffee", Position: -1}, field: "position"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.category.Normalize().Validate()
+			if tt.field == "" {
+				if err != nil {
+					t.Errorf("expecting no error, got %v instead", err)
+				}
+				return
+			}
+
+			var validationError *product.ValidationError
+			if !errors.As(err, &validationError) || validationError.Field != tt.field {
+				t.Errorf("expecting invalid %s, got %v instead", tt.field, err)
+			}
+		})
+	}
+}
+
+func TestPrice(t *testing.T) {
+	testCases := []struct {
+		price  product.Price
+		rupiah int64
+		expect string
+	}{
+		{price: 0, rupiah: 0, expect: "IDR 0.00"},
+		{price: 2_500_000, rupiah: 25_000, expect: "IDR 25000.00"},
+		{price: 150, rupiah: 1, expect: "IDR 1.50"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.expect, func(t *testing.T) {
+			if got := tt.price.String(); got != tt.expect {
+				t.Errorf("expecting %s, got %s instead", tt.expect, got)
+			}
+			if got := tt.price.Rupiah(); got != tt.rupiah {
+				t.Errorf("expecting %d rupiah, got %d instead", tt.rupiah, got)
+			}
+		})
+	}
+}
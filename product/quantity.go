@@ -0,0 +1,137 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrQuantityLimitExceeded is matched by every *QuantityLimitError.
+var ErrQuantityLimitExceeded = errors.New("product quantity limit exceeded")
+
+// QuantityLimit is how many of a product a customer can order. Zero values are not limited.
+type QuantityLimit struct {
+	// PerOrder is the most of the product in a single order, across every line of it.
+	PerOrder int
+	// PerWindow is the most of the product over the rolling Window, such as a limited item that can only be
+	// ordered twice a day. It's only set along with Window.
+	PerWindow int
+	Window    time.Duration
+}
+
+const (
+	maximumQuantityLimit = 1000
+	minimumLimitWindow   = time.Hour
+	maximumLimitWindow   = 30 * 24 * time.Hour
+)
+
+// validate returns a *ValidationError if the limit is invalid.
+func (l QuantityLimit) validate() error {
+	if l.PerOrder < 0 || l.PerOrder > maximumQuantityLimit {
+		return &ValidationError{Field: "max_quantity_per_order", Message: fmt.Sprintf("must be between 0 and %d", maximumQuantityLimit)}
+	}
+	if l.PerWindow < 0 || l.PerWindow > maximumQuantityLimit {
+		return &ValidationError{Field: "max_quantity_per_window", Message: fmt.Sprintf("must be between 0 and %d", maximumQuantityLimit)}
+	}
+	if (l.PerWindow == 0) != (l.Window == 0) {
+		return &ValidationError{Field: "quantity_window", Message: "must be set along with max_quantity_per_window"}
+	}
+	if l.Window != 0 && (l.Window < minimumLimitWindow || l.Window > maximumLimitWindow || l.Window%time.Hour != 0) {
+		return &ValidationError{Field: "quantity_window", Message: fmt.Sprintf("must be whole hours between %d and %d", int(minimumLimitWindow.Hours()), int(maximumLimitWindow.Hours()))}
+	}
+	if l.PerOrder > 0 && l.PerWindow > 0 && l.PerOrder > l.PerWindow {
+		return &ValidationError{Field: "max_quantity_per_order", Message: "must not be more than max_quantity_per_window"}
+	}
+
+	return nil
+}
+
+// PurchaseHistory is what the customers have ordered before, for the limits over a rolling window. It's
+// implemented by the orders.
+type PurchaseHistory interface {
+	// QuantityOrdered returns how many of the product the customer has ordered since the given time, not
+	// counting the cancelled orders.
+	QuantityOrdered(ctx context.Context, accountId int64, productId int64, since time.Time) (int, error)
+}
+
+// Line is a product and its quantity in an order.
+type Line struct {
+	Product Product
+	// Quantity is positive, it's checked by order creation.
+	Quantity int
+}
+
+// QuantityLimitError describes the line of the order that goes over the quantity limit of its product.
+type QuantityLimitError struct {
+	// Line is the index of the line in the order. Lines of the same product count together, it's the first
+	// line that goes over the limit.
+	Line      int
+	ProductID int64
+	// Limit is the limit that is exceeded, and Window is zero if it's the limit per order.
+	Limit  int
+	Window time.Duration
+	// Remaining is how many more of the product the customer can still order, across every line of it.
+	Remaining int
+}
+
+func (e *QuantityLimitError) Error() string {
+	if e.Window != 0 {
+		return fmt.Sprintf("line %d: at most %d of product %d can be ordered every %s, %d remaining", e.Line, e.Limit, e.ProductID, e.Window, e.Remaining)
+	}
+
+	return fmt.Sprintf("line %d: at most %d of product %d can be ordered at once", e.Line, e.Limit, e.ProductID)
+}
+
+func (e *QuantityLimitError) Is(target error) bool {
+	return target == ErrQuantityLimitExceeded
+}
+
+// CheckQuantityLimits returns a *QuantityLimitError if the lines of an order go over the quantity limit
+// of any of their products, for the customer at the given time. The history is only asked about the
+// products limited over a window.
+func CheckQuantityLimits(ctx context.Context, history PurchaseHistory, accountId int64, lines []Line, now time.Time) error {
+	quantities := make(map[int64]int, len(lines))
+	ordered := make(map[int64]int)
+
+	for i, line := range lines {
+		limit := line.Product.QuantityLimit
+		quantities[line.Product.ID] += line.Quantity
+		quantity := quantities[line.Product.ID]
+
+		if limit.PerOrder > 0 && quantity > limit.PerOrder {
+			return &QuantityLimitError{
+				Line:      i,
+				ProductID: line.Product.ID,
+				Limit:     limit.PerOrder,
+				Remaining: limit.PerOrder - (quantity - line.Quantity),
+			}
+		}
+
+		if limit.PerWindow == 0 {
+			continue
+		}
+
+		before, ok := ordered[line.Product.ID]
+		if !ok {
+			var err error
+			before, err = history.QuantityOrdered(ctx, accountId, line.Product.ID, now.Add(-limit.Window))
+			if err != nil {
+				return fmt.Errorf("acquiring quantity ordered of product %d: %w", line.Product.ID, err)
+			}
+			ordered[line.Product.ID] = before
+		}
+
+		if before+quantity > limit.PerWindow {
+			return &QuantityLimitError{
+				Line:      i,
+				ProductID: line.Product.ID,
+				Limit:     limit.PerWindow,
+				Window:    limit.Window,
+				Remaining: max(0, limit.PerWindow-before-(quantity-line.Quantity)),
+			}
+		}
+	}
+
+	return nil
+}
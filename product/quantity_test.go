@@ -0,0 +1,144 @@
+package product_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"coffee-chain-api/product"
+)
+
+// fakeHistory is the quantity ordered of each product, since any time.
+type fakeHistory map[int64]int
+
+func (f fakeHistory) QuantityOrdered(ctx context.Context, accountId int64, productId int64, since time.Time) (int, error) {
+	if productId < 0 {
+		return 0, errors.New("history is down")
+	}
+
+	return f[productId], nil
+}
+
+func TestCheckQuantityLimits(t *testing.T) {
+	unlimited := product.Product{ID: 1}
+	perOrder := product.Product{ID: 2, QuantityLimit: product.QuantityLimit{PerOrder: 3}}
+	perDay := product.Product{ID: 3, QuantityLimit: product.QuantityLimit{PerOrder: 2, PerWindow: 2, Window: 24 * time.Hour}}
+
+	testCases := []struct {
+		name    string
+		history fakeHistory
+		lines   []product.Line
+		// expect is nil if the lines are within the limits.
+		expect *product.QuantityLimitError
+	}{
+		{
+			name:  "unlimited",
+			lines: []product.Line{{Product: unlimited, Quantity: 100}},
+		},
+		{
+			name:  "at the limit per order",
+			lines: []product.Line{{Product: perOrder, Quantity: 3}},
+		},
+		{
+			name:   "above the limit per order",
+			lines:  []product.Line{{Product: unlimited, Quantity: 1}, {Product: perOrder, Quantity: 4}},
+			expect: &product.QuantityLimitError{Line: 1, ProductID: 2, Limit: 3, Remaining: 3},
+		},
+		{
+			name: "lines of the same product count together",
+			lines: []product.Line{
+				{Product: perOrder, Quantity: 2},
+				{Product: unlimited, Quantity: 1},
+				{Product: perOrder, Quantity: 2},
+			},
+			expect: &product.QuantityLimitError{Line: 2, ProductID: 2, Limit: 3, Remaining: 1},
+		},
+		{
+			name:    "within the window",
+			history: fakeHistory{3: 1},
+			lines:   []product.Line{{Product: perDay, Quantity: 1}},
+		},
+		{
+			name:    "above the window",
+			history: fakeHistory{3: 1},
+			lines:   []product.Line{{Product: perDay, Quantity: 1}, {Product: perDay, Quantity: 1}},
+			expect:  &product.QuantityLimitError{Line: 1, ProductID: 3, Limit: 2, Window: 24 * time.Hour, Remaining: 0},
+		},
+		{
+			name:    "already ordered more than the window",
+			history: fakeHistory{3: 5},
+			lines:   []product.Line{{Product: perDay, Quantity: 1}},
+			expect:  &product.QuantityLimitError{Line: 0, ProductID: 3, Limit: 2, Window: 24 * time.Hour, Remaining: 0},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := product.CheckQuantityLimits(context.Background(), tt.history, 42, tt.lines, time.Now())
+			if tt.expect == nil {
+				if err != nil {
+					t.Errorf("expecting no error, got %v instead", err)
+				}
+				return
+			}
+
+			var limitError *product.QuantityLimitError
+			if !errors.As(err, &limitError) {
+				t.Fatalf("expecting a quantity limit error, got %v instead", err)
+			}
+			if *limitError != *tt.expect {
+				t.Errorf("expecting %+v, got %+v instead", *tt.expect, *limitError)
+			}
+			if !errors.Is(err, product.ErrQuantityLimitExceeded) {
+				t.Errorf("expecting error to match ErrQuantityLimitExceeded")
+			}
+		})
+	}
+}
+
+func TestCheckQuantityLimits_HistoryError(t *testing.T) {
+	broken := product.Product{ID: -1, QuantityLimit: product.QuantityLimit{PerWindow: 1, Window: time.Hour}}
+
+	err := product.CheckQuantityLimits(context.Background(), fakeHistory{}, 42, []product.Line{{Product: broken, Quantity: 1}}, time.Now())
+	if err == nil || errors.Is(err, product.ErrQuantityLimitExceeded) {
+		t.Errorf("expecting the history error, got %v instead", err)
+	}
+}
+
+func TestRawProduct_ValidateQuantityLimit(t *testing.T) {
+	testCases := []struct {
+		name  string
+		limit product.QuantityLimit
+		field string
+	}{
+		{name: "unlimited", limit: product.QuantityLimit{}, field: ""},
+		{name: "per order", limit: product.QuantityLimit{PerOrder: 5}, field: ""},
+		{name: "per day", limit: product.QuantityLimit{PerOrder: 1, PerWindow: 2, Window: 24 * time.Hour}, field: ""},
+		{name: "negative per order", limit: product.QuantityLimit{PerOrder: -1}, field: "max_quantity_per_order"},
+		{name: "window without limit", limit: product.QuantityLimit{Window: 24 * time.Hour}, field: "quantity_window"},
+		{name: "limit without window", limit: product.QuantityLimit{PerWindow: 2}, field: "quantity_window"},
+		{name: "window too short", limit: product.QuantityLimit{PerWindow: 2, Window: time.Minute}, field: "quantity_window"},
+		{name: "window too long", limit: product.QuantityLimit{PerWindow: 2, Window: 31 * 24 * time.Hour}, field: "quantity_window"},
+		{name: "per order above per window", limit: product.QuantityLimit{PerOrder: 3, PerWindow: 2, Window: time.Hour}, field: "max_quantity_per_order"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			rawProduct := product.RawProduct{CategoryID: 1, Name: "Croissant", BasePrice: 20_000_00, QuantityLimit: tt.limit}
+
+			err := rawProduct.Normalize().Validate()
+			if tt.field == "" {
+				if err != nil {
+					t.Errorf("expecting no error, got %v instead", err)
+				}
+				return
+			}
+
+			var validationError *product.ValidationError
+			if !errors.As(err, &validationError) || validationError.Field != tt.field {
+				t.Errorf("expecting invalid %s, got %v instead", tt.field, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,811 @@
+package product
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"coffee-chain-api/account"
+
+	"github.com/rs/zerolog/log"
+)
+
+type productsTable struct {
+	ID          int64
+	CategoryID  int64
+	Name        string
+	Description string
+	ImageURL    string
+	BasePrice   int64
+	Active      bool
+	CreatedAt   time.Time
+	CreatedBy   string
+	UpdatedAt   time.Time
+	UpdatedBy   string
+
+	MaxQuantityPerOrder   int
+	MaxQuantityPerWindow  int
+	QuantityWindowSeconds int64
+
+	DietaryTags int64
+}
+
+// productsColumns is the column list for selecting productsTable, in the same order as
+// productsTable.scanDestinations.
+const productsColumns = `id,
+				category_id,
+				name,
+				description,
+				image_url,
+				base_price,
+				active,
+				created_at,
+				created_by,
+				updated_at,
+				updated_by,
+				max_quantity_per_order,
+				max_quantity_per_window,
+				quantity_window_seconds,
+				dietary_tags`
+
+func (p *productsTable) scanDestinations() []any {
+	return []any{
+		&p.ID,
+		&p.CategoryID,
+		&p.Name,
+		&p.Description,
+		&p.ImageURL,
+		&p.BasePrice,
+		&p.Active,
+		&p.CreatedAt,
+		&p.CreatedBy,
+		&p.UpdatedAt,
+		&p.UpdatedBy,
+		&p.MaxQuantityPerOrder,
+		&p.MaxQuantityPerWindow,
+		&p.QuantityWindowSeconds,
+		&p.DietaryTags,
+	}
+}
+
+func (p *productsTable) product() Product {
+	return Product{
+		ID:          p.ID,
+		CategoryID:  p.CategoryID,
+		Name:        p.Name,
+		Description: p.Description,
+		ImageURL:    p.ImageURL,
+		BasePrice:   Price(p.BasePrice),
+		Active:      p.Active,
+		QuantityLimit: QuantityLimit{
+			PerOrder:  p.MaxQuantityPerOrder,
+			PerWindow: p.MaxQuantityPerWindow,
+			Window:    time.Duration(p.QuantityWindowSeconds) * time.Second,
+		},
+		DietaryTags: DietaryTags(p.DietaryTags),
+		CreatedAt:   p.CreatedAt,
+		CreatedBy:   p.CreatedBy,
+		UpdatedAt:   p.UpdatedAt,
+		UpdatedBy:   p.UpdatedBy,
+	}
+}
+
+type productCategoriesTable struct {
+	ID        int64
+	Name      string
+	Position  int
+	CreatedAt time.Time
+	CreatedBy string
+	UpdatedAt time.Time
+	UpdatedBy string
+}
+
+// categoriesColumns is the column list for selecting productCategoriesTable, in the same order as
+// productCategoriesTable.scanDestinations.
+const categoriesColumns = `id,
+				name,
+				position,
+				created_at,
+				created_by,
+				updated_at,
+				updated_by`
+
+func (c *productCategoriesTable) scanDestinations() []any {
+	return []any{
+		&c.ID,
+		&c.Name,
+		&c.Position,
+		&c.CreatedAt,
+		&c.CreatedBy,
+		&c.UpdatedAt,
+		&c.UpdatedBy,
+	}
+}
+
+func (c *productCategoriesTable) category() Category {
+	return Category{
+		ID:        c.ID,
+		Name:      c.Name,
+		Position:  c.Position,
+		CreatedAt: c.CreatedAt,
+		CreatedBy: c.CreatedBy,
+		UpdatedAt: c.UpdatedAt,
+		UpdatedBy: c.UpdatedBy,
+	}
+}
+
+// likeEscaper escapes the wildcard characters of a LIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+type repository struct {
+	db *sql.DB
+}
+
+func (r *repository) GetByID(ctx context.Context, id int64) (Product, error) {
+	if id <= 0 {
+		return Product{}, ErrNotFound
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return Product{}, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	return getByID(ctx, conn, id)
+}
+
+func getByID(ctx context.Context, q queryer, id int64) (Product, error) {
+	var product productsTable
+	err := q.QueryRowContext(
+		ctx,
+		`SELECT
+				`+productsColumns+`
+			FROM
+				products
+			WHERE
+				id = $1
+				AND deleted_at IS NULL
+			LIMIT 1`,
+		id,
+	).Scan(product.scanDestinations()...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Product{}, ErrNotFound
+		}
+
+		return Product{}, fmt.Errorf("getting product by id: %w", err)
+	}
+
+	return product.product(), nil
+}
+
+func (r *repository) GetOrderCatalog(ctx context.Context, tx *sql.Tx, storeId int64, productIds []int64) (OrderCatalog, error) {
+	catalog := OrderCatalog{
+		Products:       make(map[int64]Product),
+		ModifierGroups: make(map[int64][]ModifierGroup),
+	}
+
+	for _, productId := range productIds {
+		if _, ok := catalog.Products[productId]; ok || productId <= 0 {
+			continue
+		}
+
+		product, err := getByID(ctx, tx, productId)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+
+			return OrderCatalog{}, fmt.Errorf("acquiring product %d: %w", productId, err)
+		}
+
+		groups, err := getProductModifierGroups(ctx, tx, productId)
+		if err != nil {
+			return OrderCatalog{}, fmt.Errorf("acquiring modifier groups of product %d: %w", productId, err)
+		}
+
+		catalog.Products[productId] = product
+		catalog.ModifierGroups[productId] = groups
+	}
+
+	foundIds := make([]int64, 0, len(catalog.Products))
+	for productId := range catalog.Products {
+		foundIds = append(foundIds, productId)
+	}
+
+	var err error
+	catalog.Availabilities, err = getStoreAvailabilities(ctx, tx, storeId, foundIds)
+	if err != nil {
+		return OrderCatalog{}, fmt.Errorf("acquiring store availabilities: %w", err)
+	}
+
+	catalog.Prices, err = getStorePrices(ctx, tx, storeId)
+	if err != nil {
+		return OrderCatalog{}, fmt.Errorf("acquiring store prices: %w", err)
+	}
+
+	return catalog, nil
+}
+
+func (r *repository) List(ctx context.Context, filter ListFilter) (ListResult, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	if limit > MaximumListLimit {
+		limit = MaximumListLimit
+	}
+
+	conditions := []string{"deleted_at IS NULL", "id > $1"}
+	args := []any{filter.Cursor}
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", "$"+strconv.Itoa(len(args))))
+	}
+
+	if filter.CategoryID > 0 {
+		addCondition("category_id = ?", filter.CategoryID)
+	}
+	if filter.ActiveOnly {
+		addCondition("active = ?", true)
+	}
+	if name := strings.TrimSpace(filter.Name); name != "" {
+		addCondition(`name ILIKE ? ESCAPE '\'`, "%"+likeEscaper.Replace(name)+"%")
+	}
+	if filter.VisibleAtStore > 0 {
+		addCondition(`NOT EXISTS (
+					SELECT 1 FROM product_availability
+					WHERE product_availability.product_id = products.id
+						AND product_availability.store_id = ?
+						AND product_availability.availability = `+strconv.Itoa(int(AvailabilityHidden))+`
+				)`, filter.VisibleAtStore)
+	}
+
+	// One more row than requested, to know whether there is a next page.
+	args = append(args, limit+1)
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return ListResult{}, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	rows, err := conn.QueryContext(
+		ctx,
+		`SELECT
+				`+productsColumns+`
+			FROM
+				products
+			WHERE
+				`+strings.Join(conditions, " AND ")+`
+			ORDER BY
+				id ASC
+			LIMIT $`+strconv.Itoa(len(args)),
+		args...,
+	)
+	if err != nil {
+		return ListResult{}, fmt.Errorf("executing select query: %w", err)
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing rows")
+		}
+	}()
+
+	var result ListResult
+	for rows.Next() {
+		var product productsTable
+		err = rows.Scan(product.scanDestinations()...)
+		if err != nil {
+			return ListResult{}, fmt.Errorf("scanning row: %w", err)
+		}
+
+		result.Products = append(result.Products, product.product())
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return ListResult{}, fmt.Errorf("iterating rows: %w", err)
+	}
+
+	if len(result.Products) > limit {
+		result.Products = result.Products[:limit]
+		result.NextCursor = result.Products[limit-1].ID
+	}
+
+	return result, nil
+}
+
+func (r *repository) Insert(ctx context.Context, rawProduct RawProduct) (Product, error) {
+	rawProduct = rawProduct.Normalize()
+	err := rawProduct.Validate()
+	if err != nil {
+		return Product{}, err
+	}
+
+	var product productsTable
+	err = r.withCategory(ctx, rawProduct.CategoryID, "FOR SHARE", func(tx *sql.Tx) error {
+		now := time.Now()
+		err := tx.QueryRowContext(
+			ctx,
+			`INSERT INTO
+				products
+				(category_id,
+				 name,
+				 description,
+				 image_url,
+				 base_price,
+				 active,
+				 created_at,
+				 created_by,
+				 updated_at,
+				 updated_by,
+				 max_quantity_per_order,
+				 max_quantity_per_window,
+				 quantity_window_seconds,
+				 dietary_tags
+				 )
+			VALUES
+				($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+			RETURNING
+				`+productsColumns,
+			rawProduct.CategoryID,
+			rawProduct.Name,
+			rawProduct.Description,
+			rawProduct.ImageURL,
+			rawProduct.BasePrice,
+			rawProduct.Active,
+			now,
+			account.ActorIdentifier(ctx),
+			now,
+			account.ActorIdentifier(ctx),
+			rawProduct.QuantityLimit.PerOrder,
+			rawProduct.QuantityLimit.PerWindow,
+			int64(rawProduct.QuantityLimit.Window/time.Second),
+			int64(rawProduct.DietaryTags),
+		).Scan(product.scanDestinations()...)
+		if err != nil {
+			return fmt.Errorf("executing insert query: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return Product{}, err
+	}
+
+	return product.product(), nil
+}
+
+func (r *repository) Update(ctx context.Context, id int64, rawProduct RawProduct) (Product, error) {
+	if id <= 0 {
+		return Product{}, ErrNotFound
+	}
+
+	rawProduct = rawProduct.Normalize()
+	err := rawProduct.Validate()
+	if err != nil {
+		return Product{}, err
+	}
+
+	var product productsTable
+	err = r.withCategory(ctx, rawProduct.CategoryID, "FOR SHARE", func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(
+			ctx,
+			`UPDATE
+				products
+			SET
+				category_id = $1,
+				name = $2,
+				description = $3,
+				image_url = $4,
+				base_price = $5,
+				active = $6,
+				updated_at = $7,
+				updated_by = $8,
+				max_quantity_per_order = $9,
+				max_quantity_per_window = $10,
+				quantity_window_seconds = $11,
+				dietary_tags = $12
+			WHERE
+				id = $13
+				AND deleted_at IS NULL
+			RETURNING
+				`+productsColumns,
+			rawProduct.CategoryID,
+			rawProduct.Name,
+			rawProduct.Description,
+			rawProduct.ImageURL,
+			rawProduct.BasePrice,
+			rawProduct.Active,
+			time.Now(),
+			account.ActorIdentifier(ctx),
+			rawProduct.QuantityLimit.PerOrder,
+			rawProduct.QuantityLimit.PerWindow,
+			int64(rawProduct.QuantityLimit.Window/time.Second),
+			int64(rawProduct.DietaryTags),
+			id,
+		).Scan(product.scanDestinations()...)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrNotFound
+			}
+
+			return fmt.Errorf("executing update query: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return Product{}, err
+	}
+
+	return product.product(), nil
+}
+
+func (r *repository) Delete(ctx context.Context, id int64) error {
+	if id <= 0 {
+		return ErrNotFound
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	result, err := conn.ExecContext(
+		ctx,
+		`UPDATE
+			products
+		SET
+			deleted_at = $1,
+			updated_at = $1,
+			updated_by = $2
+		WHERE
+			id = $3
+			AND deleted_at IS NULL`,
+		time.Now(),
+		account.ActorIdentifier(ctx),
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("executing update query: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("acquiring affected rows: %w", err)
+	}
+
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *repository) GetCategory(ctx context.Context, id int64) (Category, error) {
+	if id <= 0 {
+		return Category{}, ErrCategoryNotFound
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return Category{}, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	var category productCategoriesTable
+	err = conn.QueryRowContext(
+		ctx,
+		`SELECT
+				`+categoriesColumns+`
+			FROM
+				product_categories
+			WHERE
+				id = $1
+				AND deleted_at IS NULL
+			LIMIT 1`,
+		id,
+	).Scan(category.scanDestinations()...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Category{}, ErrCategoryNotFound
+		}
+
+		return Category{}, fmt.Errorf("getting category by id: %w", err)
+	}
+
+	return category.category(), nil
+}
+
+func (r *repository) ListCategories(ctx context.Context) ([]Category, error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	rows, err := conn.QueryContext(
+		ctx,
+		`SELECT
+				`+categoriesColumns+`
+			FROM
+				product_categories
+			WHERE
+				deleted_at IS NULL
+			ORDER BY
+				position ASC,
+				id ASC`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("executing select query: %w", err)
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing rows")
+		}
+	}()
+
+	var categories []Category
+	for rows.Next() {
+		var category productCategoriesTable
+		err = rows.Scan(category.scanDestinations()...)
+		if err != nil {
+			return nil, fmt.Errorf("scanning row: %w", err)
+		}
+
+		categories = append(categories, category.category())
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("iterating rows: %w", err)
+	}
+
+	return categories, nil
+}
+
+func (r *repository) InsertCategory(ctx context.Context, rawCategory RawCategory) (Category, error) {
+	rawCategory = rawCategory.Normalize()
+	err := rawCategory.Validate()
+	if err != nil {
+		return Category{}, err
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return Category{}, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	now := time.Now()
+	var category productCategoriesTable
+	err = conn.QueryRowContext(
+		ctx,
+		`INSERT INTO
+			product_categories
+			(name,
+			 position,
+			 created_at,
+			 created_by,
+			 updated_at,
+			 updated_by
+			 )
+		VALUES
+			($1, $2, $3, $4, $5, $6)
+		RETURNING
+			`+categoriesColumns,
+		rawCategory.Name,
+		rawCategory.Position,
+		now,
+		account.ActorIdentifier(ctx),
+		now,
+		account.ActorIdentifier(ctx),
+	).Scan(category.scanDestinations()...)
+	if err != nil {
+		return Category{}, fmt.Errorf("executing insert query: %w", err)
+	}
+
+	return category.category(), nil
+}
+
+func (r *repository) UpdateCategory(ctx context.Context, id int64, rawCategory RawCategory) (Category, error) {
+	if id <= 0 {
+		return Category{}, ErrCategoryNotFound
+	}
+
+	rawCategory = rawCategory.Normalize()
+	err := rawCategory.Validate()
+	if err != nil {
+		return Category{}, err
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return Category{}, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	var category productCategoriesTable
+	err = conn.QueryRowContext(
+		ctx,
+		`UPDATE
+			product_categories
+		SET
+			name = $1,
+			position = $2,
+			updated_at = $3,
+			updated_by = $4
+		WHERE
+			id = $5
+			AND deleted_at IS NULL
+		RETURNING
+			`+categoriesColumns,
+		rawCategory.Name,
+		rawCategory.Position,
+		time.Now(),
+		account.ActorIdentifier(ctx),
+		id,
+	).Scan(category.scanDestinations()...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Category{}, ErrCategoryNotFound
+		}
+
+		return Category{}, fmt.Errorf("executing update query: %w", err)
+	}
+
+	return category.category(), nil
+}
+
+func (r *repository) DeleteCategory(ctx context.Context, id int64) error {
+	return r.withCategory(ctx, id, "FOR UPDATE", func(tx *sql.Tx) error {
+		var inUse bool
+		err := tx.QueryRowContext(
+			ctx,
+			`SELECT EXISTS (SELECT 1 FROM products WHERE category_id = $1 AND deleted_at IS NULL)`,
+			id,
+		).Scan(&inUse)
+		if err != nil {
+			return fmt.Errorf("checking products of category: %w", err)
+		}
+
+		if inUse {
+			return ErrCategoryInUse
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			`UPDATE
+				product_categories
+			SET
+				deleted_at = $1,
+				updated_at = $1,
+				updated_by = $2
+			WHERE
+				id = $3`,
+			time.Now(),
+			account.ActorIdentifier(ctx),
+			id,
+		)
+		if err != nil {
+			return fmt.Errorf("executing update query: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// withCategory calls fn in a transaction, with the category row locked by lock, either "FOR SHARE" to keep
+// it from being deleted or "FOR UPDATE" to change it. It returns ErrCategoryNotFound if there is no such
+// category.
+func (r *repository) withCategory(ctx context.Context, categoryId int64, lock string, fn func(tx *sql.Tx) error) error {
+	if categoryId <= 0 {
+		return ErrCategoryNotFound
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		return fmt.Errorf("creating transaction: %w", err)
+	}
+
+	var id int64
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT id FROM product_categories WHERE id = $1 AND deleted_at IS NULL `+lock,
+		categoryId,
+	).Scan(&id)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCategoryNotFound
+		}
+
+		return fmt.Errorf("getting category by id: %w", err)
+	}
+
+	err = fn(tx)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+func NewRepository(db *sql.DB) (ProductRepository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db is nil")
+	}
+
+	return &repository{db: db}, nil
+}
@@ -0,0 +1,109 @@
+package product
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaximumSearchQueryLength is the longest search text, in characters.
+const MaximumSearchQueryLength = 100
+
+// SearchCursor is the position after the last product of a page of ProductRepository.Search. Products are
+// ordered by their relevance, and those of the same relevance by their ID.
+type SearchCursor struct {
+	// Rank is a Postgres real, it's kept as a float32 so that it compares equal to itself in the database.
+	Rank float32
+	ID   int64
+}
+
+// IsZero reports whether the cursor is the start of the first page.
+func (c SearchCursor) IsZero() bool {
+	return c.ID == 0
+}
+
+// String encodes the cursor for the clients, to be parsed by ParseSearchCursor.
+func (c SearchCursor) String() string {
+	return strconv.FormatFloat(float64(c.Rank), 'g', -1, 32) + "_" + strconv.FormatInt(c.ID, 10)
+}
+
+// ParseSearchCursor parses the cursor encoded by SearchCursor.String.
+func ParseSearchCursor(s string) (SearchCursor, bool) {
+	rank, id, ok := strings.Cut(s, "_")
+	if !ok {
+		return SearchCursor{}, false
+	}
+
+	parsedRank, err := strconv.ParseFloat(rank, 32)
+	if err != nil || parsedRank < 0 || math.IsInf(parsedRank, 0) || math.IsNaN(parsedRank) {
+		return SearchCursor{}, false
+	}
+
+	cursor := SearchCursor{Rank: float32(parsedRank)}
+	cursor.ID, err = strconv.ParseInt(id, 10, 64)
+	if err != nil || cursor.ID <= 0 {
+		return SearchCursor{}, false
+	}
+
+	return cursor, true
+}
+
+// SearchFilter narrows down the products returned by ProductRepository.Search. Only active products are
+// searched, and zero values are not filtered on.
+type SearchFilter struct {
+	// Query is matched against the name and the description of the products, stemmed both as English and
+	// as Indonesian. Without it, every product matches with the same relevance.
+	Query      string
+	CategoryID int64
+	// MinPrice and MaxPrice bound the base price of the products, at StoreID if it's given.
+	MinPrice Price
+	MaxPrice Price
+	// StoreID leaves out the products hidden at the store, and prices the products at the store.
+	StoreID int64
+	// AvailableOnly also leaves out the products sold out at StoreID. It requires StoreID.
+	AvailableOnly bool
+	// DietaryTags leaves out the products that lack any of the tags.
+	DietaryTags DietaryTags
+	// Cursor is the NextCursor of the previous page, or zero for the first page.
+	Cursor SearchCursor
+	Limit  int
+}
+
+// Normalize trims the query.
+func (f SearchFilter) Normalize() SearchFilter {
+	f.Query = strings.TrimSpace(f.Query)
+
+	return f
+}
+
+// Validate checks a normalized SearchFilter, and returns a *ValidationError for the first invalid field.
+func (f SearchFilter) Validate() error {
+	if utf8.RuneCountInString(f.Query) > MaximumSearchQueryLength {
+		return &ValidationError{Field: "q", Message: fmt.Sprintf("must be at most %d characters", MaximumSearchQueryLength)}
+	}
+	if f.MinPrice < 0 || f.MinPrice > MaximumPrice {
+		return &ValidationError{Field: "min_price", Message: fmt.Sprintf("must be between 0 and %d", MaximumPrice)}
+	}
+	if f.MaxPrice < 0 || f.MaxPrice > MaximumPrice {
+		return &ValidationError{Field: "max_price", Message: fmt.Sprintf("must be between 0 and %d", MaximumPrice)}
+	}
+	if f.MaxPrice > 0 && f.MinPrice > f.MaxPrice {
+		return &ValidationError{Field: "min_price", Message: "must not be more than max_price"}
+	}
+	if f.AvailableOnly && f.StoreID <= 0 {
+		return &ValidationError{Field: "store_id", Message: "is required to filter by availability"}
+	}
+	if !f.DietaryTags.valid() {
+		return &ValidationError{Field: "dietary_tags", Message: "must be known dietary tags"}
+	}
+
+	return nil
+}
+
+type SearchResult struct {
+	Products []Product
+	// NextCursor is zero if there is no next page.
+	NextCursor SearchCursor
+}
@@ -0,0 +1,160 @@
+package product
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+func (r *repository) Search(ctx context.Context, filter SearchFilter) (SearchResult, error) {
+	filter = filter.Normalize()
+	err := filter.Validate()
+	if err != nil {
+		return SearchResult{}, err
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	if limit > MaximumListLimit {
+		limit = MaximumListLimit
+	}
+
+	var args []any
+	placeholder := func(arg any) string {
+		args = append(args, arg)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	// The conditions on the products go into the subquery, and the ones on its rank and store price go
+	// outside of it.
+	conditions := []string{"deleted_at IS NULL", "active = TRUE"}
+	var matchConditions []string
+
+	rank := "0::real"
+	if filter.Query != "" {
+		query := placeholder(filter.Query)
+		tsquery := "(websearch_to_tsquery('english', " + query + ") || websearch_to_tsquery('indonesian', " + query + "))"
+		rank = "ts_rank(search_vector, " + tsquery + ")"
+		conditions = append(conditions, "search_vector @@ "+tsquery)
+	}
+
+	storePrice := "base_price"
+	if filter.StoreID > 0 {
+		storeId := placeholder(filter.StoreID)
+		storePrice = `COALESCE((
+					SELECT price FROM store_product_prices
+					WHERE store_product_prices.product_id = products.id
+						AND store_product_prices.store_id = ` + storeId + `
+				), base_price)`
+
+		unavailable := "product_availability.availability = " + strconv.Itoa(int(AvailabilityHidden))
+		if filter.AvailableOnly {
+			unavailable = `(` + unavailable + `
+						OR (product_availability.availability = ` + strconv.Itoa(int(AvailabilitySoldOut)) + `
+							AND (product_availability.sold_out_until IS NULL OR product_availability.sold_out_until > NOW())))`
+		}
+		conditions = append(conditions, `NOT EXISTS (
+					SELECT 1 FROM product_availability
+					WHERE product_availability.product_id = products.id
+						AND product_availability.store_id = `+storeId+`
+						AND `+unavailable+`
+				)`)
+	}
+
+	if filter.CategoryID > 0 {
+		conditions = append(conditions, "category_id = "+placeholder(filter.CategoryID))
+	}
+	if filter.DietaryTags != 0 {
+		tags := placeholder(int64(filter.DietaryTags))
+		conditions = append(conditions, "dietary_tags & "+tags+" = "+tags)
+	}
+	if filter.MinPrice > 0 {
+		matchConditions = append(matchConditions, "store_price >= "+placeholder(filter.MinPrice))
+	}
+	if filter.MaxPrice > 0 {
+		matchConditions = append(matchConditions, "store_price <= "+placeholder(filter.MaxPrice))
+	}
+	if !filter.Cursor.IsZero() {
+		cursorRank := placeholder(filter.Cursor.Rank)
+		cursorId := placeholder(filter.Cursor.ID)
+		matchConditions = append(matchConditions, "(search_rank < "+cursorRank+"::real OR (search_rank = "+cursorRank+"::real AND id > "+cursorId+"))")
+	}
+	if len(matchConditions) == 0 {
+		matchConditions = append(matchConditions, "TRUE")
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return SearchResult{}, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	// One more row than requested, to know whether there is a next page.
+	rows, err := conn.QueryContext(
+		ctx,
+		`SELECT
+				`+productsColumns+`,
+				search_rank
+			FROM
+				(SELECT
+					products.*,
+					`+rank+` AS search_rank,
+					`+storePrice+` AS store_price
+				FROM
+					products
+				WHERE
+					`+strings.Join(conditions, " AND ")+`
+				) AS matches
+			WHERE
+				`+strings.Join(matchConditions, " AND ")+`
+			ORDER BY
+				search_rank DESC, id ASC
+			LIMIT `+placeholder(limit+1),
+		args...,
+	)
+	if err != nil {
+		return SearchResult{}, fmt.Errorf("executing select query: %w", err)
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing rows")
+		}
+	}()
+
+	var result SearchResult
+	var ranks []float32
+	for rows.Next() {
+		var product productsTable
+		var rank float32
+		err = rows.Scan(append(product.scanDestinations(), &rank)...)
+		if err != nil {
+			return SearchResult{}, fmt.Errorf("scanning row: %w", err)
+		}
+
+		result.Products = append(result.Products, product.product())
+		ranks = append(ranks, rank)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return SearchResult{}, fmt.Errorf("iterating rows: %w", err)
+	}
+
+	if len(result.Products) > limit {
+		result.Products = result.Products[:limit]
+		result.NextCursor = SearchCursor{Rank: ranks[limit-1], ID: result.Products[limit-1].ID}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,100 @@
+package product_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"coffee-chain-api/product"
+)
+
+func TestParseSearchCursor(t *testing.T) {
+	testCases := []struct {
+		input  string
+		expect product.SearchCursor
+		ok     bool
+	}{
+		{input: "0.0607927_42", expect: product.SearchCursor{Rank: 0.0607927, ID: 42}, ok: true},
+		{input: "0_1", expect: product.SearchCursor{Rank: 0, ID: 1}, ok: true},
+		{input: "0.06", ok: false},
+		{input: "-1_42", ok: false},
+		{input: "NaN_42", ok: false},
+		{input: "Inf_42", ok: false},
+		{input: "1e39_42", ok: false},
+		{input: "0.5_0", ok: false},
+		{input: "", ok: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok := product.ParseSearchCursor(tt.input)
+			if got != tt.expect || ok != tt.ok {
+				t.Errorf("expecting %+v (%t), got %+v (%t) instead", tt.expect, tt.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestSearchCursorRoundTrip(t *testing.T) {
+	// The rank must come back exactly, or the next page would skip or repeat products of the same rank.
+	cursor := product.SearchCursor{Rank: 1.0 / 3, ID: 7}
+
+	got, ok := product.ParseSearchCursor(cursor.String())
+	if !ok || got != cursor {
+		t.Errorf("expecting %+v, got %+v (%t) instead", cursor, got, ok)
+	}
+}
+
+func TestSearchFilter_Validate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		filter product.SearchFilter
+		field  string
+	}{
+		{name: "empty", filter: product.SearchFilter{}, field: ""},
+		{name: "everything", filter: product.SearchFilter{Query: "es kopi susu", CategoryID: 1, MinPrice: 10_000_00, MaxPrice: 30_000_00, StoreID: 3, AvailableOnly: true, DietaryTags: product.NewDietaryTags(product.DietaryTagVegan)}, field: ""},
+		{name: "query too long", filter: product.SearchFilter{Query: strings.Repeat("kopi ", 21)}, field: "q"},
+		{name: "negative min price", filter: product.SearchFilter{MinPrice: -1}, field: "min_price"},
+		{name: "max price too high", filter: product.SearchFilter{MaxPrice: product.MaximumPrice + 1}, field: "max_price"},
+		{name: "min price above max price", filter: product.SearchFilter{MinPrice: 30_000_00, MaxPrice: 10_000_00}, field: "min_price"},
+		{name: "available without a store", filter: product.SearchFilter{AvailableOnly: true}, field: "store_id"},
+		{name: "unknown dietary tag", filter: product.SearchFilter{DietaryTags: 1 << 40}, field: "dietary_tags"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Normalize().Validate()
+			if tt.field == "" {
+				if err != nil {
+					t.Errorf("expecting no error, got %v instead", err)
+				}
+				return
+			}
+
+			var validationError *product.ValidationError
+			if !errors.As(err, &validationError) || validationError.Field != tt.field {
+				t.Errorf("expecting invalid %s, got %v instead", tt.field, err)
+			}
+		})
+	}
+}
+
+func TestParseDietaryTags(t *testing.T) {
+	tags, ok := product.ParseDietaryTags([]string{"vegan", "gluten_free"})
+	if !ok {
+		t.Fatalf("expecting known tags to parse")
+	}
+	if tags != product.NewDietaryTags(product.DietaryTagVegan, product.DietaryTagGlutenFree) {
+		t.Errorf("expecting vegan and gluten free, got %v instead", tags.Names())
+	}
+	if !tags.Has(product.NewDietaryTags(product.DietaryTagVegan)) || tags.Has(product.NewDietaryTags(product.DietaryTagHalal)) {
+		t.Errorf("expecting the set to have exactly its tags")
+	}
+	if names := tags.Names(); len(names) != 2 || names[0] != "vegan" || names[1] != "gluten_free" {
+		t.Errorf("expecting the names in their order, got %v instead", names)
+	}
+
+	if _, ok := product.ParseDietaryTags([]string{"vegan", "keto"}); ok {
+		t.Errorf("expecting an unknown tag not to parse")
+	}
+}
@@ -0,0 +1,76 @@
+package product
+
+import (
+	"fmt"
+)
+
+// maximumStorePrices is the most base prices, and separately price deltas, a store can override.
+const maximumStorePrices = 5000
+
+// StorePrices are the prices a store charges instead of the usual ones, such as the airport and mall
+// stores. The zero value overrides nothing.
+type StorePrices struct {
+	StoreID int64
+	// BasePrices replace the base price of the products, by their ID.
+	BasePrices map[int64]Price
+	// PriceDeltas replace the price delta of the modifier options, by their ID.
+	PriceDeltas map[int64]Price
+}
+
+// Resolve returns the product and its modifier groups with the store's prices in place of the usual ones.
+// It's the only place the prices are resolved, Configure prices the orders with it and the catalog shows
+// its prices, so that both always agree. The groups given are not modified.
+func (s StorePrices) Resolve(p Product, groups []ModifierGroup) (Product, []ModifierGroup) {
+	if price, ok := s.BasePrices[p.ID]; ok {
+		p.BasePrice = price
+	}
+
+	if len(s.PriceDeltas) == 0 {
+		return p, groups
+	}
+
+	resolved := make([]ModifierGroup, 0, len(groups))
+	for _, group := range groups {
+		options := make([]ModifierOption, 0, len(group.Options))
+		for _, option := range group.Options {
+			if priceDelta, ok := s.PriceDeltas[option.ID]; ok {
+				option.PriceDelta = priceDelta
+			}
+			options = append(options, option)
+		}
+
+		group.Options = options
+		resolved = append(resolved, group)
+	}
+
+	return p, resolved
+}
+
+// validate returns a *ValidationError if any of the prices is out of bounds.
+func (s StorePrices) validate() error {
+	if len(s.BasePrices) > maximumStorePrices {
+		return &ValidationError{Field: "base_prices", Message: fmt.Sprintf("must be at most %d prices", maximumStorePrices)}
+	}
+	for productId, price := range s.BasePrices {
+		if productId <= 0 {
+			return &ValidationError{Field: "base_prices", Message: "product IDs must be positive"}
+		}
+		if price < 0 || price > MaximumPrice {
+			return &ValidationError{Field: "base_prices", Message: fmt.Sprintf("price of product %d must be between 0 and %d", productId, MaximumPrice)}
+		}
+	}
+
+	if len(s.PriceDeltas) > maximumStorePrices {
+		return &ValidationError{Field: "price_deltas", Message: fmt.Sprintf("must be at most %d prices", maximumStorePrices)}
+	}
+	for optionId, priceDelta := range s.PriceDeltas {
+		if optionId <= 0 {
+			return &ValidationError{Field: "price_deltas", Message: "option IDs must be positive"}
+		}
+		if priceDelta < -maximumPriceDelta || priceDelta > maximumPriceDelta {
+			return &ValidationError{Field: "price_deltas", Message: fmt.Sprintf("price delta of option %d must be between -%d and %d", optionId, maximumPriceDelta, maximumPriceDelta)}
+		}
+	}
+
+	return nil
+}
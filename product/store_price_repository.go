@@ -0,0 +1,262 @@
+package product
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"coffee-chain-api/account"
+
+	"github.com/rs/zerolog/log"
+)
+
+func (r *repository) GetStorePrices(ctx context.Context, storeId int64) (StorePrices, error) {
+	if storeId <= 0 {
+		return StorePrices{StoreID: storeId, BasePrices: make(map[int64]Price), PriceDeltas: make(map[int64]Price)}, nil
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return StorePrices{}, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	return getStorePrices(ctx, conn, storeId)
+}
+
+func getStorePrices(ctx context.Context, q queryer, storeId int64) (StorePrices, error) {
+	prices := StorePrices{StoreID: storeId, BasePrices: make(map[int64]Price), PriceDeltas: make(map[int64]Price)}
+	if storeId <= 0 {
+		return prices, nil
+	}
+
+	err := selectPrices(ctx, q, `SELECT product_id, price FROM store_product_prices WHERE store_id = $1`, storeId, prices.BasePrices)
+	if err != nil {
+		return StorePrices{}, err
+	}
+
+	err = selectPrices(ctx, q, `SELECT option_id, price_delta FROM store_modifier_option_prices WHERE store_id = $1`, storeId, prices.PriceDeltas)
+	if err != nil {
+		return StorePrices{}, err
+	}
+
+	return prices, nil
+}
+
+// selectPrices runs a query of IDs and prices into the map.
+func selectPrices(ctx context.Context, q queryer, query string, storeId int64, prices map[int64]Price) error {
+	rows, err := q.QueryContext(ctx, query, storeId)
+	if err != nil {
+		return fmt.Errorf("executing select query: %w", err)
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing rows")
+		}
+	}()
+
+	for rows.Next() {
+		var id int64
+		var price Price
+		err = rows.Scan(&id, &price)
+		if err != nil {
+			return fmt.Errorf("scanning row: %w", err)
+		}
+
+		prices[id] = price
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return fmt.Errorf("iterating rows: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repository) SetStorePrices(ctx context.Context, prices StorePrices) error {
+	err := prices.validate()
+	if err != nil {
+		return err
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		return fmt.Errorf("creating transaction: %w", err)
+	}
+
+	err = setStorePrices(ctx, tx, prices)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+func setStorePrices(ctx context.Context, tx *sql.Tx, prices StorePrices) error {
+	// Keep the products and options from being deleted until the transaction is done.
+	missing, err := missingIDs(ctx, tx, `SELECT id FROM products WHERE deleted_at IS NULL AND id IN (%s) FOR SHARE`, prices.BasePrices)
+	if err != nil {
+		return err
+	}
+	if missing > 0 {
+		return &ValidationError{Field: "base_prices", Message: fmt.Sprintf("product %d does not exist", missing)}
+	}
+
+	missing, err = missingIDs(ctx, tx, `SELECT id FROM modifier_options WHERE deleted_at IS NULL AND id IN (%s) FOR SHARE`, prices.PriceDeltas)
+	if err != nil {
+		return err
+	}
+	if missing > 0 {
+		return &ValidationError{Field: "price_deltas", Message: fmt.Sprintf("option %d does not exist", missing)}
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM store_product_prices WHERE store_id = $1`, prices.StoreID)
+	if err != nil {
+		return fmt.Errorf("executing delete query: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM store_modifier_option_prices WHERE store_id = $1`, prices.StoreID)
+	if err != nil {
+		return fmt.Errorf("executing delete query: %w", err)
+	}
+
+	now := time.Now()
+	for productId, price := range prices.BasePrices {
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO
+				store_product_prices
+				(store_id,
+				 product_id,
+				 price,
+				 updated_at,
+				 updated_by
+				 )
+			VALUES
+				($1, $2, $3, $4, $5)`,
+			prices.StoreID,
+			productId,
+			price,
+			now,
+			account.ActorIdentifier(ctx),
+		)
+		if err != nil {
+			return fmt.Errorf("executing insert query: %w", err)
+		}
+	}
+
+	for optionId, priceDelta := range prices.PriceDeltas {
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO
+				store_modifier_option_prices
+				(store_id,
+				 option_id,
+				 price_delta,
+				 updated_at,
+				 updated_by
+				 )
+			VALUES
+				($1, $2, $3, $4, $5)`,
+			prices.StoreID,
+			optionId,
+			priceDelta,
+			now,
+			account.ActorIdentifier(ctx),
+		)
+		if err != nil {
+			return fmt.Errorf("executing insert query: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// missingIDs returns the lowest of the IDs that the query does not select, or zero if it selects all of
+// them. The query has a %s in place of the IDs.
+func missingIDs(ctx context.Context, tx *sql.Tx, query string, prices map[int64]Price) (int64, error) {
+	if len(prices) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]int64, 0, len(prices))
+	for id := range prices {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	args := make([]any, 0, len(ids))
+	placeholders := make([]string, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+		placeholders = append(placeholders, "$"+strconv.Itoa(len(args)))
+	}
+
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(query, strings.Join(placeholders, ", ")), args...)
+	if err != nil {
+		return 0, fmt.Errorf("executing select query: %w", err)
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing rows")
+		}
+	}()
+
+	found := make(map[int64]bool, len(ids))
+	for rows.Next() {
+		var id int64
+		err = rows.Scan(&id)
+		if err != nil {
+			return 0, fmt.Errorf("scanning row: %w", err)
+		}
+		found[id] = true
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return 0, fmt.Errorf("iterating rows: %w", err)
+	}
+
+	for _, id := range ids {
+		if !found[id] {
+			return id, nil
+		}
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,61 @@
+package product_test
+
+import (
+	"testing"
+
+	"coffee-chain-api/product"
+)
+
+func TestStorePrices_Resolve(t *testing.T) {
+	latte := product.Product{ID: 1, Name: "Caffe Latte", BasePrice: 30_000_00}
+	extras := product.ModifierGroup{ID: 20, Name: "Extras", MinSelections: 0, MaxSelections: 2, Options: []product.ModifierOption{
+		{ID: 200, GroupID: 20, Name: "Extra espresso", PriceDelta: 5_000_00},
+		{ID: 201, GroupID: 20, Name: "Oat milk", PriceDelta: 8_000_00},
+	}}
+
+	airport := product.StorePrices{
+		StoreID:     7,
+		BasePrices:  map[int64]product.Price{1: 45_000_00},
+		PriceDeltas: map[int64]product.Price{201: 10_000_00},
+	}
+
+	resolved, groups := airport.Resolve(latte, []product.ModifierGroup{extras})
+	if resolved.BasePrice != 45_000_00 {
+		t.Errorf("expecting the store's base price, got %s instead", resolved.BasePrice)
+	}
+	if groups[0].Options[0].PriceDelta != 5_000_00 || groups[0].Options[1].PriceDelta != 10_000_00 {
+		t.Errorf("expecting only the oat milk to be overridden, got %+v instead", groups[0].Options)
+	}
+	if extras.Options[1].PriceDelta != 8_000_00 {
+		t.Errorf("expecting the given groups not to be modified")
+	}
+
+	street, _ := product.StorePrices{}.Resolve(latte, nil)
+	if street.BasePrice != latte.BasePrice {
+		t.Errorf("expecting the usual price without overrides, got %s instead", street.BasePrice)
+	}
+}
+
+func TestConfigure_StorePrices(t *testing.T) {
+	latte := product.Product{ID: 1, Name: "Caffe Latte", BasePrice: 30_000_00}
+	extras := product.ModifierGroup{ID: 20, Name: "Extras", MinSelections: 0, MaxSelections: 2, Options: []product.ModifierOption{
+		{ID: 200, GroupID: 20, Name: "Extra espresso", PriceDelta: 5_000_00},
+		{ID: 201, GroupID: 20, Name: "Oat milk", PriceDelta: 8_000_00},
+	}}
+	airport := product.StorePrices{
+		StoreID:     7,
+		BasePrices:  map[int64]product.Price{1: 45_000_00},
+		PriceDeltas: map[int64]product.Price{201: 10_000_00},
+	}
+
+	got, err := product.Configure(latte, []product.ModifierGroup{extras}, []int64{200, 201}, airport)
+	if err != nil {
+		t.Fatalf("expecting no error, got %v instead", err)
+	}
+	if got.UnitPrice != 60_000_00 {
+		t.Errorf("expecting %s, got %s instead", product.Price(60_000_00), got.UnitPrice)
+	}
+	if got.Product.BasePrice != 45_000_00 || got.Options[1].PriceDelta != 10_000_00 {
+		t.Errorf("expecting the configuration to carry the store's prices, got %+v instead", got)
+	}
+}
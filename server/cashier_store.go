@@ -0,0 +1,91 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"coffee-chain-api/store"
+)
+
+// cashierStore acquires the store the authenticated cashier is assigned to. It writes the error response
+// and returns false if the cashier has no store, or it has been deleted.
+func (s *Server) cashierStore(w http.ResponseWriter, r *http.Request) (store.Store, bool) {
+	storeId := accountFromContext(r.Context()).StoreIdentifier()
+	if storeId <= 0 {
+		writeError(w, http.StatusForbidden, "no_store", "Account is not assigned to any store")
+		return store.Store{}, false
+	}
+
+	assigned, err := s.stores.GetByID(r.Context(), storeId)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusForbidden, "no_store", "Account is not assigned to any store")
+			return store.Store{}, false
+		}
+
+		writeInternalError(w, r, err)
+		return store.Store{}, false
+	}
+
+	return assigned, true
+}
+
+func (s *Server) getCashierStore(w http.ResponseWriter, r *http.Request) {
+	assigned, ok := s.cashierStore(w, r)
+	if !ok {
+		return
+	}
+
+	s.writeManagedStore(w, r, http.StatusOK, assigned)
+}
+
+type setStoreStateRequest struct {
+	State string `json:"state"`
+	// PauseReason is shown to the customers, required when pausing.
+	PauseReason string `json:"pause_reason"`
+	// ResumeAt is when the store opens again by itself, required when pausing.
+	ResumeAt time.Time `json:"resume_at"`
+}
+
+// setCashierStoreState opens, closes, or pauses the store of the authenticated cashier.
+func (s *Server) setCashierStoreState(w http.ResponseWriter, r *http.Request) {
+	var request setStoreStateRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	state, ok := store.ParseState(request.State)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid_state", "State must be one of open, closed, or paused")
+		return
+	}
+
+	assigned, ok := s.cashierStore(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := s.stores.SetState(r.Context(), assigned.ID, store.StateChange{
+		State:       state,
+		PauseReason: request.PauseReason,
+		ResumeAt:    request.ResumeAt,
+	})
+	if err != nil {
+		if writeStoreValidationError(w, err) {
+			return
+		}
+
+		switch {
+		case errors.Is(err, store.ErrInvalidStateChange):
+			writeError(w, http.StatusConflict, "invalid_state_change", "A closed store must be opened before it can be paused")
+		case errors.Is(err, store.ErrNotFound):
+			writeError(w, http.StatusForbidden, "no_store", "Account is not assigned to any store")
+		default:
+			writeInternalError(w, r, err)
+		}
+		return
+	}
+
+	s.writeManagedStore(w, r, http.StatusOK, updated)
+}
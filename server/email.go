@@ -0,0 +1,72 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"coffee-chain-api/account/emailchange"
+)
+
+type changeEmailRequest struct {
+	NewEmail        string `json:"new_email"`
+	CurrentPassword string `json:"current_password"`
+}
+
+func (s *Server) changeEmail(w http.ResponseWriter, r *http.Request) {
+	var request changeEmailRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	userAccount := accountFromContext(r.Context())
+
+	if !s.validateCurrentPassword(w, r, request.CurrentPassword) {
+		return
+	}
+
+	err := s.emailChange.RequestChange(r.Context(), userAccount, request.NewEmail)
+	if err != nil {
+		switch {
+		case errors.Is(err, emailchange.ErrInvalidEmail):
+			writeError(w, http.StatusBadRequest, "invalid_email", "New email address is invalid")
+		case errors.Is(err, emailchange.ErrSameEmail):
+			writeError(w, http.StatusBadRequest, "same_email", "New email address is the current one")
+		case errors.Is(err, emailchange.ErrEmailTaken):
+			writeError(w, http.StatusConflict, "email_taken", "New email address is used by another account")
+		default:
+			writeInternalError(w, r, err)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]string{
+		"message": "A verification code has been sent to the new email address",
+	})
+}
+
+type confirmEmailChangeRequest struct {
+	Code string `json:"code"`
+}
+
+func (s *Server) confirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	var request confirmEmailChangeRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	err := s.emailChange.Confirm(r.Context(), accountFromContext(r.Context()), request.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, emailchange.ErrInvalidCode):
+			writeError(w, http.StatusBadRequest, "invalid_code", "Verification code is invalid or has expired")
+		case errors.Is(err, emailchange.ErrEmailTaken):
+			writeError(w, http.StatusConflict, "email_taken", "New email address is used by another account")
+		default:
+			writeInternalError(w, r, err)
+		}
+		return
+	}
+
+	// Every session has been revoked, including the current one. The client has to login again.
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"coffee-chain-api/account/authentication"
+	"coffee-chain-api/account/loginthrottle"
+)
+
+type loginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type loginResponse struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// mfaChallengeResponse is returned by the login instead of loginResponse for accounts with two-factor.
+type mfaChallengeResponse struct {
+	MFARequired    bool      `json:"mfa_required"`
+	ChallengeToken string    `json:"challenge_token"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	var request loginRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	email := strings.TrimSpace(request.Email)
+	if email == "" || request.Password == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Email and password are required")
+		return
+	}
+
+	accessToken, refreshToken, expiredAt, err := s.authentication.Login(r.Context(), email, request.Password)
+	if err != nil {
+		var mfaRequiredError *authentication.MFARequiredError
+		if errors.As(err, &mfaRequiredError) {
+			writeJSON(w, http.StatusOK, mfaChallengeResponse{
+				MFARequired:    true,
+				ChallengeToken: mfaRequiredError.ChallengeToken,
+				ExpiresAt:      mfaRequiredError.ExpiredAt,
+			})
+			return
+		}
+
+		writeLoginError(w, r, err, "Email or password is incorrect")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, loginResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresAt:    expiredAt,
+	})
+}
+
+type loginWithMFARequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	// Code is either a one-time code from the authenticator app, or a recovery code.
+	Code string `json:"code"`
+}
+
+func (s *Server) loginWithMFA(w http.ResponseWriter, r *http.Request) {
+	var request loginWithMFARequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	if request.ChallengeToken == "" || strings.TrimSpace(request.Code) == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Challenge token and code are required")
+		return
+	}
+
+	accessToken, refreshToken, expiredAt, err := s.authentication.LoginWithMFA(r.Context(), request.ChallengeToken, request.Code)
+	if err != nil {
+		writeLoginError(w, r, err, "Login has expired or the code is incorrect")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, loginResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresAt:    expiredAt,
+	})
+}
+
+// writeLoginError writes the error response of both login steps.
+func writeLoginError(w http.ResponseWriter, r *http.Request, err error, invalidMessage string) {
+	var throttledError *loginthrottle.ThrottledError
+	switch {
+	case errors.As(err, &throttledError):
+		w.Header().Set("Retry-After", retryAfterSeconds(throttledError.RetryAfter))
+		writeError(w, http.StatusTooManyRequests, "too_many_attempts", "Too many failed login attempts, please try again later")
+	case errors.Is(err, authentication.ErrInvalidAuthentication):
+		writeError(w, http.StatusUnauthorized, "invalid_credentials", invalidMessage)
+	default:
+		writeInternalError(w, r, err)
+	}
+}
+
+func (s *Server) logout(w http.ResponseWriter, r *http.Request) {
+	err := s.authentication.Logout(r.Context(), accessTokenFromContext(r.Context()))
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// retryAfterSeconds formats the duration for the Retry-After header, rounded up to the next second.
+func retryAfterSeconds(retryAfter time.Duration) string {
+	seconds := int64((retryAfter + time.Second - 1) / time.Second)
+	return strconv.FormatInt(max(seconds, 1), 10)
+}
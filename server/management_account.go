@@ -0,0 +1,122 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"coffee-chain-api/account"
+	"coffee-chain-api/account/accountstore"
+)
+
+type managedAccountResponse struct {
+	accountResponse
+	EmailValidated bool      `json:"email_validated"`
+	CreatedAt      time.Time `json:"created_at"`
+	CreatedBy      string    `json:"created_by"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	UpdatedBy      string    `json:"updated_by"`
+	// DeactivatedAt is omitted if the account is active.
+	DeactivatedAt *time.Time `json:"deactivated_at,omitempty"`
+}
+
+type listAccountsResponse struct {
+	Accounts   []managedAccountResponse `json:"accounts"`
+	NextCursor string                   `json:"next_cursor,omitempty"`
+}
+
+func (s *Server) listAccounts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var filter accountstore.ListFilter
+	if value := query.Get("type"); value != "" {
+		t, ok := account.ParseType(value)
+		if !ok {
+			writeError(w, http.StatusBadRequest, "invalid_type", "Type must be one of customer, merchant_cashier, or management")
+			return
+		}
+		filter.Type = t
+	}
+	if value := query.Get("store_id"); value != "" {
+		storeId, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || storeId <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid_store_id", "Store ID must be a positive integer")
+			return
+		}
+		filter.StoreID = storeId
+	}
+	if value := query.Get("email_validated"); value != "" {
+		emailValidated, err := strconv.ParseBool(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_email_validated", "Email validated must be a boolean")
+			return
+		}
+		filter.EmailValidated = &emailValidated
+	}
+	if value := query.Get("created_after"); value != "" {
+		createdAfter, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_created_after", "Created after must be an RFC 3339 timestamp")
+			return
+		}
+		filter.CreatedAfter = createdAfter
+	}
+	if value := query.Get("created_before"); value != "" {
+		createdBefore, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_created_before", "Created before must be an RFC 3339 timestamp")
+			return
+		}
+		filter.CreatedBefore = createdBefore
+	}
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || cursor < 0 {
+			writeError(w, http.StatusBadRequest, "invalid_cursor", "Cursor is invalid")
+			return
+		}
+		filter.Cursor = cursor
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid_limit", "Limit must be a positive integer")
+			return
+		}
+		filter.Limit = limit
+	}
+	filter.Name = query.Get("q")
+
+	result, err := s.accountStore.List(r.Context(), filter)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	response := listAccountsResponse{Accounts: make([]managedAccountResponse, 0, len(result.Records))}
+	for _, record := range result.Records {
+		response.Accounts = append(response.Accounts, newManagedAccountResponse(record))
+	}
+	if result.NextCursor > 0 {
+		response.NextCursor = strconv.FormatInt(result.NextCursor, 10)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func newManagedAccountResponse(record accountstore.Record) managedAccountResponse {
+	var deactivatedAt *time.Time
+	if !record.DeactivatedAt.IsZero() {
+		deactivatedAt = &record.DeactivatedAt
+	}
+
+	return managedAccountResponse{
+		accountResponse: newAccountResponse(record.Account),
+		DeactivatedAt:   deactivatedAt,
+		EmailValidated:  record.EmailValidated,
+		CreatedAt:       record.CreatedAt,
+		CreatedBy:       record.CreatedBy,
+		UpdatedAt:       record.UpdatedAt,
+		UpdatedBy:       record.UpdatedBy,
+	}
+}
@@ -0,0 +1,208 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"coffee-chain-api/account"
+	"coffee-chain-api/account/accountstore"
+	"coffee-chain-api/account/securityevent"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+)
+
+type createCashierRequest struct {
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	StoreID int64  `json:"store_id"`
+}
+
+type createCashierResponse struct {
+	accountResponse
+	// InvitationSent is false if the account is created but the invitation email could not be sent. It
+	// can be sent again through the invite endpoint.
+	InvitationSent bool `json:"invitation_sent"`
+}
+
+// createCashier creates a merchant cashier account without a password, and emails an invitation link
+// for the cashier to set one.
+func (s *Server) createCashier(w http.ResponseWriter, r *http.Request) {
+	var request createCashierRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	name := strings.TrimSpace(request.Name)
+	if name == "" || utf8.RuneCountInString(name) > 255 {
+		writeError(w, http.StatusBadRequest, "invalid_name", "Name must be between 1 and 255 characters")
+		return
+	}
+
+	address, err := mail.ParseAddress(request.Email)
+	if err != nil || address.Name != "" {
+		writeError(w, http.StatusBadRequest, "invalid_email", "Email address is invalid")
+		return
+	}
+
+	if !s.validateStoreID(w, r, request.StoreID) {
+		return
+	}
+
+	err = s.accountStore.Insert(r.Context(), accountstore.RawAccount{
+		Name:    name,
+		Email:   address.Address,
+		Type:    account.TypeMerchantCashier,
+		StoreID: request.StoreID,
+	})
+	if err != nil {
+		if errors.Is(err, accountstore.ErrDuplicateEntry) {
+			writeError(w, http.StatusConflict, "email_taken", "Email address is used by another account")
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	cashier, err := s.accountStore.GetByEmail(r.Context(), address.Address)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	invitationSent := true
+	err = s.passwordReset.Invite(r.Context(), cashier)
+	if err != nil {
+		log.Error().Err(err).Int64("account_id", cashier.GetProfile().ID).Msg("sending cashier invitation")
+		invitationSent = false
+	}
+
+	writeJSON(w, http.StatusCreated, createCashierResponse{
+		accountResponse: newAccountResponse(cashier),
+		InvitationSent:  invitationSent,
+	})
+}
+
+// inviteCashier sends a new invitation email, invalidating the previous one.
+func (s *Server) inviteCashier(w http.ResponseWriter, r *http.Request) {
+	cashier, ok := s.cashierFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	err := s.passwordReset.Invite(r.Context(), cashier)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type assignCashierStoreRequest struct {
+	StoreID int64 `json:"store_id"`
+}
+
+func (s *Server) assignCashierStore(w http.ResponseWriter, r *http.Request) {
+	var request assignCashierStoreRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	if !s.validateStoreID(w, r, request.StoreID) {
+		return
+	}
+
+	cashier, ok := s.cashierFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	err := s.accountStore.AssignStore(r.Context(), cashier, request.StoreID)
+	if err != nil {
+		if errors.Is(err, accountstore.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "Cashier not found")
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	// Sessions hold the account from when they were created, the cashier must login again to act on
+	// behalf of the new store.
+	err = s.authentication.RevokeSessions(r.Context(), cashier.GetProfile().ID)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	cashier, err = s.accountStore.GetByID(r.Context(), cashier.GetProfile().ID)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newAccountResponse(cashier))
+}
+
+func (s *Server) deactivateCashier(w http.ResponseWriter, r *http.Request) {
+	cashier, ok := s.cashierFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	err := s.accountStore.Deactivate(r.Context(), cashier)
+	if err != nil {
+		if errors.Is(err, accountstore.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "Cashier not found")
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	s.recorder.Record(r.Context(), cashier.GetProfile().ID, securityevent.TypeAccountDeactivate, securityevent.OutcomeSuccess)
+
+	err = s.authentication.RevokeSessions(r.Context(), cashier.GetProfile().ID)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// cashierFromURL acquires the merchant cashier account from the "id" URL parameter. It writes the error
+// response and returns false if there is no such cashier.
+func (s *Server) cashierFromURL(w http.ResponseWriter, r *http.Request) (account.Account, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusNotFound, "not_found", "Cashier not found")
+		return nil, false
+	}
+
+	cashier, err := s.accountStore.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, accountstore.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "Cashier not found")
+			return nil, false
+		}
+
+		writeInternalError(w, r, err)
+		return nil, false
+	}
+
+	if cashier.GetType() != account.TypeMerchantCashier {
+		writeError(w, http.StatusNotFound, "not_found", "Cashier not found")
+		return nil, false
+	}
+
+	return cashier, true
+}
@@ -0,0 +1,347 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"coffee-chain-api/product"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type managedProductResponse struct {
+	productResponse
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	CreatedBy string    `json:"created_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UpdatedBy string    `json:"updated_by"`
+}
+
+func newManagedProductResponse(p product.Product) managedProductResponse {
+	return managedProductResponse{
+		productResponse: newProductResponse(p),
+		Active:          p.Active,
+		CreatedAt:       p.CreatedAt,
+		CreatedBy:       p.CreatedBy,
+		UpdatedAt:       p.UpdatedAt,
+		UpdatedBy:       p.UpdatedBy,
+	}
+}
+
+type managedCategoryResponse struct {
+	categoryResponse
+	CreatedAt time.Time `json:"created_at"`
+	CreatedBy string    `json:"created_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UpdatedBy string    `json:"updated_by"`
+}
+
+func newManagedCategoryResponse(c product.Category) managedCategoryResponse {
+	return managedCategoryResponse{
+		categoryResponse: newCategoryResponse(c),
+		CreatedAt:        c.CreatedAt,
+		CreatedBy:        c.CreatedBy,
+		UpdatedAt:        c.UpdatedAt,
+		UpdatedBy:        c.UpdatedBy,
+	}
+}
+
+type listManagedProductsResponse struct {
+	Products   []managedProductResponse `json:"products"`
+	NextCursor string                   `json:"next_cursor,omitempty"`
+}
+
+func (s *Server) listManagedProducts(w http.ResponseWriter, r *http.Request) {
+	var filter product.ListFilter
+	if !parseProductListFilter(w, r, &filter) {
+		return
+	}
+
+	result, err := s.products.List(r.Context(), filter)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	response := listManagedProductsResponse{Products: make([]managedProductResponse, 0, len(result.Products))}
+	for _, managedProduct := range result.Products {
+		response.Products = append(response.Products, newManagedProductResponse(managedProduct))
+	}
+	if result.NextCursor > 0 {
+		response.NextCursor = strconv.FormatInt(result.NextCursor, 10)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) getManagedProduct(w http.ResponseWriter, r *http.Request) {
+	managedProduct, ok := s.productFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	s.writeManagedProduct(w, r, http.StatusOK, managedProduct)
+}
+
+type productRequest struct {
+	CategoryID  int64  `json:"category_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	ImageURL    string `json:"image_url"`
+	// BasePrice is in IDR minor units, 100 to the Rupiah.
+	BasePrice int64 `json:"base_price"`
+	// Active defaults to true if omitted.
+	Active *bool `json:"active"`
+	// MaxQuantityPerOrder is the most a customer can order at once, zero for no limit.
+	MaxQuantityPerOrder int `json:"max_quantity_per_order"`
+	// MaxQuantityPerWindow is the most a customer can order over the last QuantityWindowHours, such as 2
+	// every 24 hours for a limited item. Both are zero for no limit.
+	MaxQuantityPerWindow int `json:"max_quantity_per_window"`
+	// QuantityWindowHours is small enough not to overflow a time.Duration.
+	QuantityWindowHours uint16 `json:"quantity_window_hours"`
+	// DietaryTags are names such as vegan or gluten_free.
+	DietaryTags []string `json:"dietary_tags"`
+}
+
+// rawProduct returns a *product.ValidationError if any of the dietary tags is unknown, the rest is validated
+// by the repository.
+func (request productRequest) rawProduct() (product.RawProduct, error) {
+	dietaryTags, ok := product.ParseDietaryTags(request.DietaryTags)
+	if !ok {
+		return product.RawProduct{}, &product.ValidationError{Field: "dietary_tags", Message: "must be known dietary tags"}
+	}
+
+	rawProduct := product.RawProduct{
+		CategoryID:  request.CategoryID,
+		Name:        request.Name,
+		Description: request.Description,
+		ImageURL:    request.ImageURL,
+		BasePrice:   product.Price(request.BasePrice),
+		Active:      true,
+		QuantityLimit: product.QuantityLimit{
+			PerOrder:  request.MaxQuantityPerOrder,
+			PerWindow: request.MaxQuantityPerWindow,
+			Window:    time.Duration(request.QuantityWindowHours) * time.Hour,
+		},
+		DietaryTags: dietaryTags,
+	}
+	if request.Active != nil {
+		rawProduct.Active = *request.Active
+	}
+
+	return rawProduct, nil
+}
+
+func (s *Server) createProduct(w http.ResponseWriter, r *http.Request) {
+	var request productRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	rawProduct, err := request.rawProduct()
+	if err != nil {
+		writeProductError(w, err)
+		return
+	}
+
+	created, err := s.products.Insert(r.Context(), rawProduct)
+	if err != nil {
+		if writeProductError(w, err) {
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	s.writeManagedProduct(w, r, http.StatusCreated, created)
+}
+
+// updateProduct replaces every field of the product, the omitted fields are reset to their defaults.
+func (s *Server) updateProduct(w http.ResponseWriter, r *http.Request) {
+	var request productRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	rawProduct, err := request.rawProduct()
+	if err != nil {
+		writeProductError(w, err)
+		return
+	}
+
+	managedProduct, ok := s.productFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := s.products.Update(r.Context(), managedProduct.ID, rawProduct)
+	if err != nil {
+		if writeProductError(w, err) {
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	s.writeManagedProduct(w, r, http.StatusOK, updated)
+}
+
+// deleteProduct removes a product from the menu for good. Products that are only off the menu for a
+// while should be set to inactive instead.
+func (s *Server) deleteProduct(w http.ResponseWriter, r *http.Request) {
+	managedProduct, ok := s.productFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	err := s.products.Delete(r.Context(), managedProduct.ID)
+	if err != nil {
+		if writeProductError(w, err) {
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type listManagedCategoriesResponse struct {
+	Categories []managedCategoryResponse `json:"categories"`
+}
+
+func (s *Server) listManagedCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := s.products.ListCategories(r.Context())
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	response := listManagedCategoriesResponse{Categories: make([]managedCategoryResponse, 0, len(categories))}
+	for _, category := range categories {
+		response.Categories = append(response.Categories, newManagedCategoryResponse(category))
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+type categoryRequest struct {
+	Name     string `json:"name"`
+	Position int    `json:"position"`
+}
+
+func (s *Server) createCategory(w http.ResponseWriter, r *http.Request) {
+	var request categoryRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	created, err := s.products.InsertCategory(r.Context(), product.RawCategory{
+		Name:     request.Name,
+		Position: request.Position,
+	})
+	if err != nil {
+		if writeProductError(w, err) {
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newManagedCategoryResponse(created))
+}
+
+func (s *Server) updateCategory(w http.ResponseWriter, r *http.Request) {
+	var request categoryRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	categoryId, ok := categoryIDFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := s.products.UpdateCategory(r.Context(), categoryId, product.RawCategory{
+		Name:     request.Name,
+		Position: request.Position,
+	})
+	if err != nil {
+		if errors.Is(err, product.ErrCategoryNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "Category not found")
+			return
+		}
+		if writeProductError(w, err) {
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newManagedCategoryResponse(updated))
+}
+
+// deleteCategory removes a category that has no products anymore.
+func (s *Server) deleteCategory(w http.ResponseWriter, r *http.Request) {
+	categoryId, ok := categoryIDFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	err := s.products.DeleteCategory(r.Context(), categoryId)
+	if err != nil {
+		if errors.Is(err, product.ErrCategoryNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "Category not found")
+			return
+		}
+		if writeProductError(w, err) {
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// categoryIDFromURL parses the "id" URL parameter as a category ID. It writes the error response and
+// returns false if it's not a valid ID.
+func categoryIDFromURL(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusNotFound, "not_found", "Category not found")
+		return 0, false
+	}
+
+	return id, true
+}
+
+// writeProductError writes the response for the errors of the product repository that are caused by the
+// request, with ErrCategoryNotFound being an invalid category_id in the body. It returns false if err is not
+// one of them.
+func writeProductError(w http.ResponseWriter, err error) bool {
+	var validationError *product.ValidationError
+	switch {
+	case errors.As(err, &validationError):
+		writeError(w, http.StatusBadRequest, "invalid_"+validationError.Field, validationError.Error())
+	case errors.Is(err, product.ErrNotFound):
+		writeError(w, http.StatusNotFound, "not_found", "Product not found")
+	case errors.Is(err, product.ErrCategoryNotFound):
+		writeError(w, http.StatusBadRequest, "invalid_category_id", "Category does not exist")
+	case errors.Is(err, product.ErrCategoryInUse):
+		writeError(w, http.StatusConflict, "category_has_products", "Move or delete the products of the category first")
+	default:
+		return false
+	}
+
+	return true
+}
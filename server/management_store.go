@@ -0,0 +1,277 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"coffee-chain-api/account"
+	"coffee-chain-api/account/accountstore"
+	"coffee-chain-api/store"
+)
+
+type managedStoreResponse struct {
+	storeResponse
+	Status string `json:"status"`
+	// StateUpdatedAt and StateUpdatedBy are omitted if the state has never been set.
+	StateUpdatedAt *time.Time `json:"state_updated_at,omitempty"`
+	StateUpdatedBy string     `json:"state_updated_by,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	CreatedBy      string     `json:"created_by"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	UpdatedBy      string     `json:"updated_by"`
+}
+
+func newManagedStoreResponse(s store.Store, schedule store.Schedule) managedStoreResponse {
+	var stateUpdatedAt *time.Time
+	if !s.StateUpdatedAt.IsZero() {
+		stateUpdatedAt = &s.StateUpdatedAt
+	}
+
+	return managedStoreResponse{
+		storeResponse:  newStoreResponse(s, schedule),
+		Status:         s.Status.String(),
+		StateUpdatedAt: stateUpdatedAt,
+		StateUpdatedBy: s.StateUpdatedBy,
+		CreatedAt:      s.CreatedAt,
+		CreatedBy:      s.CreatedBy,
+		UpdatedAt:      s.UpdatedAt,
+		UpdatedBy:      s.UpdatedBy,
+	}
+}
+
+type managedStoreDetailResponse struct {
+	managedStoreResponse
+	storeScheduleResponse
+}
+
+// writeManagedStore writes the store along with its schedule.
+func (s *Server) writeManagedStore(w http.ResponseWriter, r *http.Request, statusCode int, managedStore store.Store) {
+	schedules, err := s.storeSchedules(r, managedStore)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	writeJSON(w, statusCode, managedStoreDetailResponse{
+		managedStoreResponse:  newManagedStoreResponse(managedStore, schedules[managedStore.ID]),
+		storeScheduleResponse: newStoreScheduleResponse(managedStore, schedules[managedStore.ID]),
+	})
+}
+
+type listManagedStoresResponse struct {
+	Stores     []managedStoreResponse `json:"stores"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
+}
+
+func (s *Server) listManagedStores(w http.ResponseWriter, r *http.Request) {
+	var filter store.ListFilter
+	if value := r.URL.Query().Get("status"); value != "" {
+		status, ok := store.ParseStatus(value)
+		if !ok {
+			writeError(w, http.StatusBadRequest, "invalid_status", "Status must be either active or inactive")
+			return
+		}
+		filter.Status = status
+	}
+	if !parseStoreListFilter(w, r, &filter) {
+		return
+	}
+
+	result, err := s.stores.List(r.Context(), filter)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	schedules, err := s.storeSchedules(r, result.Stores...)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	response := listManagedStoresResponse{Stores: make([]managedStoreResponse, 0, len(result.Stores))}
+	for _, managedStore := range result.Stores {
+		response.Stores = append(response.Stores, newManagedStoreResponse(managedStore, schedules[managedStore.ID]))
+	}
+	if result.NextCursor > 0 {
+		response.NextCursor = strconv.FormatInt(result.NextCursor, 10)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) getManagedStore(w http.ResponseWriter, r *http.Request) {
+	managedStore, ok := s.storeFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	s.writeManagedStore(w, r, http.StatusOK, managedStore)
+}
+
+type storeRequest struct {
+	Name      string  `json:"name"`
+	Address   string  `json:"address"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Phone     string  `json:"phone"`
+	// Timezone defaults to Asia/Jakarta if empty.
+	Timezone string `json:"timezone"`
+	// Status defaults to active if empty.
+	Status string `json:"status"`
+}
+
+// rawStore converts the request into a store.RawStore. It writes the error response and returns false if
+// the status is unknown, the other fields are validated by the store repository.
+func (request storeRequest) rawStore(w http.ResponseWriter) (store.RawStore, bool) {
+	rawStore := store.RawStore{
+		Name:      request.Name,
+		Address:   request.Address,
+		Latitude:  request.Latitude,
+		Longitude: request.Longitude,
+		Phone:     request.Phone,
+		Timezone:  request.Timezone,
+	}
+
+	if request.Status != "" {
+		status, ok := store.ParseStatus(request.Status)
+		if !ok {
+			writeError(w, http.StatusBadRequest, "invalid_status", "Status must be either active or inactive")
+			return store.RawStore{}, false
+		}
+		rawStore.Status = status
+	}
+
+	return rawStore, true
+}
+
+func (s *Server) createStore(w http.ResponseWriter, r *http.Request) {
+	var request storeRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	rawStore, ok := request.rawStore(w)
+	if !ok {
+		return
+	}
+
+	created, err := s.stores.Insert(r.Context(), rawStore)
+	if err != nil {
+		if writeStoreValidationError(w, err) {
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	s.writeManagedStore(w, r, http.StatusCreated, created)
+}
+
+// updateStore replaces every field of the store, the omitted fields are reset to their defaults.
+func (s *Server) updateStore(w http.ResponseWriter, r *http.Request) {
+	var request storeRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	rawStore, ok := request.rawStore(w)
+	if !ok {
+		return
+	}
+
+	managedStore, ok := s.storeFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := s.stores.Update(r.Context(), managedStore.ID, rawStore)
+	if err != nil {
+		if writeStoreValidationError(w, err) {
+			return
+		}
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "Store not found")
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	s.writeManagedStore(w, r, http.StatusOK, updated)
+}
+
+// deleteStore removes a store that has no cashier assigned to it anymore. Stores that are only closed for
+// a while should be set to inactive instead.
+func (s *Server) deleteStore(w http.ResponseWriter, r *http.Request) {
+	managedStore, ok := s.storeFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	cashiers, err := s.accountStore.List(r.Context(), accountstore.ListFilter{
+		Type:    account.TypeMerchantCashier,
+		StoreID: managedStore.ID,
+		Limit:   1,
+	})
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	if len(cashiers.Records) > 0 {
+		writeError(w, http.StatusConflict, "store_has_cashiers", "Reassign or deactivate the cashiers of the store first")
+		return
+	}
+
+	err = s.stores.Delete(r.Context(), managedStore.ID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "Store not found")
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeStoreValidationError writes the response for a *store.ValidationError. It returns false if err is
+// not one.
+func writeStoreValidationError(w http.ResponseWriter, err error) bool {
+	var validationError *store.ValidationError
+	if !errors.As(err, &validationError) {
+		return false
+	}
+
+	writeError(w, http.StatusBadRequest, "invalid_"+validationError.Field, validationError.Error())
+	return true
+}
+
+// validateStoreID checks that the store a cashier is assigned to exists. It writes the error response and
+// returns false if it does not.
+func (s *Server) validateStoreID(w http.ResponseWriter, r *http.Request, storeId int64) bool {
+	if storeId <= 0 {
+		writeError(w, http.StatusBadRequest, "invalid_store_id", "Store ID must be a positive integer")
+		return false
+	}
+
+	_, err := s.stores.GetByID(r.Context(), storeId)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusBadRequest, "invalid_store_id", "Store does not exist")
+			return false
+		}
+
+		writeInternalError(w, r, err)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+
+	"coffee-chain-api/product"
+)
+
+type basePriceJSON struct {
+	ProductID int64 `json:"product_id"`
+	// Price is in IDR minor units, in place of the product's base price.
+	Price int64 `json:"price"`
+}
+
+type priceDeltaJSON struct {
+	OptionID int64 `json:"option_id"`
+	// PriceDelta is in IDR minor units, in place of the option's price delta.
+	PriceDelta int64 `json:"price_delta"`
+}
+
+type storePricesResponse struct {
+	StoreID     int64            `json:"store_id"`
+	BasePrices  []basePriceJSON  `json:"base_prices"`
+	PriceDeltas []priceDeltaJSON `json:"price_deltas"`
+}
+
+func newStorePricesResponse(prices product.StorePrices) storePricesResponse {
+	response := storePricesResponse{
+		StoreID:     prices.StoreID,
+		BasePrices:  make([]basePriceJSON, 0, len(prices.BasePrices)),
+		PriceDeltas: make([]priceDeltaJSON, 0, len(prices.PriceDeltas)),
+	}
+	for productId, price := range prices.BasePrices {
+		response.BasePrices = append(response.BasePrices, basePriceJSON{ProductID: productId, Price: int64(price)})
+	}
+	for optionId, priceDelta := range prices.PriceDeltas {
+		response.PriceDeltas = append(response.PriceDeltas, priceDeltaJSON{OptionID: optionId, PriceDelta: int64(priceDelta)})
+	}
+
+	sort.Slice(response.BasePrices, func(i, j int) bool {
+		return response.BasePrices[i].ProductID < response.BasePrices[j].ProductID
+	})
+	sort.Slice(response.PriceDeltas, func(i, j int) bool {
+		return response.PriceDeltas[i].OptionID < response.PriceDeltas[j].OptionID
+	})
+
+	return response
+}
+
+// getStorePrices lists the prices the store charges instead of the usual ones.
+func (s *Server) getStorePrices(w http.ResponseWriter, r *http.Request) {
+	managedStore, ok := s.storeFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	prices, err := s.products.GetStorePrices(r.Context(), managedStore.ID)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newStorePricesResponse(prices))
+}
+
+type setStorePricesRequest struct {
+	// BasePrices and PriceDeltas replace every price the store overrides, the products and options left out
+	// go back to their usual prices.
+	BasePrices  []basePriceJSON  `json:"base_prices"`
+	PriceDeltas []priceDeltaJSON `json:"price_deltas"`
+}
+
+// setStorePrices replaces the prices the store charges instead of the usual ones, all at once.
+func (s *Server) setStorePrices(w http.ResponseWriter, r *http.Request) {
+	var request setStorePricesRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	prices := product.StorePrices{
+		BasePrices:  make(map[int64]product.Price, len(request.BasePrices)),
+		PriceDeltas: make(map[int64]product.Price, len(request.PriceDeltas)),
+	}
+	for _, basePrice := range request.BasePrices {
+		if _, ok := prices.BasePrices[basePrice.ProductID]; ok {
+			writeError(w, http.StatusBadRequest, "invalid_base_prices", "Each product must only be priced once")
+			return
+		}
+		prices.BasePrices[basePrice.ProductID] = product.Price(basePrice.Price)
+	}
+	for _, priceDelta := range request.PriceDeltas {
+		if _, ok := prices.PriceDeltas[priceDelta.OptionID]; ok {
+			writeError(w, http.StatusBadRequest, "invalid_price_deltas", "Each option must only be priced once")
+			return
+		}
+		prices.PriceDeltas[priceDelta.OptionID] = product.Price(priceDelta.PriceDelta)
+	}
+
+	managedStore, ok := s.storeFromURL(w, r)
+	if !ok {
+		return
+	}
+	prices.StoreID = managedStore.ID
+
+	err := s.products.SetStorePrices(r.Context(), prices)
+	if err != nil {
+		if writeProductError(w, err) {
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newStorePricesResponse(prices))
+}
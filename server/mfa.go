@@ -0,0 +1,129 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"coffee-chain-api/account/securityevent"
+	"coffee-chain-api/account/totp"
+)
+
+type enrollTOTPRequest struct {
+	CurrentPassword string `json:"current_password"`
+}
+
+type enrollTOTPResponse struct {
+	Secret string `json:"secret"`
+	// ProvisioningURI is to be rendered as a QR code by the client, and scanned by the authenticator app.
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+func (s *Server) enrollTOTP(w http.ResponseWriter, r *http.Request) {
+	var request enrollTOTPRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	userAccount := accountFromContext(r.Context())
+
+	if !s.validateCurrentPassword(w, r, request.CurrentPassword) {
+		return
+	}
+
+	enrollment, err := s.totp.Enroll(r.Context(), userAccount)
+	if err != nil {
+		if errors.Is(err, totp.ErrAlreadyEnrolled) {
+			writeError(w, http.StatusConflict, "already_enrolled", "Two-factor authentication is already enabled")
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, enrollTOTPResponse{
+		Secret:          enrollment.Secret,
+		ProvisioningURI: enrollment.ProvisioningURI,
+	})
+}
+
+type confirmTOTPRequest struct {
+	Code string `json:"code"`
+}
+
+type confirmTOTPResponse struct {
+	// RecoveryCodes are only shown once, each of them can be used once in place of a one-time code.
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func (s *Server) confirmTOTP(w http.ResponseWriter, r *http.Request) {
+	var request confirmTOTPRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	recoveryCodes, err := s.totp.ConfirmEnrollment(r.Context(), accountFromContext(r.Context()), request.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, totp.ErrInvalidCode):
+			writeError(w, http.StatusBadRequest, "invalid_code", "Code is incorrect, check the clock of your device")
+		case errors.Is(err, totp.ErrNotEnrolled):
+			writeError(w, http.StatusConflict, "not_enrolled", "Two-factor enrollment has not been started")
+		case errors.Is(err, totp.ErrAlreadyEnrolled):
+			writeError(w, http.StatusConflict, "already_enrolled", "Two-factor authentication is already enabled")
+		default:
+			writeInternalError(w, r, err)
+		}
+		return
+	}
+
+	s.recorder.Record(r.Context(), accountFromContext(r.Context()).GetProfile().ID, securityevent.TypeMFAEnable, securityevent.OutcomeSuccess)
+
+	writeJSON(w, http.StatusOK, confirmTOTPResponse{RecoveryCodes: recoveryCodes})
+}
+
+type disableTOTPRequest struct {
+	CurrentPassword string `json:"current_password"`
+	Code            string `json:"code"`
+}
+
+// disableTOTP removes the second factor. Accounts whose type requires two-factor have to enroll again
+// before they can do anything else, which is how the authenticator device is replaced.
+func (s *Server) disableTOTP(w http.ResponseWriter, r *http.Request) {
+	var request disableTOTPRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	userAccount := accountFromContext(r.Context())
+
+	if !s.validateCurrentPassword(w, r, request.CurrentPassword) {
+		return
+	}
+
+	verified, err := s.totp.Verify(r.Context(), userAccount.GetProfile().ID, request.Code)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	if !verified {
+		writeError(w, http.StatusBadRequest, "invalid_code", "Code is incorrect")
+		return
+	}
+
+	err = s.totp.Disable(r.Context(), userAccount)
+	if err != nil {
+		if errors.Is(err, totp.ErrNotEnrolled) {
+			writeError(w, http.StatusConflict, "not_enrolled", "Two-factor authentication is not enabled")
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	s.recorder.Record(r.Context(), userAccount.GetProfile().ID, securityevent.TypeMFADisable, securityevent.OutcomeSuccess)
+
+	w.WriteHeader(http.StatusNoContent)
+}
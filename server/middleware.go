@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"coffee-chain-api/account"
+	"coffee-chain-api/account/authentication"
+)
+
+type contextKey int
+
+const (
+	accountContextKey contextKey = iota
+	accessTokenContextKey
+)
+
+// clientInfo carries the client's IP address and user agent on the request context, see
+// account.ClientFromContext. It must be used after middleware.RealIP, so the address behind a trusted proxy
+// is used.
+func clientInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := r.RemoteAddr
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			ip = host
+		}
+
+		userAgent := r.UserAgent()
+		if len(userAgent) > maximumUserAgentLength {
+			userAgent = strings.ToValidUTF8(userAgent[:maximumUserAgentLength], "")
+		}
+
+		ctx := account.WithClient(r.Context(), account.Client{IP: ip, UserAgent: userAgent})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// maximumUserAgentLength keeps an absurdly long user agent from being stored as is.
+const maximumUserAgentLength = 512
+
+// authenticate guards the handler by a Bearer access token. The authenticated account and access token
+// can be acquired with accountFromContext and accessTokenFromContext.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, "unauthenticated", "Missing bearer token")
+			return
+		}
+
+		userAccount, err := s.authentication.ValidateSession(r.Context(), token)
+		if err != nil {
+			if errors.Is(err, authentication.ErrInvalidAuthentication) {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				writeError(w, http.StatusUnauthorized, "unauthenticated", "Invalid or expired bearer token")
+				return
+			}
+
+			writeInternalError(w, r, err)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), accountContextKey, userAccount)
+		ctx = context.WithValue(ctx, accessTokenContextKey, token)
+		ctx = account.WithActor(ctx, userAccount)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// accountFromContext returns the account set by the authenticate middleware.
+func accountFromContext(ctx context.Context) account.Account {
+	userAccount, _ := ctx.Value(accountContextKey).(account.Account)
+	return userAccount
+}
+
+// accessTokenFromContext returns the access token set by the authenticate middleware.
+func accessTokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(accessTokenContextKey).(string)
+	return token
+}
+
+// requireAccountType only allows accounts of the given types through. It must be used after authenticate.
+func requireAccountType(types ...account.Type) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userAccount := accountFromContext(r.Context())
+			if userAccount == nil {
+				writeError(w, http.StatusUnauthorized, "unauthenticated", "Missing bearer token")
+				return
+			}
+
+			for _, t := range types {
+				if userAccount.GetType() == t {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			writeError(w, http.StatusForbidden, "forbidden", "Account is not allowed to access this resource")
+		})
+	}
+}
+
+// requireMFAEnrollment only allows accounts through that have enabled two-factor authentication, if their
+// type requires it. It must be used after authenticate.
+func (s *Server) requireMFAEnrollment(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAccount := accountFromContext(r.Context())
+		if userAccount == nil {
+			writeError(w, http.StatusUnauthorized, "unauthenticated", "Missing bearer token")
+			return
+		}
+
+		if !s.mfaPolicy.Required(userAccount.GetType()) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		enrolled, err := s.totp.Enrolled(r.Context(), userAccount.GetProfile().ID)
+		if err != nil {
+			writeInternalError(w, r, err)
+			return
+		}
+
+		if !enrolled {
+			writeError(w, http.StatusForbidden, "mfa_enrollment_required", "Two-factor authentication must be enabled for this account")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
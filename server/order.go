@@ -0,0 +1,178 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"coffee-chain-api/order"
+	"coffee-chain-api/product"
+	"coffee-chain-api/store"
+)
+
+type orderLineRequest struct {
+	ProductID int64 `json:"product_id"`
+	// OptionIDs are the selected options, the groups left out take their default options.
+	OptionIDs []int64 `json:"option_ids"`
+	Quantity  int     `json:"quantity"`
+}
+
+type createOrderRequest struct {
+	StoreID int64              `json:"store_id"`
+	Lines   []orderLineRequest `json:"lines"`
+}
+
+type orderLineResponse struct {
+	ProductID int64                    `json:"product_id"`
+	Name      string                   `json:"name"`
+	Options   []modifierOptionResponse `json:"options"`
+	Quantity  int                      `json:"quantity"`
+	// UnitPrice and Subtotal are in IDR minor units.
+	UnitPrice int64 `json:"unit_price"`
+	Subtotal  int64 `json:"subtotal"`
+}
+
+type orderResponse struct {
+	ID      int64               `json:"id"`
+	StoreID int64               `json:"store_id"`
+	Status  string              `json:"status"`
+	Lines   []orderLineResponse `json:"lines"`
+	// Total is in IDR minor units.
+	Total     int64     `json:"total"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newOrderResponse(o order.Order) orderResponse {
+	response := orderResponse{
+		ID:        o.ID,
+		StoreID:   o.StoreID,
+		Status:    o.Status.String(),
+		Lines:     make([]orderLineResponse, 0, len(o.Lines)),
+		Total:     int64(o.Total),
+		CreatedAt: o.CreatedAt,
+	}
+	for _, line := range o.Lines {
+		lineResponse := orderLineResponse{
+			ProductID: line.Product.ID,
+			Name:      line.Product.Name,
+			Options:   make([]modifierOptionResponse, 0, len(line.Options)),
+			Quantity:  line.Quantity,
+			UnitPrice: int64(line.UnitPrice),
+			Subtotal:  int64(line.Subtotal()),
+		}
+		for _, option := range line.Options {
+			lineResponse.Options = append(lineResponse.Options, newModifierOptionResponse(option))
+		}
+		response.Lines = append(response.Lines, lineResponse)
+	}
+
+	return response
+}
+
+// createOrder places an order for the customer at a store. Every line is priced by the server against the
+// latest catalog, and checked against the availability at the store and the quantity limits of its
+// product.
+func (s *Server) createOrder(w http.ResponseWriter, r *http.Request) {
+	var request createOrderRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	rawOrder := order.RawOrder{StoreID: request.StoreID, Lines: make([]order.RawLine, 0, len(request.Lines))}
+	for _, line := range request.Lines {
+		rawOrder.Lines = append(rawOrder.Lines, order.RawLine{
+			ProductID: line.ProductID,
+			OptionIDs: line.OptionIDs,
+			Quantity:  line.Quantity,
+		})
+	}
+
+	created, err := s.orders.Create(r.Context(), accountFromContext(r.Context()).GetProfile().ID, rawOrder)
+	if err != nil {
+		if writeOrderError(w, err) {
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newOrderResponse(created))
+}
+
+type lineErrorDetails struct {
+	Line      int   `json:"line"`
+	ProductID int64 `json:"product_id"`
+	GroupID   int64 `json:"group_id,omitempty"`
+	OptionID  int64 `json:"option_id,omitempty"`
+}
+
+type orderUnavailableErrorDetails struct {
+	Line int `json:"line"`
+	unavailableErrorDetails
+}
+
+type quantityLimitErrorDetails struct {
+	Line      int   `json:"line"`
+	ProductID int64 `json:"product_id"`
+	Limit     int   `json:"limit"`
+	// WindowHours is omitted if it's the limit per order.
+	WindowHours int `json:"window_hours,omitempty"`
+	Remaining   int `json:"remaining"`
+}
+
+// writeOrderError writes the error response if err is about the order rather than the server, and returns
+// whether it did.
+func writeOrderError(w http.ResponseWriter, err error) bool {
+	var validationError *order.ValidationError
+	var lineError *order.LineError
+	var unavailableError *product.UnavailableError
+	var quantityLimitError *product.QuantityLimitError
+	switch {
+	case errors.As(err, &validationError):
+		writeError(w, http.StatusBadRequest, "invalid_"+validationError.Field, validationError.Error())
+	case errors.Is(err, store.ErrStoreNotOpen):
+		writeError(w, http.StatusConflict, "store_not_open", "Store is not taking orders right now")
+	case errors.As(err, &unavailableError):
+		writeJSON(w, http.StatusConflict, errorResponse{
+			Code:    "product_unavailable",
+			Message: unavailableError.Error(),
+			Details: orderUnavailableErrorDetails{
+				Line:                    unavailableError.Line,
+				unavailableErrorDetails: newUnavailableErrorDetails(unavailableError),
+			},
+		})
+	case errors.As(err, &lineError):
+		details := lineErrorDetails{Line: lineError.Line, ProductID: lineError.ProductID}
+		code := "product_not_found"
+
+		var selectionError *product.SelectionError
+		if errors.As(lineError, &selectionError) {
+			details.GroupID = selectionError.GroupID
+			details.OptionID = selectionError.OptionID
+			code = "invalid_selection"
+		}
+
+		writeJSON(w, http.StatusUnprocessableEntity, errorResponse{
+			Code:    code,
+			Message: lineError.Error(),
+			Details: details,
+		})
+	case errors.As(err, &quantityLimitError):
+		writeJSON(w, http.StatusUnprocessableEntity, errorResponse{
+			Code:    "quantity_limit_exceeded",
+			Message: quantityLimitError.Error(),
+			Details: quantityLimitErrorDetails{
+				Line:        quantityLimitError.Line,
+				ProductID:   quantityLimitError.ProductID,
+				Limit:       quantityLimitError.Limit,
+				WindowHours: int(quantityLimitError.Window.Hours()),
+				Remaining:   quantityLimitError.Remaining,
+			},
+		})
+	default:
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,181 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"coffee-chain-api/account"
+	"coffee-chain-api/account/loginthrottle"
+	"coffee-chain-api/account/passwordreset"
+	"coffee-chain-api/account/securityevent"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maximumPendingResetRequests is the number of password reset requests processed in the background at
+// once.
+const maximumPendingResetRequests = 16
+
+type forgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+func (s *Server) forgotPassword(w http.ResponseWriter, r *http.Request) {
+	var request forgotPasswordRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	email := strings.TrimSpace(request.Email)
+	if email == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Email is required")
+		return
+	}
+
+	client := account.ClientFromContext(r.Context())
+	err := s.resetThrottle.Check(r.Context(), email, client.IP)
+	if err != nil {
+		var throttledError *loginthrottle.ThrottledError
+		if errors.As(err, &throttledError) {
+			w.Header().Set("Retry-After", retryAfterSeconds(throttledError.RetryAfter))
+			writeError(w, http.StatusTooManyRequests, "too_many_requests", "Too many password reset requests, please try again later")
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	// Every request counts, whether the email is registered or not.
+	_, err = s.resetThrottle.Fail(r.Context(), email, client.IP)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	// The reset is requested in the background, so whether the email is registered can not be inferred
+	// from the response nor from how long it takes. The pending requests are bounded, and the ones beyond
+	// it are dropped rather than piling up while the mailer is slow.
+	select {
+	case s.resetRequests <- struct{}{}:
+		go func(ctx context.Context, email string) {
+			defer func() { <-s.resetRequests }()
+
+			err := s.passwordReset.RequestReset(ctx, email)
+			if err != nil {
+				log.Error().Err(err).Msg("requesting password reset")
+			}
+		}(context.WithoutCancel(r.Context()), email)
+	default:
+		log.Warn().Msg("dropping password reset request, too many are pending")
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]string{
+		"message": "If the email is registered, a password reset link has been sent to it",
+	})
+}
+
+type resetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+func (s *Server) resetPassword(w http.ResponseWriter, r *http.Request) {
+	var request resetPasswordRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	err := s.passwordReset.Reset(r.Context(), request.Token, request.NewPassword)
+	if err != nil {
+		if errors.Is(err, passwordreset.ErrInvalidToken) {
+			writeError(w, http.StatusBadRequest, "invalid_token", "Password reset link is invalid or has expired")
+			return
+		}
+
+		if writePasswordPolicyError(w, err) {
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+	// RefreshToken is the refresh token of the current session, which will not be revoked.
+	// Leave it empty to log out from every refresh session.
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (s *Server) changePassword(w http.ResponseWriter, r *http.Request) {
+	var request changePasswordRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	userAccount := accountFromContext(r.Context())
+
+	if !s.validateCurrentPassword(w, r, request.CurrentPassword) {
+		return
+	}
+
+	err := s.passwordPolicy.Check(r.Context(), request.NewPassword, userAccount.GetProfile().Name, userAccount.GetProfile().Email)
+	if err != nil {
+		if writePasswordPolicyError(w, err) {
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	err = s.accountStore.UpdatePassword(r.Context(), userAccount, request.NewPassword)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	keepTokens := []string{accessTokenFromContext(r.Context())}
+	if request.RefreshToken != "" {
+		keepTokens = append(keepTokens, request.RefreshToken)
+	}
+
+	s.recorder.Record(r.Context(), userAccount.GetProfile().ID, securityevent.TypePasswordChange, securityevent.OutcomeSuccess)
+
+	err = s.authentication.RevokeSessions(r.Context(), userAccount.GetProfile().ID, keepTokens...)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateCurrentPassword checks the authenticated account's password before a sensitive change. It writes
+// the error response and returns false if the password is missing or incorrect.
+func (s *Server) validateCurrentPassword(w http.ResponseWriter, r *http.Request, currentPassword string) bool {
+	if currentPassword == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Current password is required")
+		return false
+	}
+
+	validated, err := s.accountStore.ValidatePassword(r.Context(), accountFromContext(r.Context()), currentPassword)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return false
+	}
+
+	if !validated {
+		writeError(w, http.StatusForbidden, "invalid_password", "Current password is incorrect")
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,239 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"coffee-chain-api/product"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type productResponse struct {
+	ID          int64  `json:"id"`
+	CategoryID  int64  `json:"category_id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+	// BasePrice is in IDR minor units, 100 to the Rupiah.
+	BasePrice int64 `json:"base_price"`
+	// The quantity limits are omitted if the product is not limited.
+	MaxQuantityPerOrder  int      `json:"max_quantity_per_order,omitempty"`
+	MaxQuantityPerWindow int      `json:"max_quantity_per_window,omitempty"`
+	QuantityWindowHours  int      `json:"quantity_window_hours,omitempty"`
+	DietaryTags          []string `json:"dietary_tags"`
+	// Availability is only included when browsing the products of a store.
+	Availability *availabilityResponse `json:"availability,omitempty"`
+}
+
+func newProductResponse(p product.Product) productResponse {
+	return productResponse{
+		ID:                   p.ID,
+		CategoryID:           p.CategoryID,
+		Name:                 p.Name,
+		Description:          p.Description,
+		ImageURL:             p.ImageURL,
+		BasePrice:            int64(p.BasePrice),
+		MaxQuantityPerOrder:  p.QuantityLimit.PerOrder,
+		MaxQuantityPerWindow: p.QuantityLimit.PerWindow,
+		QuantityWindowHours:  int(p.QuantityLimit.Window / time.Hour),
+		DietaryTags:          p.DietaryTags.Names(),
+	}
+}
+
+type categoryResponse struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Position int    `json:"position"`
+}
+
+func newCategoryResponse(c product.Category) categoryResponse {
+	return categoryResponse{
+		ID:       c.ID,
+		Name:     c.Name,
+		Position: c.Position,
+	}
+}
+
+type listProductsResponse struct {
+	Products   []productResponse `json:"products"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+// listProducts lists the active products for the customers. Given a store, the products hidden there are
+// left out and the others include their availability and price at the store.
+func (s *Server) listProducts(w http.ResponseWriter, r *http.Request) {
+	filter := product.ListFilter{ActiveOnly: true}
+	if !parseProductListFilter(w, r, &filter) {
+		return
+	}
+
+	storeId, ok := s.storeIDFromQuery(w, r)
+	if !ok {
+		return
+	}
+	filter.VisibleAtStore = storeId
+
+	result, err := s.products.List(r.Context(), filter)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	prices, err := s.products.GetStorePrices(r.Context(), storeId)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	response := listProductsResponse{Products: make([]productResponse, 0, len(result.Products))}
+	for _, activeProduct := range result.Products {
+		resolved, _ := prices.Resolve(activeProduct, nil)
+		response.Products = append(response.Products, newProductResponse(resolved))
+	}
+	if result.NextCursor > 0 {
+		response.NextCursor = strconv.FormatInt(result.NextCursor, 10)
+	}
+
+	if storeId > 0 {
+		err = s.setAvailabilities(r, storeId, response.Products)
+		if err != nil {
+			writeInternalError(w, r, err)
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) getProduct(w http.ResponseWriter, r *http.Request) {
+	activeProduct, ok := s.productFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	// Inactive products are not shown to the customers.
+	if !activeProduct.Active {
+		writeError(w, http.StatusNotFound, "not_found", "Product not found")
+		return
+	}
+
+	storeId, ok := s.storeIDFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	groups, err := s.products.GetProductModifierGroups(r.Context(), activeProduct.ID)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	prices, err := s.products.GetStorePrices(r.Context(), storeId)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	resolved, groups := prices.Resolve(activeProduct, groups)
+	response := productDetailResponse{
+		productResponse: newProductResponse(resolved),
+		ModifierGroups:  newModifierGroupResponses(groups),
+	}
+	if storeId > 0 {
+		products := []productResponse{response.productResponse}
+		err = s.setAvailabilities(r, storeId, products)
+		if err != nil {
+			writeInternalError(w, r, err)
+			return
+		}
+
+		// Neither are the products hidden at the store.
+		if products[0].Availability.Availability == product.AvailabilityHidden.String() {
+			writeError(w, http.StatusNotFound, "not_found", "Product not found")
+			return
+		}
+		response.productResponse = products[0]
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+type listCategoriesResponse struct {
+	Categories []categoryResponse `json:"categories"`
+}
+
+// listCategories lists every product category in their order on the menu.
+func (s *Server) listCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := s.products.ListCategories(r.Context())
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	response := listCategoriesResponse{Categories: make([]categoryResponse, 0, len(categories))}
+	for _, category := range categories {
+		response.Categories = append(response.Categories, newCategoryResponse(category))
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// productFromURL acquires the product from the "id" URL parameter. It writes the error response and
+// returns false if there is no such product.
+func (s *Server) productFromURL(w http.ResponseWriter, r *http.Request) (product.Product, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusNotFound, "not_found", "Product not found")
+		return product.Product{}, false
+	}
+
+	found, err := s.products.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, product.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "Product not found")
+			return product.Product{}, false
+		}
+
+		writeInternalError(w, r, err)
+		return product.Product{}, false
+	}
+
+	return found, true
+}
+
+// parseProductListFilter reads the category, name search, cursor, and limit query parameters into the
+// filter. It writes the error response and returns false if any is invalid.
+func parseProductListFilter(w http.ResponseWriter, r *http.Request, filter *product.ListFilter) bool {
+	query := r.URL.Query()
+
+	if value := query.Get("category_id"); value != "" {
+		categoryId, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || categoryId <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid_category_id", "Category ID must be a positive integer")
+			return false
+		}
+		filter.CategoryID = categoryId
+	}
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || cursor < 0 {
+			writeError(w, http.StatusBadRequest, "invalid_cursor", "Cursor is invalid")
+			return false
+		}
+		filter.Cursor = cursor
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid_limit", "Limit must be a positive integer")
+			return false
+		}
+		filter.Limit = limit
+	}
+	filter.Name = query.Get("q")
+
+	return true
+}
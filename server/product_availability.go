@@ -0,0 +1,231 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"coffee-chain-api/product"
+	"coffee-chain-api/store"
+)
+
+type availabilityResponse struct {
+	// Availability is one of available, sold_out, or hidden, with expired sold outs available again.
+	Availability string `json:"availability"`
+	// SoldOutUntil is omitted if the product is not sold out, or it stays sold out until a cashier sets it
+	// back.
+	SoldOutUntil *time.Time `json:"sold_out_until,omitempty"`
+}
+
+func newAvailabilityResponse(a product.StoreAvailability, now time.Time) availabilityResponse {
+	availability := a.AvailabilityAt(now)
+	response := availabilityResponse{Availability: availability.String()}
+	if availability == product.AvailabilitySoldOut && !a.SoldOutUntil.IsZero() {
+		response.SoldOutUntil = &a.SoldOutUntil
+	}
+
+	return response
+}
+
+// setAvailabilities fills in the availability of each product at the store. The products the store has
+// never set are available.
+func (s *Server) setAvailabilities(r *http.Request, storeId int64, products []productResponse) error {
+	productIds := make([]int64, 0, len(products))
+	for _, p := range products {
+		productIds = append(productIds, p.ID)
+	}
+
+	availabilities, err := s.products.GetStoreAvailabilities(r.Context(), storeId, productIds)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range products {
+		response := newAvailabilityResponse(availabilities[products[i].ID], now)
+		products[i].Availability = &response
+	}
+
+	return nil
+}
+
+// storeIDFromQuery parses the optional "store_id" query parameter, to browse the products of that store.
+// It writes the error response and returns false if it's not an active store, and returns zero if it's
+// not given.
+func (s *Server) storeIDFromQuery(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	value := r.URL.Query().Get("store_id")
+	if value == "" {
+		return 0, true
+	}
+
+	storeId, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || storeId <= 0 {
+		writeError(w, http.StatusBadRequest, "invalid_store_id", "Store ID must be a positive integer")
+		return 0, false
+	}
+
+	_, ok := s.requireActiveStore(w, r, storeId)
+	if !ok {
+		return 0, false
+	}
+
+	return storeId, true
+}
+
+// requireActiveStore returns the store, or writes the error response and returns false if the store does
+// not exist or is inactive, since inactive stores are not shown to the customers.
+func (s *Server) requireActiveStore(w http.ResponseWriter, r *http.Request, storeId int64) (store.Store, bool) {
+	activeStore, err := s.stores.GetByID(r.Context(), storeId)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusBadRequest, "invalid_store_id", "Store does not exist")
+			return store.Store{}, false
+		}
+
+		writeInternalError(w, r, err)
+		return store.Store{}, false
+	}
+
+	if activeStore.Status != store.StatusActive {
+		writeError(w, http.StatusBadRequest, "invalid_store_id", "Store does not exist")
+		return store.Store{}, false
+	}
+
+	return activeStore, true
+}
+
+type unavailableErrorDetails struct {
+	ProductID    int64      `json:"product_id"`
+	Availability string     `json:"availability"`
+	SoldOutUntil *time.Time `json:"sold_out_until,omitempty"`
+}
+
+// writeUnavailableError writes the product at fault if err is a *product.UnavailableError, and returns
+// whether it did.
+func writeUnavailableError(w http.ResponseWriter, err error) bool {
+	var unavailableError *product.UnavailableError
+	if !errors.As(err, &unavailableError) {
+		return false
+	}
+
+	writeJSON(w, http.StatusConflict, errorResponse{
+		Code:    "product_unavailable",
+		Message: unavailableError.Error(),
+		Details: newUnavailableErrorDetails(unavailableError),
+	})
+	return true
+}
+
+func newUnavailableErrorDetails(e *product.UnavailableError) unavailableErrorDetails {
+	details := unavailableErrorDetails{
+		ProductID:    e.ProductID,
+		Availability: e.Availability.String(),
+	}
+	if !e.SoldOutUntil.IsZero() {
+		details.SoldOutUntil = &e.SoldOutUntil
+	}
+
+	return details
+}
+
+// listCashierProducts lists the active products along with their availability and price at the cashier's
+// store, including the ones hidden there so that they can be shown again.
+func (s *Server) listCashierProducts(w http.ResponseWriter, r *http.Request) {
+	filter := product.ListFilter{ActiveOnly: true}
+	if !parseProductListFilter(w, r, &filter) {
+		return
+	}
+
+	assigned, ok := s.cashierStore(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := s.products.List(r.Context(), filter)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	prices, err := s.products.GetStorePrices(r.Context(), assigned.ID)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	response := listProductsResponse{Products: make([]productResponse, 0, len(result.Products))}
+	for _, activeProduct := range result.Products {
+		resolved, _ := prices.Resolve(activeProduct, nil)
+		response.Products = append(response.Products, newProductResponse(resolved))
+	}
+	if result.NextCursor > 0 {
+		response.NextCursor = strconv.FormatInt(result.NextCursor, 10)
+	}
+
+	err = s.setAvailabilities(r, assigned.ID, response.Products)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+type setProductAvailabilityRequest struct {
+	Availability string `json:"availability"`
+	// SoldOutUntil is when a sold out product becomes available again by itself. Leave it empty to keep it
+	// sold out until it's set back.
+	SoldOutUntil time.Time `json:"sold_out_until"`
+}
+
+type storeAvailabilityResponse struct {
+	ProductID int64 `json:"product_id"`
+	availabilityResponse
+	UpdatedAt time.Time `json:"updated_at"`
+	UpdatedBy string    `json:"updated_by"`
+}
+
+// setCashierProductAvailability marks the product as available, sold out, or hidden at the cashier's store.
+func (s *Server) setCashierProductAvailability(w http.ResponseWriter, r *http.Request) {
+	var request setProductAvailabilityRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	availability, ok := product.ParseAvailability(request.Availability)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid_availability", "Availability must be one of available, sold_out, or hidden")
+		return
+	}
+
+	assigned, ok := s.cashierStore(w, r)
+	if !ok {
+		return
+	}
+
+	existing, ok := s.productFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := s.products.SetStoreAvailability(r.Context(), assigned.ID, existing.ID, product.AvailabilityChange{
+		Availability: availability,
+		SoldOutUntil: request.SoldOutUntil,
+	})
+	if err != nil {
+		if writeProductError(w, err) {
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, storeAvailabilityResponse{
+		ProductID:            updated.ProductID,
+		availabilityResponse: newAvailabilityResponse(updated, time.Now()),
+		UpdatedAt:            updated.UpdatedAt,
+		UpdatedBy:            updated.UpdatedBy,
+	})
+}
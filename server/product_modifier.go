@@ -0,0 +1,420 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"coffee-chain-api/product"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type modifierOptionResponse struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	// PriceDelta is in IDR minor units, negative for options that cost less.
+	PriceDelta int64 `json:"price_delta"`
+	Default    bool  `json:"default"`
+}
+
+func newModifierOptionResponse(o product.ModifierOption) modifierOptionResponse {
+	return modifierOptionResponse{
+		ID:         o.ID,
+		Name:       o.Name,
+		PriceDelta: int64(o.PriceDelta),
+		Default:    o.Default,
+	}
+}
+
+type modifierGroupResponse struct {
+	ID            int64                    `json:"id"`
+	Name          string                   `json:"name"`
+	Required      bool                     `json:"required"`
+	MinSelections int                      `json:"min_selections"`
+	MaxSelections int                      `json:"max_selections"`
+	Options       []modifierOptionResponse `json:"options"`
+}
+
+func newModifierGroupResponse(g product.ModifierGroup) modifierGroupResponse {
+	response := modifierGroupResponse{
+		ID:            g.ID,
+		Name:          g.Name,
+		Required:      g.Required(),
+		MinSelections: g.MinSelections,
+		MaxSelections: g.MaxSelections,
+		Options:       make([]modifierOptionResponse, 0, len(g.Options)),
+	}
+	for _, option := range g.Options {
+		response.Options = append(response.Options, newModifierOptionResponse(option))
+	}
+
+	return response
+}
+
+func newModifierGroupResponses(groups []product.ModifierGroup) []modifierGroupResponse {
+	responses := make([]modifierGroupResponse, 0, len(groups))
+	for _, group := range groups {
+		responses = append(responses, newModifierGroupResponse(group))
+	}
+
+	return responses
+}
+
+type productDetailResponse struct {
+	productResponse
+	ModifierGroups []modifierGroupResponse `json:"modifier_groups"`
+}
+
+type managedProductDetailResponse struct {
+	managedProductResponse
+	ModifierGroups []modifierGroupResponse `json:"modifier_groups"`
+}
+
+// writeManagedProduct writes the product along with its modifier groups.
+func (s *Server) writeManagedProduct(w http.ResponseWriter, r *http.Request, statusCode int, managedProduct product.Product) {
+	groups, err := s.products.GetProductModifierGroups(r.Context(), managedProduct.ID)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	writeJSON(w, statusCode, managedProductDetailResponse{
+		managedProductResponse: newManagedProductResponse(managedProduct),
+		ModifierGroups:         newModifierGroupResponses(groups),
+	})
+}
+
+type quoteProductRequest struct {
+	// OptionIDs are the selected options, the groups left out take their default options.
+	OptionIDs []int64 `json:"option_ids"`
+	// StoreID is the store to order from, if it's known. It must be open, and the product available there.
+	StoreID int64 `json:"store_id"`
+}
+
+type quoteProductResponse struct {
+	ProductID int64                    `json:"product_id"`
+	Options   []modifierOptionResponse `json:"options"`
+	// UnitPrice is in IDR minor units.
+	UnitPrice int64 `json:"unit_price"`
+}
+
+// quoteProduct validates a selection of modifier options for the product and prices it, the same way the
+// product is priced when it's ordered. Given a store, the store must be open and the product available
+// there, and it's priced at the store.
+func (s *Server) quoteProduct(w http.ResponseWriter, r *http.Request) {
+	var request quoteProductRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	activeProduct, ok := s.productFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	if !activeProduct.Active {
+		writeError(w, http.StatusNotFound, "not_found", "Product not found")
+		return
+	}
+
+	if request.StoreID < 0 {
+		writeError(w, http.StatusBadRequest, "invalid_store_id", "Store ID must be a positive integer")
+		return
+	}
+	if request.StoreID > 0 {
+		activeStore, ok := s.requireActiveStore(w, r, request.StoreID)
+		if !ok {
+			return
+		}
+
+		now := time.Now()
+		err := activeStore.CheckOpen(now)
+		if err != nil {
+			writeError(w, http.StatusConflict, "store_not_open", "Store is not taking orders right now")
+			return
+		}
+
+		availabilities, err := s.products.GetStoreAvailabilities(r.Context(), request.StoreID, []int64{activeProduct.ID})
+		if err != nil {
+			writeInternalError(w, r, err)
+			return
+		}
+
+		err = product.CheckAvailability([]product.Line{{Product: activeProduct, Quantity: 1}}, availabilities, now)
+		if err != nil {
+			if writeUnavailableError(w, err) {
+				return
+			}
+
+			writeInternalError(w, r, err)
+			return
+		}
+	}
+
+	groups, err := s.products.GetProductModifierGroups(r.Context(), activeProduct.ID)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	prices, err := s.products.GetStorePrices(r.Context(), request.StoreID)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	configuration, err := product.Configure(activeProduct, groups, request.OptionIDs, prices)
+	if err != nil {
+		if writeSelectionError(w, err) {
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	response := quoteProductResponse{
+		ProductID: activeProduct.ID,
+		Options:   make([]modifierOptionResponse, 0, len(configuration.Options)),
+		UnitPrice: int64(configuration.UnitPrice),
+	}
+	for _, option := range configuration.Options {
+		response.Options = append(response.Options, newModifierOptionResponse(option))
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+type selectionErrorDetails struct {
+	GroupID  int64 `json:"group_id,omitempty"`
+	OptionID int64 `json:"option_id,omitempty"`
+}
+
+// writeSelectionError writes the group or option at fault if err is a *product.SelectionError, and
+// returns whether it did.
+func writeSelectionError(w http.ResponseWriter, err error) bool {
+	var selectionError *product.SelectionError
+	if !errors.As(err, &selectionError) {
+		return false
+	}
+
+	writeJSON(w, http.StatusUnprocessableEntity, errorResponse{
+		Code:    "invalid_selection",
+		Message: selectionError.Error(),
+		Details: selectionErrorDetails{GroupID: selectionError.GroupID, OptionID: selectionError.OptionID},
+	})
+	return true
+}
+
+type managedModifierGroupResponse struct {
+	modifierGroupResponse
+	CreatedAt time.Time `json:"created_at"`
+	CreatedBy string    `json:"created_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UpdatedBy string    `json:"updated_by"`
+}
+
+func newManagedModifierGroupResponse(g product.ModifierGroup) managedModifierGroupResponse {
+	return managedModifierGroupResponse{
+		modifierGroupResponse: newModifierGroupResponse(g),
+		CreatedAt:             g.CreatedAt,
+		CreatedBy:             g.CreatedBy,
+		UpdatedAt:             g.UpdatedAt,
+		UpdatedBy:             g.UpdatedBy,
+	}
+}
+
+type listModifierGroupsResponse struct {
+	ModifierGroups []managedModifierGroupResponse `json:"modifier_groups"`
+}
+
+func (s *Server) listModifierGroups(w http.ResponseWriter, r *http.Request) {
+	groups, err := s.products.ListModifierGroups(r.Context())
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	response := listModifierGroupsResponse{ModifierGroups: make([]managedModifierGroupResponse, 0, len(groups))}
+	for _, group := range groups {
+		response.ModifierGroups = append(response.ModifierGroups, newManagedModifierGroupResponse(group))
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) getModifierGroup(w http.ResponseWriter, r *http.Request) {
+	groupId, ok := modifierGroupIDFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	group, err := s.products.GetModifierGroup(r.Context(), groupId)
+	if err != nil {
+		if errors.Is(err, product.ErrModifierGroupNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "Modifier group not found")
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newManagedModifierGroupResponse(group))
+}
+
+type modifierOptionRequest struct {
+	// ID is the option to update, leave it empty for a new option.
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	// PriceDelta is in IDR minor units, negative for options that cost less.
+	PriceDelta int64 `json:"price_delta"`
+	Default    bool  `json:"default"`
+}
+
+type modifierGroupRequest struct {
+	Name          string `json:"name"`
+	MinSelections int    `json:"min_selections"`
+	MaxSelections int    `json:"max_selections"`
+	// Options replaces every option of the group, the ones left out are removed.
+	Options []modifierOptionRequest `json:"options"`
+}
+
+func (request modifierGroupRequest) rawModifierGroup() product.RawModifierGroup {
+	rawGroup := product.RawModifierGroup{
+		Name:          request.Name,
+		MinSelections: request.MinSelections,
+		MaxSelections: request.MaxSelections,
+		Options:       make([]product.RawModifierOption, 0, len(request.Options)),
+	}
+	for _, option := range request.Options {
+		rawGroup.Options = append(rawGroup.Options, product.RawModifierOption{
+			ID:         option.ID,
+			Name:       option.Name,
+			PriceDelta: product.Price(option.PriceDelta),
+			Default:    option.Default,
+		})
+	}
+
+	return rawGroup
+}
+
+func (s *Server) createModifierGroup(w http.ResponseWriter, r *http.Request) {
+	var request modifierGroupRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	created, err := s.products.InsertModifierGroup(r.Context(), request.rawModifierGroup())
+	if err != nil {
+		if writeProductError(w, err) {
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newManagedModifierGroupResponse(created))
+}
+
+// updateModifierGroup replaces the group and its options, for every product it's attached to.
+func (s *Server) updateModifierGroup(w http.ResponseWriter, r *http.Request) {
+	var request modifierGroupRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	groupId, ok := modifierGroupIDFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := s.products.UpdateModifierGroup(r.Context(), groupId, request.rawModifierGroup())
+	if err != nil {
+		if errors.Is(err, product.ErrModifierGroupNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "Modifier group not found")
+			return
+		}
+		if writeProductError(w, err) {
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newManagedModifierGroupResponse(updated))
+}
+
+// deleteModifierGroup removes a group that is not attached to any product anymore.
+func (s *Server) deleteModifierGroup(w http.ResponseWriter, r *http.Request) {
+	groupId, ok := modifierGroupIDFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	err := s.products.DeleteModifierGroup(r.Context(), groupId)
+	if err != nil {
+		if errors.Is(err, product.ErrModifierGroupNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "Modifier group not found")
+			return
+		}
+		if errors.Is(err, product.ErrModifierGroupInUse) {
+			writeError(w, http.StatusConflict, "modifier_group_in_use", "Detach the modifier group from its products first")
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type setProductModifierGroupsRequest struct {
+	// ModifierGroupIDs replaces the groups attached to the product, in the order they're shown.
+	ModifierGroupIDs []int64 `json:"modifier_group_ids"`
+}
+
+func (s *Server) setProductModifierGroups(w http.ResponseWriter, r *http.Request) {
+	var request setProductModifierGroupsRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	managedProduct, ok := s.productFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	err := s.products.SetProductModifierGroups(r.Context(), managedProduct.ID, request.ModifierGroupIDs)
+	if err != nil {
+		if errors.Is(err, product.ErrModifierGroupNotFound) {
+			writeError(w, http.StatusBadRequest, "invalid_modifier_group_ids", "Modifier group does not exist")
+			return
+		}
+		if writeProductError(w, err) {
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	s.writeManagedProduct(w, r, http.StatusOK, managedProduct)
+}
+
+// modifierGroupIDFromURL parses the "id" URL parameter as a modifier group ID. It writes the error response
+// and returns false if it's not a valid ID.
+func modifierGroupIDFromURL(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusNotFound, "not_found", "Modifier group not found")
+		return 0, false
+	}
+
+	return id, true
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"coffee-chain-api/product"
+)
+
+// searchProducts searches the active products by their name and description, the most relevant first.
+// Given a store, the products hidden there are left out and the others include their availability and
+// price at the store, which is also the price the price range applies to.
+func (s *Server) searchProducts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := product.SearchFilter{Query: query.Get("q")}
+
+	if value := query.Get("category_id"); value != "" {
+		categoryId, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || categoryId <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid_category_id", "Category ID must be a positive integer")
+			return
+		}
+		filter.CategoryID = categoryId
+	}
+	if value := query.Get("min_price"); value != "" {
+		minPrice, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_min_price", "Minimum price must be an integer in IDR minor units")
+			return
+		}
+		filter.MinPrice = product.Price(minPrice)
+	}
+	if value := query.Get("max_price"); value != "" {
+		maxPrice, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_max_price", "Maximum price must be an integer in IDR minor units")
+			return
+		}
+		filter.MaxPrice = product.Price(maxPrice)
+	}
+	if value := query.Get("available"); value != "" {
+		available, err := strconv.ParseBool(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_available", "Available must be true or false")
+			return
+		}
+		filter.AvailableOnly = available
+	}
+	if value := query.Get("dietary_tags"); value != "" {
+		dietaryTags, ok := product.ParseDietaryTags(strings.Split(value, ","))
+		if !ok {
+			writeError(w, http.StatusBadRequest, "invalid_dietary_tags", "Dietary tags must be a comma-separated list of known tags")
+			return
+		}
+		filter.DietaryTags = dietaryTags
+	}
+	if value := query.Get("cursor"); value != "" {
+		cursor, ok := product.ParseSearchCursor(value)
+		if !ok {
+			writeError(w, http.StatusBadRequest, "invalid_cursor", "Cursor is invalid")
+			return
+		}
+		filter.Cursor = cursor
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid_limit", "Limit must be a positive integer")
+			return
+		}
+		filter.Limit = limit
+	}
+
+	storeId, ok := s.storeIDFromQuery(w, r)
+	if !ok {
+		return
+	}
+	filter.StoreID = storeId
+
+	result, err := s.products.Search(r.Context(), filter)
+	if err != nil {
+		if writeProductError(w, err) {
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	prices, err := s.products.GetStorePrices(r.Context(), storeId)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	response := listProductsResponse{Products: make([]productResponse, 0, len(result.Products))}
+	for _, activeProduct := range result.Products {
+		resolved, _ := prices.Resolve(activeProduct, nil)
+		response.Products = append(response.Products, newProductResponse(resolved))
+	}
+	if !result.NextCursor.IsZero() {
+		response.NextCursor = result.NextCursor.String()
+	}
+
+	if storeId > 0 {
+		err = s.setAvailabilities(r, storeId, response.Products)
+		if err != nil {
+			writeInternalError(w, r, err)
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
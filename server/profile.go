@@ -0,0 +1,178 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"coffee-chain-api/account"
+	"coffee-chain-api/account/accountstore"
+	"coffee-chain-api/account/securityevent"
+)
+
+type accountResponse struct {
+	account.Profile
+	Type    string `json:"type"`
+	StoreID int64  `json:"store_id,omitempty"`
+}
+
+func newAccountResponse(userAccount account.Account) accountResponse {
+	return accountResponse{
+		Profile: userAccount.GetProfile(),
+		Type:    userAccount.GetType().String(),
+		StoreID: userAccount.StoreIdentifier(),
+	}
+}
+
+func (s *Server) self(w http.ResponseWriter, r *http.Request) {
+	// The session holds a snapshot of the account from when it was created, acquire the latest one.
+	userAccount, err := s.accountStore.GetByID(r.Context(), accountFromContext(r.Context()).GetProfile().ID)
+	if err != nil {
+		if errors.Is(err, accountstore.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "Account not found")
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newAccountResponse(userAccount))
+}
+
+// modifySelfRequest uses pointers to tell apart the fields that are absent from the ones that are set
+// into their zero value.
+type modifySelfRequest struct {
+	Name                    *string                               `json:"name"`
+	Gender                  *account.Gender                       `json:"gender"`
+	NotificationPreferences *modifyNotificationPreferencesRequest `json:"notification_preferences"`
+}
+
+type modifyNotificationPreferencesRequest struct {
+	Push  *modifyNotificationCategoriesRequest `json:"push"`
+	Email *modifyNotificationCategoriesRequest `json:"email"`
+}
+
+type modifyNotificationCategoriesRequest struct {
+	Promotional   *bool `json:"promotional"`
+	Transactional *bool `json:"transactional"`
+}
+
+func (s *Server) modifySelf(w http.ResponseWriter, r *http.Request) {
+	var request modifySelfRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	var update accountstore.PartialUpdate
+	if request.Name != nil {
+		name := strings.TrimSpace(*request.Name)
+		if name == "" || utf8.RuneCountInString(name) > 255 {
+			writeError(w, http.StatusBadRequest, "invalid_name", "Name must be between 1 and 255 characters")
+			return
+		}
+
+		update.Fields |= accountstore.UpdateFieldName
+		update.Name = name
+	}
+	if request.Gender != nil {
+		if *request.Gender > account.GenderOthers {
+			writeError(w, http.StatusBadRequest, "invalid_gender", "Gender is invalid")
+			return
+		}
+
+		update.Fields |= accountstore.UpdateFieldGender
+		update.Gender = *request.Gender
+	}
+	if preferences := request.NotificationPreferences; preferences != nil {
+		if preferences.Push != nil {
+			if preferences.Push.Promotional != nil {
+				update.Fields |= accountstore.UpdateFieldNotifyPushPromotional
+				update.NotificationPreferences.Push.Promotional = *preferences.Push.Promotional
+			}
+			if preferences.Push.Transactional != nil {
+				update.Fields |= accountstore.UpdateFieldNotifyPushTransactional
+				update.NotificationPreferences.Push.Transactional = *preferences.Push.Transactional
+			}
+		}
+		if preferences.Email != nil {
+			if preferences.Email.Promotional != nil {
+				update.Fields |= accountstore.UpdateFieldNotifyEmailPromotional
+				update.NotificationPreferences.Email.Promotional = *preferences.Email.Promotional
+			}
+			if preferences.Email.Transactional != nil {
+				update.Fields |= accountstore.UpdateFieldNotifyEmailTransactional
+				update.NotificationPreferences.Email.Transactional = *preferences.Email.Transactional
+			}
+		}
+	}
+
+	userAccount, err := s.accountStore.UpdatePartial(r.Context(), accountFromContext(r.Context()), update)
+	if err != nil {
+		if errors.Is(err, accountstore.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "Account not found")
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newAccountResponse(userAccount))
+}
+
+type deleteSelfRequest struct {
+	CurrentPassword string `json:"current_password"`
+}
+
+func (s *Server) deleteSelf(w http.ResponseWriter, r *http.Request) {
+	var request deleteSelfRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	userAccount := accountFromContext(r.Context())
+
+	if !s.validateCurrentPassword(w, r, request.CurrentPassword) {
+		return
+	}
+
+	err := s.accountStore.DeleteByEmail(r.Context(), userAccount.GetProfile().Email)
+	if err != nil && !errors.Is(err, accountstore.ErrNotFound) {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	s.recorder.Record(r.Context(), userAccount.GetProfile().ID, securityevent.TypeAccountDelete, securityevent.OutcomeSuccess)
+
+	err = s.authentication.RevokeSessions(r.Context(), userAccount.GetProfile().ID)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) exportSelf(w http.ResponseWriter, r *http.Request) {
+	userAccount, err := s.accountStore.GetByID(r.Context(), accountFromContext(r.Context()).GetProfile().ID)
+	if err != nil {
+		if errors.Is(err, accountstore.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "Account not found")
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	archive, err := s.dataExporter.Export(r.Context(), userAccount)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="account-export.json"`)
+	writeJSON(w, http.StatusOK, archive)
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+
+	"coffee-chain-api/account/accountstore"
+	"coffee-chain-api/account/password"
+)
+
+type registerRequest struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// register creates a customer account. The password is checked against the password policy, with the
+// name and email address as personal inputs.
+func (s *Server) register(w http.ResponseWriter, r *http.Request) {
+	var request registerRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	name := strings.TrimSpace(request.Name)
+	if name == "" || utf8.RuneCountInString(name) > 255 {
+		writeError(w, http.StatusBadRequest, "invalid_name", "Name must be between 1 and 255 characters")
+		return
+	}
+
+	address, err := mail.ParseAddress(request.Email)
+	if err != nil || address.Name != "" {
+		writeError(w, http.StatusBadRequest, "invalid_email", "Email address is invalid")
+		return
+	}
+
+	err = s.registration.Register(r.Context(), accountstore.RawAccount{
+		Name:          name,
+		Email:         address.Address,
+		PlainPassword: request.Password,
+	})
+	if err != nil {
+		if writePasswordPolicyError(w, err) {
+			return
+		}
+		if errors.Is(err, accountstore.ErrDuplicateEntry) {
+			writeError(w, http.StatusConflict, "email_taken", "Email address is used by another account")
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	customer, err := s.accountStore.GetByEmail(r.Context(), address.Address)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newAccountResponse(customer))
+}
+
+// writePasswordPolicyError writes the password policy violations if err is a *password.PolicyError,
+// and returns whether it did.
+func writePasswordPolicyError(w http.ResponseWriter, err error) bool {
+	var policyError *password.PolicyError
+	if !errors.As(err, &policyError) {
+		return false
+	}
+
+	writeJSON(w, http.StatusUnprocessableEntity, errorResponse{
+		Code:    "password_rejected",
+		Message: "Password does not satisfy the password policy",
+		Details: policyError.Violations,
+	})
+	return true
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maximumRequestBodySize limits the size of JSON request bodies.
+const maximumRequestBodySize = 1 << 20
+
+type errorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details any    `json:"details,omitempty"`
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, body any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+
+	err := json.NewEncoder(w).Encode(body)
+	if err != nil {
+		log.Error().Err(err).Msg("writing json response")
+	}
+}
+
+func writeError(w http.ResponseWriter, statusCode int, code string, message string) {
+	writeJSON(w, statusCode, errorResponse{Code: code, Message: message})
+}
+
+func writeInternalError(w http.ResponseWriter, r *http.Request, err error) {
+	log.Error().Err(err).Str("method", r.Method).Str("path", r.URL.Path).Msg("handling request")
+	writeError(w, http.StatusInternalServerError, "internal_error", "Internal server error")
+}
+
+// decodeJSON decodes the request body into destination. If it returns false, an error response
+// has been written and the handler should return immediately.
+func decodeJSON(w http.ResponseWriter, r *http.Request, destination any) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maximumRequestBodySize))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(destination)
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			writeError(w, http.StatusRequestEntityTooLarge, "request_too_large", "Request body is too large")
+			return false
+		}
+
+		if errors.Is(err, io.EOF) {
+			writeError(w, http.StatusBadRequest, "invalid_request", "Request body is empty")
+			return false
+		}
+
+		writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("Invalid request body: %s", err.Error()))
+		return false
+	}
+
+	return true
+}
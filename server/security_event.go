@@ -0,0 +1,128 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"coffee-chain-api/account/securityevent"
+)
+
+type securityEventResponse struct {
+	ID int64 `json:"id"`
+	// AccountID is omitted if the event does not belong to any account.
+	AccountID int64     `json:"account_id,omitempty"`
+	Type      string    `json:"type"`
+	Outcome   string    `json:"outcome"`
+	IP        string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	Actor     string    `json:"actor"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type listSecurityEventsResponse struct {
+	Events     []securityEventResponse `json:"events"`
+	NextCursor string                  `json:"next_cursor,omitempty"`
+}
+
+// listOwnSecurityEvents lists the events of the authenticated account, so the owner can notice logins and
+// changes that were not theirs.
+func (s *Server) listOwnSecurityEvents(w http.ResponseWriter, r *http.Request) {
+	var filter securityevent.ListFilter
+	if !parseSecurityEventPagination(w, r, &filter) {
+		return
+	}
+	filter.AccountID = accountFromContext(r.Context()).GetProfile().ID
+
+	s.writeSecurityEvents(w, r, filter)
+}
+
+func (s *Server) listSecurityEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var filter securityevent.ListFilter
+	if value := query.Get("account_id"); value != "" {
+		accountId, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || accountId <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid_account_id", "Account ID must be a positive integer")
+			return
+		}
+		filter.AccountID = accountId
+	}
+	filter.Type = securityevent.Type(query.Get("type"))
+	filter.Outcome = securityevent.Outcome(query.Get("outcome"))
+	filter.IP = query.Get("ip_address")
+	if value := query.Get("created_after"); value != "" {
+		createdAfter, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_created_after", "Created after must be an RFC 3339 timestamp")
+			return
+		}
+		filter.CreatedAfter = createdAfter
+	}
+	if value := query.Get("created_before"); value != "" {
+		createdBefore, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_created_before", "Created before must be an RFC 3339 timestamp")
+			return
+		}
+		filter.CreatedBefore = createdBefore
+	}
+	if !parseSecurityEventPagination(w, r, &filter) {
+		return
+	}
+
+	s.writeSecurityEvents(w, r, filter)
+}
+
+// parseSecurityEventPagination reads the cursor and limit query parameters into the filter. It writes the error
+// response and returns false if either is invalid.
+func parseSecurityEventPagination(w http.ResponseWriter, r *http.Request, filter *securityevent.ListFilter) bool {
+	query := r.URL.Query()
+
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || cursor < 0 {
+			writeError(w, http.StatusBadRequest, "invalid_cursor", "Cursor is invalid")
+			return false
+		}
+		filter.Cursor = cursor
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid_limit", "Limit must be a positive integer")
+			return false
+		}
+		filter.Limit = limit
+	}
+
+	return true
+}
+
+func (s *Server) writeSecurityEvents(w http.ResponseWriter, r *http.Request, filter securityevent.ListFilter) {
+	result, err := s.securityEvents.List(r.Context(), filter)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	response := listSecurityEventsResponse{Events: make([]securityEventResponse, 0, len(result.Events))}
+	for _, event := range result.Events {
+		response.Events = append(response.Events, securityEventResponse{
+			ID:        event.ID,
+			AccountID: event.AccountID,
+			Type:      string(event.Type),
+			Outcome:   string(event.Outcome),
+			IP:        event.IP,
+			UserAgent: event.UserAgent,
+			Actor:     event.Actor,
+			CreatedAt: event.CreatedAt,
+		})
+	}
+	if result.NextCursor > 0 {
+		response.NextCursor = strconv.FormatInt(result.NextCursor, 10)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
@@ -2,36 +2,259 @@ package server
 
 import (
 	"crypto/tls"
+	"fmt"
 	"net"
 	"net/http"
 	"time"
 
+	"coffee-chain-api/account"
+	"coffee-chain-api/account/accountstore"
+	"coffee-chain-api/account/authentication"
+	"coffee-chain-api/account/dataexport"
+	"coffee-chain-api/account/emailchange"
+	"coffee-chain-api/account/loginthrottle"
+	"coffee-chain-api/account/password"
+	"coffee-chain-api/account/passwordreset"
+	"coffee-chain-api/account/registration"
+	"coffee-chain-api/account/securityevent"
+	"coffee-chain-api/account/totp"
+	"coffee-chain-api/order"
+	"coffee-chain-api/product"
+	"coffee-chain-api/store"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
-type Server struct{}
+type Server struct {
+	accountStore   accountstore.AccountStore
+	authentication authentication.AuthenticatorAndValidator
+	dataExporter   *dataexport.Exporter
+	emailChange    *emailchange.Repository
+	passwordPolicy *password.Policy
+	passwordReset  *passwordreset.Repository
+	resetThrottle  *loginthrottle.Throttler
+	resetRequests  chan struct{}
+	registration   *registration.Repository
+	totp           *totp.Repository
+	mfaPolicy      *totp.Policy
+	securityEvents securityevent.Store
+	recorder       *securityevent.Recorder
+	stores         store.StoreRepository
+	products       product.ProductRepository
+	orders         order.OrderRepository
+}
 
 type Config struct {
 	Hostname  string
 	Port      string
 	TLSConfig *tls.Config
+
+	AccountStore   accountstore.AccountStore
+	Authentication authentication.AuthenticatorAndValidator
+	// DataExporter must have order.NewExportSection under dataexport.SectionOrders, or the orders are
+	// exported as an empty list.
+	DataExporter   *dataexport.Exporter
+	EmailChange    *emailchange.Repository
+	PasswordPolicy *password.Policy
+	PasswordReset  *passwordreset.Repository
+	// ResetThrottle counts every password reset request, with passwordreset.DefaultThrottleAccountRule and
+	// passwordreset.DefaultThrottleIPRule. Its store must not be shared with the login throttler, since
+	// both use the same keys.
+	ResetThrottle  *loginthrottle.Throttler
+	Registration   *registration.Repository
+	TOTP           *totp.Repository
+	MFAPolicy      *totp.Policy
+	SecurityEvents securityevent.Store
+	Stores         store.StoreRepository
+	Products       product.ProductRepository
+	Orders         order.OrderRepository
 }
 
 func NewServer(config Config) (*http.Server, error) {
+	if config.AccountStore == nil {
+		return nil, fmt.Errorf("AccountStore is nil")
+	}
+	if config.Authentication == nil {
+		return nil, fmt.Errorf("Authentication is nil")
+	}
+	if config.DataExporter == nil {
+		return nil, fmt.Errorf("DataExporter is nil")
+	}
+	if config.EmailChange == nil {
+		return nil, fmt.Errorf("EmailChange is nil")
+	}
+	if config.PasswordPolicy == nil {
+		return nil, fmt.Errorf("PasswordPolicy is nil")
+	}
+	if config.PasswordReset == nil {
+		return nil, fmt.Errorf("PasswordReset is nil")
+	}
+	if config.ResetThrottle == nil {
+		return nil, fmt.Errorf("ResetThrottle is nil")
+	}
+	if config.Registration == nil {
+		return nil, fmt.Errorf("Registration is nil")
+	}
+	if config.TOTP == nil {
+		return nil, fmt.Errorf("TOTP is nil")
+	}
+	if config.MFAPolicy == nil {
+		return nil, fmt.Errorf("MFAPolicy is nil")
+	}
+	if config.SecurityEvents == nil {
+		return nil, fmt.Errorf("SecurityEvents is nil")
+	}
+	if config.Stores == nil {
+		return nil, fmt.Errorf("Stores is nil")
+	}
+	if config.Products == nil {
+		return nil, fmt.Errorf("Products is nil")
+	}
+	if config.Orders == nil {
+		return nil, fmt.Errorf("Orders is nil")
+	}
+
+	recorder, err := securityevent.NewRecorder(config.SecurityEvents)
+	if err != nil {
+		return nil, fmt.Errorf("creating security event recorder: %w", err)
+	}
+
+	s := &Server{
+		accountStore:   config.AccountStore,
+		authentication: config.Authentication,
+		dataExporter:   config.DataExporter,
+		emailChange:    config.EmailChange,
+		passwordPolicy: config.PasswordPolicy,
+		passwordReset:  config.PasswordReset,
+		resetThrottle:  config.ResetThrottle,
+		resetRequests:  make(chan struct{}, maximumPendingResetRequests),
+		registration:   config.Registration,
+		totp:           config.TOTP,
+		mfaPolicy:      config.MFAPolicy,
+		securityEvents: config.SecurityEvents,
+		recorder:       recorder,
+		stores:         config.Stores,
+		products:       config.Products,
+		orders:         config.Orders,
+	}
+
 	router := chi.NewRouter()
 
 	router.Use(middleware.CleanPath)
 	router.Use(middleware.Heartbeat("/health"))
 	router.Use(middleware.RealIP)
+	router.Use(clientInfo)
 
 	// Account endpoints
-	router.Post("/account/login", noopHandler)          // Your usual login
-	router.Post("/account/logout", noopHandler)         // You're an idiot
-	router.Post("/account/register", noopHandler)       // You're dumb
+	router.Post("/account/login", s.login)              // Your usual login, throttled after failed attempts
+	router.Post("/account/login/mfa", s.loginWithMFA)   // Second step of the login for accounts with two-factor
+	router.Post("/account/register", s.register)        // Create a customer account
 	router.Post("/account/validate-email", noopHandler) // Validate email by code send to email
-	router.Get("/account/self", noopHandler)            // Get current user's account data, guard by Bearer auth
-	router.Post("/account/modify-self", noopHandler)    // Modify account data
+
+	router.Post("/account/password/forgot", s.forgotPassword) // Email a password reset link
+	router.Post("/account/password/reset", s.resetPassword)   // Set a new password with the emailed reset token
+
+	router.Group(func(r chi.Router) {
+		r.Use(s.authenticate)
+
+		// Reachable before two-factor is enabled, so the accounts that require it can enable it
+		r.Post("/account/logout", s.logout)                        // You're an idiot
+		r.Get("/account/self", s.self)                             // Get current user's account data
+		r.Get("/account/security-events", s.listOwnSecurityEvents) // Recent logins and account changes, to spot what wasn't you
+		r.Post("/account/mfa/totp/enroll", s.enrollTOTP)           // Start two-factor enrollment, returns the provisioning URI
+		r.Post("/account/mfa/totp/confirm", s.confirmTOTP)         // Enable two-factor with the first code, returns recovery codes
+
+		r.Group(func(r chi.Router) {
+			r.Use(s.requireMFAEnrollment)
+
+			r.Patch("/account/modify-self", s.modifySelf)                 // Modify account data, only the fields present are updated
+			r.Post("/account/delete-self", s.deleteSelf)                  // Delete account, anonymised after a grace period
+			r.Get("/account/export", s.exportSelf)                        // Download every data we hold about the account
+			r.Post("/account/change-password", s.changePassword)          // Change password, revokes every other session
+			r.Post("/account/change-email", s.changeEmail)                // Send a verification code to the new email address
+			r.Post("/account/change-email/confirm", s.confirmEmailChange) // Swap the email address, revokes every session
+			r.Post("/account/mfa/totp/disable", s.disableTOTP)            // Disable two-factor with a code
+		})
+	})
+
+	// Store endpoints
+	router.Get("/stores", s.listStores)    // List the active stores, nearest first if lat and lng are given
+	router.Get("/stores/{id}", s.getStore) // Get an active store
+
+	// Product endpoints
+	router.Get("/products", s.listProducts)              // Browse the active products, at a store if store_id is given
+	router.Get("/products/categories", s.listCategories) // List the categories in their order on the menu
+	router.Get("/products/search", s.searchProducts)     // Search the active products by text, price, availability, and dietary tags
+	router.Get("/products/{id}", s.getProduct)           // Get an active product with its modifier groups
+	router.Post("/products/{id}/quote", s.quoteProduct)  // Validate a selection of modifiers and price it
+
+	// Order endpoints
+	router.Group(func(r chi.Router) {
+		r.Use(s.authenticate)
+		r.Use(requireAccountType(account.TypeCustomer))
+		r.Use(s.requireMFAEnrollment)
+
+		r.Post("/orders", s.createOrder) // Place an order at a store, priced and checked against the latest catalog
+	})
+
+	// Cashier endpoints
+	router.Group(func(r chi.Router) {
+		r.Use(s.authenticate)
+		r.Use(requireAccountType(account.TypeMerchantCashier))
+		r.Use(s.requireMFAEnrollment)
+
+		r.Get("/cashier/store", s.getCashierStore)            // Get the store the cashier is assigned to
+		r.Put("/cashier/store/state", s.setCashierStoreState) // Open, close, or pause the cashier's store
+
+		r.Get("/cashier/products", s.listCashierProducts)                             // List the products with their availability at the cashier's store
+		r.Put("/cashier/products/{id}/availability", s.setCashierProductAvailability) // Mark a product available, sold out, or hidden at the store
+	})
+
+	// Management endpoints
+	router.Group(func(r chi.Router) {
+		r.Use(s.authenticate)
+		r.Use(requireAccountType(account.TypeManagement))
+		r.Use(s.requireMFAEnrollment)
+
+		r.Get("/management/accounts", s.listAccounts)              // List and search accounts
+		r.Get("/management/security-events", s.listSecurityEvents) // Query the security events of every account
+
+		r.Post("/management/cashiers", s.createCashier)                     // Create a merchant cashier and email an invitation
+		r.Post("/management/cashiers/{id}/invite", s.inviteCashier)         // Send the invitation again
+		r.Put("/management/cashiers/{id}/store", s.assignCashierStore)      // Reassign to another store
+		r.Post("/management/cashiers/{id}/deactivate", s.deactivateCashier) // Disable the account and revoke its sessions
+
+		r.Get("/management/stores", s.listManagedStores)    // List every store, including the inactive ones
+		r.Post("/management/stores", s.createStore)         // Create a physical store
+		r.Get("/management/stores/{id}", s.getManagedStore) // Get a store
+		r.Put("/management/stores/{id}", s.updateStore)     // Replace a store's data
+		r.Delete("/management/stores/{id}", s.deleteStore)  // Remove a store without cashiers
+
+		r.Put("/management/stores/{id}/opening-hours", s.setOpeningHours)                         // Replace the weekly opening hours
+		r.Put("/management/stores/{id}/schedule-exceptions/{date}", s.setScheduleException)       // Set the hours of a holiday or special day
+		r.Delete("/management/stores/{id}/schedule-exceptions/{date}", s.deleteScheduleException) // Go back to the weekly hours on the date
+		r.Get("/management/stores/{id}/prices", s.getStorePrices)                                 // List the prices the store overrides
+		r.Put("/management/stores/{id}/prices", s.setStorePrices)                                 // Replace every price the store overrides
+
+		r.Get("/management/products", s.listManagedProducts)    // List every product, including the inactive ones
+		r.Post("/management/products", s.createProduct)         // Create a base product with its price
+		r.Get("/management/products/{id}", s.getManagedProduct) // Get a product
+		r.Put("/management/products/{id}", s.updateProduct)     // Replace a product's data
+		r.Delete("/management/products/{id}", s.deleteProduct)  // Remove a product from the menu
+
+		r.Get("/management/products/categories", s.listManagedCategories)  // List the product categories
+		r.Post("/management/products/categories", s.createCategory)        // Create a product category
+		r.Put("/management/products/categories/{id}", s.updateCategory)    // Rename or reorder a category
+		r.Delete("/management/products/categories/{id}", s.deleteCategory) // Remove a category without products
+
+		r.Put("/management/products/{id}/modifier-groups", s.setProductModifierGroups) // Attach modifier groups to a product, in order
+		r.Get("/management/modifier-groups", s.listModifierGroups)                     // List the modifier groups with their options
+		r.Post("/management/modifier-groups", s.createModifierGroup)                   // Create a group such as sugar level or extra espresso
+		r.Get("/management/modifier-groups/{id}", s.getModifierGroup)                  // Get a modifier group
+		r.Put("/management/modifier-groups/{id}", s.updateModifierGroup)               // Replace a group and its options
+		r.Delete("/management/modifier-groups/{id}", s.deleteModifierGroup)            // Remove a group that is not attached anymore
+	})
 
 	server := &http.Server{
 		Addr:              net.JoinHostPort(config.Hostname, config.Port),
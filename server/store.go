@@ -0,0 +1,264 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"coffee-chain-api/store"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type storeResponse struct {
+	ID        int64   `json:"id"`
+	Name      string  `json:"name"`
+	Address   string  `json:"address"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Phone     string  `json:"phone,omitempty"`
+	Timezone  string  `json:"timezone"`
+	// State is the effective state at the time of the response, with expired pauses resumed.
+	State string `json:"state"`
+	// PauseReason and ResumeAt are only present while the store is paused.
+	PauseReason string     `json:"pause_reason,omitempty"`
+	ResumeAt    *time.Time `json:"resume_at,omitempty"`
+	IsOpenNow   bool       `json:"is_open_now"`
+	// NextOpening is omitted if the store is open, or it does not open within a month.
+	NextOpening *time.Time `json:"next_opening,omitempty"`
+}
+
+func newStoreResponse(s store.Store, schedule store.Schedule) storeResponse {
+	now := time.Now()
+
+	response := storeResponse{
+		ID:        s.ID,
+		Name:      s.Name,
+		Address:   s.Address,
+		Latitude:  s.Latitude,
+		Longitude: s.Longitude,
+		Phone:     s.Phone,
+		Timezone:  s.Timezone,
+		State:     s.StateAt(now).String(),
+		IsOpenNow: s.CheckOpen(now) == nil,
+	}
+
+	if s.StateAt(now) == store.StatePaused {
+		response.PauseReason = s.PauseReason
+		response.ResumeAt = &s.ResumeAt
+	}
+
+	if nextOpening, ok := s.NextOpening(schedule, now); ok {
+		response.NextOpening = &nextOpening
+	}
+
+	return response
+}
+
+type storeDetailResponse struct {
+	storeResponse
+	storeScheduleResponse
+}
+
+type listStoresResponse struct {
+	Stores     []storeResponse `json:"stores"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// listStores lists the active stores for the customers, or the nearest ones if the coordinates are given.
+func (s *Server) listStores(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Has("lat") || query.Has("lng") {
+		s.listNearbyStores(w, r)
+		return
+	}
+
+	filter := store.ListFilter{Status: store.StatusActive}
+	if !parseStoreListFilter(w, r, &filter) {
+		return
+	}
+
+	result, err := s.stores.List(r.Context(), filter)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	schedules, err := s.storeSchedules(r, result.Stores...)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	response := listStoresResponse{Stores: make([]storeResponse, 0, len(result.Stores))}
+	for _, activeStore := range result.Stores {
+		response.Stores = append(response.Stores, newStoreResponse(activeStore, schedules[activeStore.ID]))
+	}
+	if result.NextCursor > 0 {
+		response.NextCursor = strconv.FormatInt(result.NextCursor, 10)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+type nearbyStoreResponse struct {
+	storeResponse
+	// DistanceMeters is the great-circle distance from the searched coordinates, rounded to the meter.
+	DistanceMeters int64 `json:"distance_meters"`
+}
+
+type listNearbyStoresResponse struct {
+	Stores     []nearbyStoreResponse `json:"stores"`
+	NextCursor string                `json:"next_cursor,omitempty"`
+}
+
+// listNearbyStores lists the active stores within the radius of the coordinates, nearest first.
+func (s *Server) listNearbyStores(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := store.NearbyFilter{Status: store.StatusActive, Name: query.Get("q")}
+
+	latitude, err := strconv.ParseFloat(query.Get("lat"), 64)
+	if err != nil || math.IsNaN(latitude) || latitude < -90 || latitude > 90 {
+		writeError(w, http.StatusBadRequest, "invalid_lat", "Latitude must be between -90 and 90")
+		return
+	}
+	filter.Latitude = latitude
+
+	longitude, err := strconv.ParseFloat(query.Get("lng"), 64)
+	if err != nil || math.IsNaN(longitude) || longitude < -180 || longitude > 180 {
+		writeError(w, http.StatusBadRequest, "invalid_lng", "Longitude must be between -180 and 180")
+		return
+	}
+	filter.Longitude = longitude
+
+	if value := query.Get("radius"); value != "" {
+		radius, err := strconv.ParseFloat(value, 64)
+		if err != nil || !(radius > 0 && radius <= store.MaximumNearbyRadius) {
+			writeError(w, http.StatusBadRequest, "invalid_radius", fmt.Sprintf("Radius must be a number of meters up to %d", store.MaximumNearbyRadius))
+			return
+		}
+		filter.RadiusMeters = radius
+	}
+	if value := query.Get("cursor"); value != "" {
+		cursor, ok := store.ParseNearbyCursor(value)
+		if !ok {
+			writeError(w, http.StatusBadRequest, "invalid_cursor", "Cursor is invalid")
+			return
+		}
+		filter.Cursor = cursor
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid_limit", "Limit must be a positive integer")
+			return
+		}
+		filter.Limit = limit
+	}
+
+	result, err := s.stores.ListNearby(r.Context(), filter)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	nearbyStores := make([]store.Store, 0, len(result.Stores))
+	for _, nearby := range result.Stores {
+		nearbyStores = append(nearbyStores, nearby.Store)
+	}
+
+	schedules, err := s.storeSchedules(r, nearbyStores...)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	response := listNearbyStoresResponse{Stores: make([]nearbyStoreResponse, 0, len(result.Stores))}
+	for _, nearby := range result.Stores {
+		response.Stores = append(response.Stores, nearbyStoreResponse{
+			storeResponse:  newStoreResponse(nearby.Store, schedules[nearby.ID]),
+			DistanceMeters: int64(math.Round(nearby.DistanceMeters)),
+		})
+	}
+	if !result.NextCursor.IsZero() {
+		response.NextCursor = result.NextCursor.String()
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) getStore(w http.ResponseWriter, r *http.Request) {
+	activeStore, ok := s.storeFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	// Inactive stores are not shown to the customers.
+	if activeStore.Status != store.StatusActive {
+		writeError(w, http.StatusNotFound, "not_found", "Store not found")
+		return
+	}
+
+	schedules, err := s.storeSchedules(r, activeStore)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, storeDetailResponse{
+		storeResponse:         newStoreResponse(activeStore, schedules[activeStore.ID]),
+		storeScheduleResponse: newStoreScheduleResponse(activeStore, schedules[activeStore.ID]),
+	})
+}
+
+// storeFromURL acquires the store from the "id" URL parameter. It writes the error response and returns
+// false if there is no such store.
+func (s *Server) storeFromURL(w http.ResponseWriter, r *http.Request) (store.Store, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusNotFound, "not_found", "Store not found")
+		return store.Store{}, false
+	}
+
+	found, err := s.stores.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "Store not found")
+			return store.Store{}, false
+		}
+
+		writeInternalError(w, r, err)
+		return store.Store{}, false
+	}
+
+	return found, true
+}
+
+// parseStoreListFilter reads the name search, cursor, and limit query parameters into the filter. It
+// writes the error response and returns false if any is invalid.
+func parseStoreListFilter(w http.ResponseWriter, r *http.Request, filter *store.ListFilter) bool {
+	query := r.URL.Query()
+
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || cursor < 0 {
+			writeError(w, http.StatusBadRequest, "invalid_cursor", "Cursor is invalid")
+			return false
+		}
+		filter.Cursor = cursor
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid_limit", "Limit must be a positive integer")
+			return false
+		}
+		filter.Limit = limit
+	}
+	filter.Name = query.Get("q")
+
+	return true
+}
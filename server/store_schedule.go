@@ -0,0 +1,254 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"coffee-chain-api/store"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// dateLayout is the format of dates in requests and responses.
+const dateLayout = "2006-01-02"
+
+type timeRangeJSON struct {
+	// Opens and Closes are in the "15:04" format, in the store's timezone. A range that closes at or before
+	// it opens goes past midnight, "24:00" closes at midnight.
+	Opens  string `json:"opens"`
+	Closes string `json:"closes"`
+}
+
+func newTimeRangeJSON(r store.TimeRange) timeRangeJSON {
+	return timeRangeJSON{Opens: r.Opens.String(), Closes: r.Closes.String()}
+}
+
+// timeRange converts the range, it returns false if either time is invalid.
+func (t timeRangeJSON) timeRange() (store.TimeRange, bool) {
+	opens, ok := store.ParseTimeOfDay(t.Opens)
+	if !ok {
+		return store.TimeRange{}, false
+	}
+
+	closes, ok := store.ParseTimeOfDay(t.Closes)
+	if !ok {
+		return store.TimeRange{}, false
+	}
+
+	return store.TimeRange{Opens: opens, Closes: closes}, true
+}
+
+type openingHoursJSON struct {
+	// Weekday is the lowercase English name, e.g. "monday".
+	Weekday string `json:"weekday"`
+	timeRangeJSON
+}
+
+type scheduleExceptionJSON struct {
+	Date string `json:"date"`
+	Note string `json:"note,omitempty"`
+	// Hours is empty if the store is closed for the day.
+	Hours []timeRangeJSON `json:"hours"`
+}
+
+type storeScheduleResponse struct {
+	OpeningHours []openingHoursJSON `json:"opening_hours"`
+	// Exceptions are the upcoming ones, from today in the store's timezone.
+	Exceptions []scheduleExceptionJSON `json:"exceptions"`
+}
+
+func newStoreScheduleResponse(s store.Store, schedule store.Schedule) storeScheduleResponse {
+	response := storeScheduleResponse{
+		OpeningHours: make([]openingHoursJSON, 0, len(schedule.Weekly)),
+		Exceptions:   make([]scheduleExceptionJSON, 0, len(schedule.Exceptions)),
+	}
+
+	for _, h := range schedule.Weekly {
+		response.OpeningHours = append(response.OpeningHours, openingHoursJSON{
+			Weekday:       strings.ToLower(h.Weekday.String()),
+			timeRangeJSON: newTimeRangeJSON(h.TimeRange),
+		})
+	}
+
+	today := time.Now().In(s.Location()).Format(dateLayout)
+	for _, exception := range schedule.Exceptions {
+		date := exception.Date.Format(dateLayout)
+		if date < today {
+			continue
+		}
+
+		hours := make([]timeRangeJSON, 0, len(exception.Hours))
+		for _, r := range exception.Hours {
+			hours = append(hours, newTimeRangeJSON(r))
+		}
+
+		response.Exceptions = append(response.Exceptions, scheduleExceptionJSON{
+			Date:  date,
+			Note:  exception.Note,
+			Hours: hours,
+		})
+	}
+
+	return response
+}
+
+// storeSchedules acquires the schedules of the stores, keyed by the store ID.
+func (s *Server) storeSchedules(r *http.Request, stores ...store.Store) (map[int64]store.Schedule, error) {
+	storeIds := make([]int64, 0, len(stores))
+	for _, scheduled := range stores {
+		storeIds = append(storeIds, scheduled.ID)
+	}
+
+	return s.stores.GetSchedules(r.Context(), storeIds, store.ScheduleFrom(time.Now()))
+}
+
+// parseWeekday parses the lowercase English name of the day, e.g. "monday".
+func parseWeekday(s string) (time.Weekday, bool) {
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		if s == strings.ToLower(weekday.String()) {
+			return weekday, true
+		}
+	}
+
+	return 0, false
+}
+
+type setOpeningHoursRequest struct {
+	// OpeningHours replaces every regular opening hours of the store, an empty list removes them.
+	OpeningHours []openingHoursJSON `json:"opening_hours"`
+}
+
+func (s *Server) setOpeningHours(w http.ResponseWriter, r *http.Request) {
+	var request setOpeningHoursRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	hours := make([]store.OpeningHours, 0, len(request.OpeningHours))
+	for _, h := range request.OpeningHours {
+		weekday, ok := parseWeekday(h.Weekday)
+		if !ok {
+			writeError(w, http.StatusBadRequest, "invalid_opening_hours", "Weekday must be the lowercase English name of the day, such as monday")
+			return
+		}
+
+		timeRange, ok := h.timeRange()
+		if !ok {
+			writeError(w, http.StatusBadRequest, "invalid_opening_hours", "Opening and closing times must be in the 24-hour HH:MM format")
+			return
+		}
+
+		hours = append(hours, store.OpeningHours{Weekday: weekday, TimeRange: timeRange})
+	}
+
+	managedStore, ok := s.storeFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	err := s.stores.SetOpeningHours(r.Context(), managedStore.ID, hours)
+	if err != nil {
+		if writeStoreValidationError(w, err) {
+			return
+		}
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "Store not found")
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	s.writeManagedStore(w, r, http.StatusOK, managedStore)
+}
+
+type setScheduleExceptionRequest struct {
+	Note string `json:"note"`
+	// Hours replaces the opening hours of the date, leave it empty to close the store for the day.
+	Hours []timeRangeJSON `json:"hours"`
+}
+
+// setScheduleException sets the opening hours of the date in the URL, replacing the regular ones.
+func (s *Server) setScheduleException(w http.ResponseWriter, r *http.Request) {
+	var request setScheduleExceptionRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	date, ok := dateFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	exception := store.ScheduleException{Date: date, Note: request.Note}
+	for _, h := range request.Hours {
+		timeRange, ok := h.timeRange()
+		if !ok {
+			writeError(w, http.StatusBadRequest, "invalid_hours", "Opening and closing times must be in the 24-hour HH:MM format")
+			return
+		}
+
+		exception.Hours = append(exception.Hours, timeRange)
+	}
+
+	managedStore, ok := s.storeFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	err := s.stores.SetScheduleException(r.Context(), managedStore.ID, exception)
+	if err != nil {
+		if writeStoreValidationError(w, err) {
+			return
+		}
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "Store not found")
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	s.writeManagedStore(w, r, http.StatusOK, managedStore)
+}
+
+func (s *Server) deleteScheduleException(w http.ResponseWriter, r *http.Request) {
+	date, ok := dateFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	managedStore, ok := s.storeFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	err := s.stores.DeleteScheduleException(r.Context(), managedStore.ID, date)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "There is no exception on the date")
+			return
+		}
+
+		writeInternalError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// dateFromURL parses the "date" URL parameter. It writes the error response and returns false if it's not
+// a valid date.
+func dateFromURL(w http.ResponseWriter, r *http.Request) (time.Time, bool) {
+	date, err := time.Parse(dateLayout, chi.URLParam(r, "date"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_date", "Date must be in the YYYY-MM-DD format")
+		return time.Time{}, false
+	}
+
+	return date, true
+}
@@ -0,0 +1,166 @@
+package store
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	// earthRadiusMeters is the mean radius of the Earth.
+	earthRadiusMeters = 6371008.8
+	// DefaultNearbyRadius is the search radius of StoreRepository.ListNearby in meters, if
+	// NearbyFilter.RadiusMeters is not set.
+	DefaultNearbyRadius = 10_000
+	// MaximumNearbyRadius is the largest search radius in meters.
+	MaximumNearbyRadius = 50_000
+)
+
+// DistanceMeters returns the great-circle distance between the two coordinates in meters, with the
+// haversine formula.
+func DistanceMeters(latitude1, longitude1, latitude2, longitude2 float64) float64 {
+	lat1 := latitude1 * math.Pi / 180
+	lat2 := latitude2 * math.Pi / 180
+	deltaLat := (latitude2 - latitude1) * math.Pi / 180
+	deltaLng := (longitude2 - longitude1) * math.Pi / 180
+
+	h := math.Pow(math.Sin(deltaLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(deltaLng/2), 2)
+
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(math.Min(1, h)))
+}
+
+// distanceExpression is DistanceMeters in SQL, from the latitude and longitude columns to the coordinates
+// in the $1 and $2 parameters.
+const distanceExpression = `2 * 6371008.8 * ASIN(SQRT(LEAST(1,
+					POWER(SIN(RADIANS(latitude - $1) / 2), 2)
+					+ COS(RADIANS($1)) * COS(RADIANS(latitude)) * POWER(SIN(RADIANS(longitude - $2) / 2), 2)
+				)))`
+
+// BoundingBox is the smallest latitude and longitude range that covers a circle on the Earth, to narrow
+// down the stores before their distances are calculated.
+type BoundingBox struct {
+	MinLatitude  float64
+	MaxLatitude  float64
+	MinLongitude float64
+	MaxLongitude float64
+	// CrossesAntimeridian is true if the box goes past the 180th meridian, MinLongitude is then greater
+	// than MaxLongitude and the box covers the longitudes outside of them.
+	CrossesAntimeridian bool
+}
+
+// BoundingBoxAround returns the box covering every point within the radius of the coordinates.
+func BoundingBoxAround(latitude, longitude, radiusMeters float64) BoundingBox {
+	angularRadius := radiusMeters / earthRadiusMeters
+	lat := latitude * math.Pi / 180
+
+	minLat := lat - angularRadius
+	maxLat := lat + angularRadius
+
+	// The circle covers a pole, so every longitude.
+	if minLat <= -math.Pi/2 || maxLat >= math.Pi/2 {
+		return BoundingBox{
+			MinLatitude:  math.Max(minLat*180/math.Pi, -90),
+			MaxLatitude:  math.Min(maxLat*180/math.Pi, 90),
+			MinLongitude: -180,
+			MaxLongitude: 180,
+		}
+	}
+
+	deltaLng := math.Asin(math.Sin(angularRadius)/math.Cos(lat)) * 180 / math.Pi
+	box := BoundingBox{
+		MinLatitude:  minLat * 180 / math.Pi,
+		MaxLatitude:  maxLat * 180 / math.Pi,
+		MinLongitude: longitude - deltaLng,
+		MaxLongitude: longitude + deltaLng,
+	}
+
+	if box.MinLongitude < -180 {
+		box.MinLongitude += 360
+		box.CrossesAntimeridian = true
+	}
+	if box.MaxLongitude > 180 {
+		box.MaxLongitude -= 360
+		box.CrossesAntimeridian = true
+	}
+
+	return box
+}
+
+// Contains reports whether the coordinates are within the box.
+func (b BoundingBox) Contains(latitude, longitude float64) bool {
+	if latitude < b.MinLatitude || latitude > b.MaxLatitude {
+		return false
+	}
+
+	if b.CrossesAntimeridian {
+		return longitude >= b.MinLongitude || longitude <= b.MaxLongitude
+	}
+
+	return longitude >= b.MinLongitude && longitude <= b.MaxLongitude
+}
+
+// NearbyCursor is the position after the last store of a page of StoreRepository.ListNearby. Stores at
+// the same distance are ordered by their ID.
+type NearbyCursor struct {
+	DistanceMeters float64
+	ID             int64
+}
+
+// IsZero reports whether the cursor is the start of the first page.
+func (c NearbyCursor) IsZero() bool {
+	return c.ID == 0
+}
+
+// String encodes the cursor for the clients, to be parsed by ParseNearbyCursor.
+func (c NearbyCursor) String() string {
+	return strconv.FormatFloat(c.DistanceMeters, 'g', -1, 64) + "_" + strconv.FormatInt(c.ID, 10)
+}
+
+// ParseNearbyCursor parses the cursor encoded by NearbyCursor.String.
+func ParseNearbyCursor(s string) (NearbyCursor, bool) {
+	distance, id, ok := strings.Cut(s, "_")
+	if !ok {
+		return NearbyCursor{}, false
+	}
+
+	var cursor NearbyCursor
+	var err error
+
+	cursor.DistanceMeters, err = strconv.ParseFloat(distance, 64)
+	if err != nil || cursor.DistanceMeters < 0 || math.IsInf(cursor.DistanceMeters, 0) || math.IsNaN(cursor.DistanceMeters) {
+		return NearbyCursor{}, false
+	}
+
+	cursor.ID, err = strconv.ParseInt(id, 10, 64)
+	if err != nil || cursor.ID <= 0 {
+		return NearbyCursor{}, false
+	}
+
+	return cursor, true
+}
+
+// NearbyFilter narrows down the stores returned by StoreRepository.ListNearby.
+type NearbyFilter struct {
+	Latitude  float64
+	Longitude float64
+	// RadiusMeters is capped at MaximumNearbyRadius, zero is DefaultNearbyRadius.
+	RadiusMeters float64
+	// Status and Name are the same as in ListFilter.
+	Status Status
+	Name   string
+	// Cursor is the NextCursor of the previous page, or zero for the first page.
+	Cursor NearbyCursor
+	Limit  int
+}
+
+// NearbyStore is a store along with its distance from the searched coordinates.
+type NearbyStore struct {
+	Store
+	DistanceMeters float64
+}
+
+type NearbyResult struct {
+	Stores []NearbyStore
+	// NextCursor is zero if there is no next page.
+	NextCursor NearbyCursor
+}
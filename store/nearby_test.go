@@ -0,0 +1,130 @@
+package store_test
+
+import (
+	"math"
+	"testing"
+
+	"coffee-chain-api/store"
+)
+
+func TestDistanceMeters(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		latitude1, longitude1 float64
+		latitude2, longitude2 float64
+		expect                float64
+	}{
+		{name: "same point", latitude1: -6.2, longitude1: 106.8, latitude2: -6.2, longitude2: 106.8, expect: 0},
+		{name: "one degree of latitude", latitude1: 0, longitude1: 0, latitude2: 1, longitude2: 0, expect: 111195},
+		{name: "one degree of longitude at the equator", latitude1: 0, longitude1: 0, latitude2: 0, longitude2: 1, expect: 111195},
+		{name: "Jakarta to Bandung", latitude1: -6.1754, longitude1: 106.8272, latitude2: -6.9175, longitude2: 107.6191, expect: 120000},
+		{name: "across the antimeridian", latitude1: 0, longitude1: 179.5, latitude2: 0, longitude2: -179.5, expect: 111195},
+		{name: "antipodes", latitude1: 0, longitude1: 0, latitude2: 0, longitude2: 180, expect: math.Pi * 6371008.8},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := store.DistanceMeters(tt.latitude1, tt.longitude1, tt.latitude2, tt.longitude2)
+			// Within 1% or a meter.
+			if math.Abs(got-tt.expect) > math.Max(1, tt.expect*0.01) {
+				t.Errorf("expecting %f, got %f instead", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestBoundingBoxAround(t *testing.T) {
+	testCases := []struct {
+		name                string
+		latitude, longitude float64
+		radius              float64
+		crossesAntimeridian bool
+	}{
+		{name: "Jakarta", latitude: -6.2, longitude: 106.8, radius: 10_000},
+		{name: "far north", latitude: 70, longitude: 20, radius: 50_000},
+		{name: "near the antimeridian", latitude: -17.7, longitude: 179.9, radius: 50_000, crossesAntimeridian: true},
+		{name: "near the antimeridian from the west", latitude: 65, longitude: -179.9, radius: 50_000, crossesAntimeridian: true},
+		{name: "around the pole", latitude: 89.9, longitude: 0, radius: 50_000},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			box := store.BoundingBoxAround(tt.latitude, tt.longitude, tt.radius)
+			if box.CrossesAntimeridian != tt.crossesAntimeridian {
+				t.Errorf("expecting crosses antimeridian %t, got %t instead", tt.crossesAntimeridian, box.CrossesAntimeridian)
+			}
+
+			if !box.Contains(tt.latitude, tt.longitude) {
+				t.Errorf("expecting the center to be in %+v", box)
+			}
+
+			// Walk the circle just inside the radius, every point of it must be in the box.
+			for bearing := 0.0; bearing < 360; bearing += 5 {
+				latitude, longitude := destination(tt.latitude, tt.longitude, bearing, tt.radius*0.999)
+				if !box.Contains(latitude, longitude) {
+					t.Errorf("expecting %f,%f at bearing %f to be in %+v", latitude, longitude, bearing, box)
+				}
+			}
+
+			// And just outside the radius to the north and south, unless it's past the pole.
+			for _, bearing := range []float64{0, 180} {
+				latitude, longitude := destination(tt.latitude, tt.longitude, bearing, tt.radius*1.01)
+				if math.Abs(latitude) < 89 && box.Contains(latitude, longitude) {
+					t.Errorf("expecting %f,%f at bearing %f to be outside of %+v", latitude, longitude, bearing, box)
+				}
+			}
+		})
+	}
+}
+
+// destination returns the point at the distance from the coordinates along the bearing in degrees.
+func destination(latitude, longitude, bearing, distance float64) (float64, float64) {
+	const earthRadius = 6371008.8
+	lat := latitude * math.Pi / 180
+	lng := longitude * math.Pi / 180
+	theta := bearing * math.Pi / 180
+	delta := distance / earthRadius
+
+	lat2 := math.Asin(math.Sin(lat)*math.Cos(delta) + math.Cos(lat)*math.Sin(delta)*math.Cos(theta))
+	lng2 := lng + math.Atan2(math.Sin(theta)*math.Sin(delta)*math.Cos(lat), math.Cos(delta)-math.Sin(lat)*math.Sin(lat2))
+
+	longitude2 := math.Mod(lng2*180/math.Pi+540, 360) - 180
+	return lat2 * 180 / math.Pi, longitude2
+}
+
+func TestParseNearbyCursor(t *testing.T) {
+	testCases := []struct {
+		input  string
+		expect store.NearbyCursor
+		ok     bool
+	}{
+		{input: "1234.5678_42", expect: store.NearbyCursor{DistanceMeters: 1234.5678, ID: 42}, ok: true},
+		{input: "0_1", expect: store.NearbyCursor{DistanceMeters: 0, ID: 1}, ok: true},
+		{input: "1234.5678", ok: false},
+		{input: "-1_42", ok: false},
+		{input: "NaN_42", ok: false},
+		{input: "Inf_42", ok: false},
+		{input: "12_0", ok: false},
+		{input: "12_abc", ok: false},
+		{input: "", ok: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok := store.ParseNearbyCursor(tt.input)
+			if got != tt.expect || ok != tt.ok {
+				t.Errorf("expecting %+v (%t), got %+v (%t) instead", tt.expect, tt.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestNearbyCursorRoundTrip(t *testing.T) {
+	// The distance must come back exactly, or the next page would skip or repeat stores at the same distance.
+	cursor := store.NearbyCursor{DistanceMeters: store.DistanceMeters(-6.1754, 106.8272, -6.2, 106.8), ID: 7}
+
+	got, ok := store.ParseNearbyCursor(cursor.String())
+	if !ok || got != cursor {
+		t.Errorf("expecting %+v, got %+v (%t) instead", cursor, got, ok)
+	}
+}
@@ -0,0 +1,980 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"coffee-chain-api/account"
+
+	"github.com/rs/zerolog/log"
+)
+
+type storesTable struct {
+	ID        int64
+	Name      string
+	Address   string
+	Latitude  float64
+	Longitude float64
+	Phone     string
+	Timezone  string
+	Status    int8
+	CreatedAt time.Time
+	CreatedBy string
+	UpdatedAt time.Time
+	UpdatedBy string
+
+	OperationalState int8
+	PauseReason      string
+	ResumeAt         sql.NullTime
+	StateUpdatedAt   sql.NullTime
+	StateUpdatedBy   string
+}
+
+// storesColumns is the column list for selecting storesTable, in the same order as
+// storesTable.scanDestinations.
+const storesColumns = `id,
+				name,
+				address,
+				latitude,
+				longitude,
+				phone,
+				timezone,
+				status,
+				created_at,
+				created_by,
+				updated_at,
+				updated_by,
+				operational_state,
+				pause_reason,
+				resume_at,
+				state_updated_at,
+				state_updated_by`
+
+func (s *storesTable) scanDestinations() []any {
+	return []any{
+		&s.ID,
+		&s.Name,
+		&s.Address,
+		&s.Latitude,
+		&s.Longitude,
+		&s.Phone,
+		&s.Timezone,
+		&s.Status,
+		&s.CreatedAt,
+		&s.CreatedBy,
+		&s.UpdatedAt,
+		&s.UpdatedBy,
+		&s.OperationalState,
+		&s.PauseReason,
+		&s.ResumeAt,
+		&s.StateUpdatedAt,
+		&s.StateUpdatedBy,
+	}
+}
+
+func (s *storesTable) store() Store {
+	return Store{
+		ID:             s.ID,
+		Name:           s.Name,
+		Address:        s.Address,
+		Latitude:       s.Latitude,
+		Longitude:      s.Longitude,
+		Phone:          s.Phone,
+		Timezone:       s.Timezone,
+		Status:         Status(s.Status),
+		State:          State(s.OperationalState),
+		PauseReason:    s.PauseReason,
+		ResumeAt:       s.ResumeAt.Time,
+		StateUpdatedAt: s.StateUpdatedAt.Time,
+		StateUpdatedBy: s.StateUpdatedBy,
+		CreatedAt:      s.CreatedAt,
+		CreatedBy:      s.CreatedBy,
+		UpdatedAt:      s.UpdatedAt,
+		UpdatedBy:      s.UpdatedBy,
+	}
+}
+
+// likeEscaper escapes the wildcard characters of a LIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+type repository struct {
+	db *sql.DB
+}
+
+func (r *repository) GetByID(ctx context.Context, id int64) (Store, error) {
+	if id <= 0 {
+		return Store{}, ErrNotFound
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return Store{}, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	return getByID(ctx, conn, id, "")
+}
+
+func (r *repository) GetByIDForShare(ctx context.Context, tx *sql.Tx, id int64) (Store, error) {
+	if id <= 0 {
+		return Store{}, ErrNotFound
+	}
+
+	return getByID(ctx, tx, id, "FOR SHARE")
+}
+
+// queryRower is either a connection or a transaction.
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// getByID acquires the store, and locks its row by lock if it's set, such as "FOR SHARE".
+func getByID(ctx context.Context, q queryRower, id int64, lock string) (Store, error) {
+	var store storesTable
+	err := q.QueryRowContext(
+		ctx,
+		`SELECT
+				`+storesColumns+`
+			FROM
+				stores
+			WHERE
+				id = $1
+				AND deleted_at IS NULL
+			LIMIT 1 `+lock,
+		id,
+	).Scan(store.scanDestinations()...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Store{}, ErrNotFound
+		}
+
+		return Store{}, fmt.Errorf("getting store by id: %w", err)
+	}
+
+	return store.store(), nil
+}
+
+func (r *repository) List(ctx context.Context, filter ListFilter) (ListResult, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	if limit > MaximumListLimit {
+		limit = MaximumListLimit
+	}
+
+	conditions := []string{"deleted_at IS NULL", "id > $1"}
+	args := []any{filter.Cursor}
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", "$"+strconv.Itoa(len(args))))
+	}
+
+	if filter.Status != StatusUnspecified {
+		addCondition("status = ?", filter.Status)
+	}
+	if name := strings.TrimSpace(filter.Name); name != "" {
+		addCondition(`name ILIKE ? ESCAPE '\'`, "%"+likeEscaper.Replace(name)+"%")
+	}
+
+	// One more row than requested, to know whether there is a next page.
+	args = append(args, limit+1)
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return ListResult{}, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	rows, err := conn.QueryContext(
+		ctx,
+		`SELECT
+				`+storesColumns+`
+			FROM
+				stores
+			WHERE
+				`+strings.Join(conditions, " AND ")+`
+			ORDER BY
+				id ASC
+			LIMIT $`+strconv.Itoa(len(args)),
+		args...,
+	)
+	if err != nil {
+		return ListResult{}, fmt.Errorf("executing select query: %w", err)
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing rows")
+		}
+	}()
+
+	var result ListResult
+	for rows.Next() {
+		var store storesTable
+		err = rows.Scan(store.scanDestinations()...)
+		if err != nil {
+			return ListResult{}, fmt.Errorf("scanning row: %w", err)
+		}
+
+		result.Stores = append(result.Stores, store.store())
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return ListResult{}, fmt.Errorf("iterating rows: %w", err)
+	}
+
+	if len(result.Stores) > limit {
+		result.Stores = result.Stores[:limit]
+		result.NextCursor = result.Stores[limit-1].ID
+	}
+
+	return result, nil
+}
+
+func (r *repository) ListNearby(ctx context.Context, filter NearbyFilter) (NearbyResult, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	if limit > MaximumListLimit {
+		limit = MaximumListLimit
+	}
+
+	radius := filter.RadiusMeters
+	if radius <= 0 {
+		radius = DefaultNearbyRadius
+	}
+	if radius > MaximumNearbyRadius {
+		radius = MaximumNearbyRadius
+	}
+
+	// $1 and $2 are the coordinates used by distanceExpression.
+	conditions := []string{"deleted_at IS NULL"}
+	args := []any{filter.Latitude, filter.Longitude}
+	addCondition := func(condition string, conditionArgs ...any) {
+		for _, arg := range conditionArgs {
+			args = append(args, arg)
+			condition = strings.Replace(condition, "?", "$"+strconv.Itoa(len(args)), 1)
+		}
+		conditions = append(conditions, condition)
+	}
+
+	// The bounding box can use the index, and leaves only the corners of the box to the distance.
+	box := BoundingBoxAround(filter.Latitude, filter.Longitude, radius)
+	addCondition("latitude BETWEEN ? AND ?", box.MinLatitude, box.MaxLatitude)
+	if box.CrossesAntimeridian {
+		addCondition("(longitude >= ? OR longitude <= ?)", box.MinLongitude, box.MaxLongitude)
+	} else {
+		addCondition("longitude BETWEEN ? AND ?", box.MinLongitude, box.MaxLongitude)
+	}
+
+	if filter.Status != StatusUnspecified {
+		addCondition("status = ?", filter.Status)
+	}
+	if name := strings.TrimSpace(filter.Name); name != "" {
+		addCondition(`name ILIKE ? ESCAPE '\'`, "%"+likeEscaper.Replace(name)+"%")
+	}
+
+	outerConditions := []string{"distance <= $" + strconv.Itoa(len(args)+1)}
+	args = append(args, radius)
+	if !filter.Cursor.IsZero() {
+		args = append(args, filter.Cursor.DistanceMeters, filter.Cursor.ID)
+		distance, id := "$"+strconv.Itoa(len(args)-1), "$"+strconv.Itoa(len(args))
+		outerConditions = append(outerConditions, "(distance > "+distance+" OR (distance = "+distance+" AND id > "+id+"))")
+	}
+
+	// One more row than requested, to know whether there is a next page.
+	args = append(args, limit+1)
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return NearbyResult{}, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	rows, err := conn.QueryContext(
+		ctx,
+		`SELECT
+				*
+			FROM (
+				SELECT
+					`+storesColumns+`,
+					`+distanceExpression+` AS distance
+				FROM
+					stores
+				WHERE
+					`+strings.Join(conditions, " AND ")+`
+			) AS nearby
+			WHERE
+				`+strings.Join(outerConditions, " AND ")+`
+			ORDER BY
+				distance ASC,
+				id ASC
+			LIMIT $`+strconv.Itoa(len(args)),
+		args...,
+	)
+	if err != nil {
+		return NearbyResult{}, fmt.Errorf("executing select query: %w", err)
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing rows")
+		}
+	}()
+
+	var result NearbyResult
+	for rows.Next() {
+		var store storesTable
+		var distance float64
+		err = rows.Scan(append(store.scanDestinations(), &distance)...)
+		if err != nil {
+			return NearbyResult{}, fmt.Errorf("scanning row: %w", err)
+		}
+
+		result.Stores = append(result.Stores, NearbyStore{Store: store.store(), DistanceMeters: distance})
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return NearbyResult{}, fmt.Errorf("iterating rows: %w", err)
+	}
+
+	if len(result.Stores) > limit {
+		result.Stores = result.Stores[:limit]
+		last := result.Stores[limit-1]
+		result.NextCursor = NearbyCursor{DistanceMeters: last.DistanceMeters, ID: last.ID}
+	}
+
+	return result, nil
+}
+
+func (r *repository) Insert(ctx context.Context, rawStore RawStore) (Store, error) {
+	rawStore = rawStore.Normalize()
+	err := rawStore.Validate()
+	if err != nil {
+		return Store{}, err
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return Store{}, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	now := time.Now()
+	var store storesTable
+	err = conn.QueryRowContext(
+		ctx,
+		`INSERT INTO
+			stores
+			(name,
+			 address,
+			 latitude,
+			 longitude,
+			 phone,
+			 timezone,
+			 status,
+			 created_at,
+			 created_by,
+			 updated_at,
+			 updated_by
+			 )
+		VALUES
+			($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING
+			`+storesColumns,
+		rawStore.Name,
+		rawStore.Address,
+		rawStore.Latitude,
+		rawStore.Longitude,
+		rawStore.Phone,
+		rawStore.Timezone,
+		rawStore.Status,
+		now,
+		account.ActorIdentifier(ctx),
+		now,
+		account.ActorIdentifier(ctx),
+	).Scan(store.scanDestinations()...)
+	if err != nil {
+		return Store{}, fmt.Errorf("executing insert query: %w", err)
+	}
+
+	return store.store(), nil
+}
+
+func (r *repository) Update(ctx context.Context, id int64, rawStore RawStore) (Store, error) {
+	if id <= 0 {
+		return Store{}, ErrNotFound
+	}
+
+	rawStore = rawStore.Normalize()
+	err := rawStore.Validate()
+	if err != nil {
+		return Store{}, err
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return Store{}, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	var store storesTable
+	err = conn.QueryRowContext(
+		ctx,
+		`UPDATE
+			stores
+		SET
+			name = $1,
+			address = $2,
+			latitude = $3,
+			longitude = $4,
+			phone = $5,
+			timezone = $6,
+			status = $7,
+			updated_at = $8,
+			updated_by = $9
+		WHERE
+			id = $10
+			AND deleted_at IS NULL
+		RETURNING
+			`+storesColumns,
+		rawStore.Name,
+		rawStore.Address,
+		rawStore.Latitude,
+		rawStore.Longitude,
+		rawStore.Phone,
+		rawStore.Timezone,
+		rawStore.Status,
+		time.Now(),
+		account.ActorIdentifier(ctx),
+		id,
+	).Scan(store.scanDestinations()...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Store{}, ErrNotFound
+		}
+
+		return Store{}, fmt.Errorf("executing update query: %w", err)
+	}
+
+	return store.store(), nil
+}
+
+func (r *repository) Delete(ctx context.Context, id int64) error {
+	if id <= 0 {
+		return ErrNotFound
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	result, err := conn.ExecContext(
+		ctx,
+		`UPDATE
+			stores
+		SET
+			deleted_at = $1,
+			updated_at = $1,
+			updated_by = $2
+		WHERE
+			id = $3
+			AND deleted_at IS NULL`,
+		time.Now(),
+		account.ActorIdentifier(ctx),
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("executing update query: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("acquiring affected rows: %w", err)
+	}
+
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *repository) SetState(ctx context.Context, id int64, change StateChange) (Store, error) {
+	if id <= 0 {
+		return Store{}, ErrNotFound
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return Store{}, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		return Store{}, fmt.Errorf("creating transaction: %w", err)
+	}
+
+	var current storesTable
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT
+				`+storesColumns+`
+			FROM
+				stores
+			WHERE
+				id = $1
+				AND deleted_at IS NULL
+			LIMIT 1
+			FOR UPDATE`,
+		id,
+	).Scan(current.scanDestinations()...)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return Store{}, fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return Store{}, ErrNotFound
+		}
+
+		return Store{}, fmt.Errorf("getting store by id: %w", err)
+	}
+
+	updated, changed, err := current.store().ApplyStateChange(change, time.Now())
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return Store{}, fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		return Store{}, err
+	}
+
+	// Moving into the current state changes nothing, not even who set it last.
+	if !changed {
+		err = tx.Commit()
+		if err != nil {
+			return Store{}, fmt.Errorf("committing transaction: %w", err)
+		}
+
+		return updated, nil
+	}
+
+	updated.StateUpdatedBy = account.ActorIdentifier(ctx)
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE
+			stores
+		SET
+			operational_state = $1,
+			pause_reason = $2,
+			resume_at = $3,
+			state_updated_at = $4,
+			state_updated_by = $5
+		WHERE
+			id = $6`,
+		updated.State,
+		updated.PauseReason,
+		sql.NullTime{Time: updated.ResumeAt, Valid: !updated.ResumeAt.IsZero()},
+		updated.StateUpdatedAt,
+		updated.StateUpdatedBy,
+		id,
+	)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return Store{}, fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		return Store{}, fmt.Errorf("executing update query: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return Store{}, fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return updated, nil
+}
+
+func NewRepository(db *sql.DB) (StoreRepository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db is nil")
+	}
+
+	return &repository{db: db}, nil
+}
+
+// dateLayout is how dates are passed to and from DATE columns.
+const dateLayout = "2006-01-02"
+
+func (r *repository) GetSchedules(ctx context.Context, storeIds []int64, from time.Time) (map[int64]Schedule, error) {
+	schedules := make(map[int64]Schedule, len(storeIds))
+	if len(storeIds) == 0 {
+		return schedules, nil
+	}
+
+	placeholders := make([]string, 0, len(storeIds))
+	args := make([]any, 0, len(storeIds)+1)
+	for _, storeId := range storeIds {
+		args = append(args, storeId)
+		placeholders = append(placeholders, "$"+strconv.Itoa(len(args)))
+	}
+	in := strings.Join(placeholders, ", ")
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	rows, err := conn.QueryContext(
+		ctx,
+		`SELECT
+				store_id,
+				weekday,
+				opens_at,
+				closes_at
+			FROM
+				store_opening_hours
+			WHERE
+				store_id IN (`+in+`)
+			ORDER BY
+				store_id, weekday, opens_at`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("executing select query: %w", err)
+	}
+
+	for rows.Next() {
+		var storeId int64
+		var hours OpeningHours
+		err = rows.Scan(&storeId, &hours.Weekday, &hours.Opens, &hours.Closes)
+		if err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("scanning row: %w", err)
+		}
+
+		schedule := schedules[storeId]
+		schedule.Weekly = append(schedule.Weekly, hours)
+		schedules[storeId] = schedule
+	}
+
+	err = rows.Err()
+	if err != nil {
+		_ = rows.Close()
+		return nil, fmt.Errorf("iterating rows: %w", err)
+	}
+
+	err = rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("closing rows: %w", err)
+	}
+
+	args = append(args, from.Format(dateLayout))
+	rows, err = conn.QueryContext(
+		ctx,
+		`SELECT
+				store_id,
+				date,
+				note,
+				opens_at,
+				closes_at
+			FROM
+				store_schedule_exceptions
+			WHERE
+				store_id IN (`+in+`)
+				AND date >= $`+strconv.Itoa(len(args))+`
+			ORDER BY
+				store_id, date, opens_at NULLS FIRST`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("executing select query: %w", err)
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing rows")
+		}
+	}()
+
+	for rows.Next() {
+		var storeId int64
+		var date time.Time
+		var note string
+		var opensAt, closesAt sql.NullInt16
+		err = rows.Scan(&storeId, &date, &note, &opensAt, &closesAt)
+		if err != nil {
+			return nil, fmt.Errorf("scanning row: %w", err)
+		}
+
+		// An exception is stored as a row per range, or a single row without a range if it's closed all day.
+		schedule := schedules[storeId]
+		last := len(schedule.Exceptions) - 1
+		if last < 0 || !schedule.Exceptions[last].Date.Equal(date) {
+			schedule.Exceptions = append(schedule.Exceptions, ScheduleException{Date: date, Note: note})
+			last++
+		}
+		if opensAt.Valid && closesAt.Valid {
+			schedule.Exceptions[last].Hours = append(schedule.Exceptions[last].Hours, TimeRange{
+				Opens:  TimeOfDay(opensAt.Int16),
+				Closes: TimeOfDay(closesAt.Int16),
+			})
+		}
+		schedules[storeId] = schedule
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("iterating rows: %w", err)
+	}
+
+	return schedules, nil
+}
+
+func (r *repository) SetOpeningHours(ctx context.Context, storeId int64, hours []OpeningHours) error {
+	err := ValidateOpeningHours(hours)
+	if err != nil {
+		return err
+	}
+
+	return r.replaceSchedule(ctx, storeId, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `DELETE FROM store_opening_hours WHERE store_id = $1`, storeId)
+		if err != nil {
+			return fmt.Errorf("executing delete query: %w", err)
+		}
+
+		for _, h := range hours {
+			_, err = tx.ExecContext(
+				ctx,
+				`INSERT INTO
+					store_opening_hours
+					(store_id,
+					 weekday,
+					 opens_at,
+					 closes_at
+					 )
+				VALUES
+					($1, $2, $3, $4)`,
+				storeId,
+				h.Weekday,
+				h.Opens,
+				h.Closes,
+			)
+			if err != nil {
+				return fmt.Errorf("executing insert query: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *repository) SetScheduleException(ctx context.Context, storeId int64, exception ScheduleException) error {
+	err := exception.Validate()
+	if err != nil {
+		return err
+	}
+
+	date := exception.Date.Format(dateLayout)
+	note := strings.TrimSpace(exception.Note)
+
+	return r.replaceSchedule(ctx, storeId, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `DELETE FROM store_schedule_exceptions WHERE store_id = $1 AND date = $2`, storeId, date)
+		if err != nil {
+			return fmt.Errorf("executing delete query: %w", err)
+		}
+
+		insert := func(opensAt sql.NullInt16, closesAt sql.NullInt16) error {
+			_, err := tx.ExecContext(
+				ctx,
+				`INSERT INTO
+					store_schedule_exceptions
+					(store_id,
+					 date,
+					 note,
+					 opens_at,
+					 closes_at
+					 )
+				VALUES
+					($1, $2, $3, $4, $5)`,
+				storeId,
+				date,
+				note,
+				opensAt,
+				closesAt,
+			)
+			if err != nil {
+				return fmt.Errorf("executing insert query: %w", err)
+			}
+
+			return nil
+		}
+
+		// A day closed altogether is stored as a single row without a range.
+		if len(exception.Hours) == 0 {
+			return insert(sql.NullInt16{}, sql.NullInt16{})
+		}
+
+		for _, h := range exception.Hours {
+			err = insert(sql.NullInt16{Int16: int16(h.Opens), Valid: true}, sql.NullInt16{Int16: int16(h.Closes), Valid: true})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *repository) DeleteScheduleException(ctx context.Context, storeId int64, date time.Time) error {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	result, err := conn.ExecContext(
+		ctx,
+		`DELETE FROM store_schedule_exceptions WHERE store_id = $1 AND date = $2`,
+		storeId,
+		date.Format(dateLayout),
+	)
+	if err != nil {
+		return fmt.Errorf("executing delete query: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("acquiring affected rows: %w", err)
+	}
+
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// replaceSchedule runs replace in a transaction, with the store locked so concurrent replacements of its
+// schedule do not interleave. It returns ErrNotFound if the store does not exist.
+func (r *repository) replaceSchedule(ctx context.Context, storeId int64, replace func(tx *sql.Tx) error) error {
+	if storeId <= 0 {
+		return ErrNotFound
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection from pool: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("closing connection back to pool")
+		}
+	}()
+
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		return fmt.Errorf("creating transaction: %w", err)
+	}
+
+	var id int64
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT id FROM stores WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`,
+		storeId,
+	).Scan(&id)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+
+		return fmt.Errorf("getting store by id: %w", err)
+	}
+
+	err = replace(tx)
+	if err != nil {
+		if e := tx.Rollback(); e != nil {
+			return fmt.Errorf("rolling back transaction: %w (%s)", e, err)
+		}
+
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,313 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// TimeOfDay is the number of minutes since midnight, in the store's timezone. It ranges from 00:00 to
+// 24:00, the latter is only meaningful as a closing time.
+type TimeOfDay int16
+
+const minutesPerDay = 24 * 60
+
+// ParseTimeOfDay parses the time from the 24-hour "15:04" format, and "24:00".
+func ParseTimeOfDay(s string) (TimeOfDay, bool) {
+	if len(s) != 5 || s[2] != ':' {
+		return 0, false
+	}
+
+	for i, c := range []byte(s) {
+		if i != 2 && (c < '0' || c > '9') {
+			return 0, false
+		}
+	}
+
+	hour := int(s[0]-'0')*10 + int(s[1]-'0')
+	minute := int(s[3]-'0')*10 + int(s[4]-'0')
+
+	if minute > 59 || hour > 24 || (hour == 24 && minute != 0) {
+		return 0, false
+	}
+
+	return TimeOfDay(hour*60 + minute), true
+}
+
+func (t TimeOfDay) String() string {
+	return fmt.Sprintf("%02d:%02d", t/60, t%60)
+}
+
+// TimeRange is a period of the day the store is open. A range that closes at or before it opens goes past
+// midnight, and closes on the next day.
+type TimeRange struct {
+	Opens  TimeOfDay
+	Closes TimeOfDay
+}
+
+func (r TimeRange) minutes() int {
+	if r.Closes > r.Opens {
+		return int(r.Closes - r.Opens)
+	}
+
+	return int(r.Closes) + minutesPerDay - int(r.Opens)
+}
+
+func (r TimeRange) valid() bool {
+	return r.Opens >= 0 && r.Opens < minutesPerDay && r.Closes >= 0 && r.Closes <= minutesPerDay && r.Opens != r.Closes
+}
+
+// OpeningHours is a range the store is regularly open on a day of the week.
+type OpeningHours struct {
+	Weekday time.Weekday
+	TimeRange
+}
+
+// ScheduleException replaces the regular opening hours of a single date, such as a holiday.
+type ScheduleException struct {
+	// Date is the local date in the store's timezone. Only its year, month, and day are used.
+	Date time.Time
+	// Note tells the customers why, such as "Eid al-Fitr".
+	Note string
+	// Hours replaces the opening hours of the date, the store is closed for the day if it's empty.
+	Hours []TimeRange
+}
+
+// Schedule is when a store is supposed to be open.
+type Schedule struct {
+	Weekly []OpeningHours
+	// Exceptions are ordered by their date.
+	Exceptions []ScheduleException
+}
+
+const (
+	maximumRangesPerDay = 4
+	maximumNoteLength   = 255
+	// scheduleLookahead is how far ahead Schedule.NextOpening looks for an opening.
+	scheduleLookahead = 31
+	// scheduleLookbehind is how far back Schedule.StateAt looks for the last opening or closing, a week and
+	// the overnight spill of the day before.
+	scheduleLookbehind = 8
+)
+
+// ScheduleFrom returns the earliest date of the exceptions that matter to the schedule at the given time,
+// to be passed to StoreRepository.GetSchedules. The extra day covers the difference of the timezones.
+func ScheduleFrom(now time.Time) time.Time {
+	return now.AddDate(0, 0, -scheduleLookbehind-1)
+}
+
+// ValidateOpeningHours checks the weekly opening hours, and returns a *ValidationError if any range is
+// invalid or overlaps another one.
+func ValidateOpeningHours(hours []OpeningHours) error {
+	perDay := make(map[time.Weekday]int)
+	for _, h := range hours {
+		if h.Weekday < time.Sunday || h.Weekday > time.Saturday {
+			return &ValidationError{Field: "opening_hours", Message: "weekday is invalid"}
+		}
+		if !h.valid() {
+			return &ValidationError{Field: "opening_hours", Message: fmt.Sprintf("%s %s-%s is not a valid range", h.Weekday, h.Opens, h.Closes)}
+		}
+
+		perDay[h.Weekday]++
+		if perDay[h.Weekday] > maximumRangesPerDay {
+			return &ValidationError{Field: "opening_hours", Message: fmt.Sprintf("%s has more than %d ranges", h.Weekday, maximumRangesPerDay)}
+		}
+	}
+
+	// Compare as minutes of the week, shifted by a week either way so Saturday night can overlap Sunday.
+	const minutesPerWeek = 7 * minutesPerDay
+	for i := range hours {
+		start := int(hours[i].Weekday)*minutesPerDay + int(hours[i].Opens)
+		end := start + hours[i].minutes()
+
+		for j := i + 1; j < len(hours); j++ {
+			otherStart := int(hours[j].Weekday)*minutesPerDay + int(hours[j].Opens)
+			otherEnd := otherStart + hours[j].minutes()
+
+			for _, shift := range []int{-minutesPerWeek, 0, minutesPerWeek} {
+				if start < otherEnd+shift && otherStart+shift < end {
+					return &ValidationError{Field: "opening_hours", Message: fmt.Sprintf("%s %s-%s overlaps %s %s-%s", hours[i].Weekday, hours[i].Opens, hours[i].Closes, hours[j].Weekday, hours[j].Opens, hours[j].Closes)}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Validate checks the exception, and returns a *ValidationError if it's invalid.
+func (e ScheduleException) Validate() error {
+	if e.Date.IsZero() {
+		return &ValidationError{Field: "date", Message: "is required"}
+	}
+	if utf8.RuneCountInString(strings.TrimSpace(e.Note)) > maximumNoteLength {
+		return &ValidationError{Field: "note", Message: fmt.Sprintf("must be at most %d characters", maximumNoteLength)}
+	}
+	if len(e.Hours) > maximumRangesPerDay {
+		return &ValidationError{Field: "hours", Message: fmt.Sprintf("must have at most %d ranges", maximumRangesPerDay)}
+	}
+
+	for i, r := range e.Hours {
+		if !r.valid() {
+			return &ValidationError{Field: "hours", Message: fmt.Sprintf("%s-%s is not a valid range", r.Opens, r.Closes)}
+		}
+
+		for _, other := range e.Hours[i+1:] {
+			if int(r.Opens) < int(other.Opens)+other.minutes() && int(other.Opens) < int(r.Opens)+r.minutes() {
+				return &ValidationError{Field: "hours", Message: fmt.Sprintf("%s-%s overlaps %s-%s", r.Opens, r.Closes, other.Opens, other.Closes)}
+			}
+		}
+	}
+
+	return nil
+}
+
+// interval is a concrete period the store is open.
+type interval struct {
+	start time.Time
+	end   time.Time
+}
+
+// rangesOn returns the ranges that open on the date, from the exception of the date if there is one.
+func (s Schedule) rangesOn(year int, month time.Month, day int, weekday time.Weekday) []TimeRange {
+	for _, exception := range s.Exceptions {
+		y, m, d := exception.Date.Date()
+		if y == year && m == month && d == day {
+			return exception.Hours
+		}
+	}
+
+	var ranges []TimeRange
+	for _, h := range s.Weekly {
+		if h.Weekday == weekday {
+			ranges = append(ranges, h.TimeRange)
+		}
+	}
+
+	return ranges
+}
+
+// intervals returns the merged opening intervals that open from the local date of t shifted by fromDays,
+// for the given number of days.
+func (s Schedule) intervals(t time.Time, location *time.Location, fromDays int, days int) []interval {
+	year, month, day := t.In(location).Date()
+
+	var intervals []interval
+	for offset := fromDays; offset < fromDays+days; offset++ {
+		date := time.Date(year, month, day+offset, 0, 0, 0, 0, location)
+		y, m, d := date.Date()
+
+		for _, r := range s.rangesOn(y, m, d, date.Weekday()) {
+			closesDay := d
+			if r.Closes <= r.Opens {
+				closesDay++
+			}
+
+			intervals = append(intervals, interval{
+				start: time.Date(y, m, d, 0, int(r.Opens), 0, 0, location),
+				end:   time.Date(y, m, closesDay, 0, int(r.Closes), 0, 0, location),
+			})
+		}
+	}
+
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i].start.Before(intervals[j].start)
+	})
+
+	// Back-to-back ranges, such as 18:00-24:00 and 00:00-02:00, are a single opening.
+	merged := intervals[:0]
+	for _, i := range intervals {
+		if len(merged) > 0 && !i.start.After(merged[len(merged)-1].end) {
+			if i.end.After(merged[len(merged)-1].end) {
+				merged[len(merged)-1].end = i.end
+			}
+			continue
+		}
+		merged = append(merged, i)
+	}
+
+	return merged
+}
+
+// IsOpenAt reports whether the store is supposed to be open at the given time.
+func (s Schedule) IsOpenAt(t time.Time, location *time.Location) bool {
+	for _, i := range s.intervals(t, location, -1, 2) {
+		if !t.Before(i.start) && t.Before(i.end) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NextOpening returns when the store is supposed to open next after the given time. It returns false if it
+// does not open within a month.
+func (s Schedule) NextOpening(t time.Time, location *time.Location) (time.Time, bool) {
+	for _, i := range s.intervals(t, location, -1, scheduleLookahead+1) {
+		if i.start.After(t) {
+			return i.start, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// StateAt returns whether the store is supposed to be open at the given time, and since when, that is the
+// last opening or closing at or before it. It returns false if there has been neither in the last week.
+func (s Schedule) StateAt(t time.Time, location *time.Location) (open bool, since time.Time, ok bool) {
+	for _, i := range s.intervals(t, location, -scheduleLookbehind, scheduleLookbehind+1) {
+		if i.start.After(t) {
+			break
+		}
+
+		if t.Before(i.end) {
+			open, since, ok = true, i.start, true
+		} else {
+			open, since, ok = false, i.end, true
+		}
+	}
+
+	return open, since, ok
+}
+
+// ScheduledStateChange returns the change to bring the store in line with its schedule at the given time.
+// Whichever is the latest wins, the schedule or the cashier: a store opened by its cashier after the
+// scheduled closing stays open. A paused store counts as open, it's only closed by the schedule. It
+// returns false if there is nothing to change.
+func (s Store) ScheduledStateChange(schedule Schedule, now time.Time) (StateChange, bool) {
+	if s.Status != StatusActive {
+		return StateChange{}, false
+	}
+
+	open, since, ok := schedule.StateAt(now, s.Location())
+	if !ok || !since.After(s.StateUpdatedAt) {
+		return StateChange{}, false
+	}
+
+	current := s.StateAt(now)
+	switch {
+	case open && current == StateClosed:
+		return StateChange{State: StateOpen}, true
+	case !open && current != StateClosed:
+		return StateChange{State: StateClosed}, true
+	default:
+		return StateChange{}, false
+	}
+}
+
+// NextOpening returns when the store opens next if it's not open at the given time: the resume time of a
+// paused store, or the next scheduled opening. It returns false if the store is open, or it's not known
+// when it opens.
+func (s Store) NextOpening(schedule Schedule, now time.Time) (time.Time, bool) {
+	if s.Status != StatusActive || s.CheckOpen(now) == nil {
+		return time.Time{}, false
+	}
+
+	if s.StateAt(now) == StatePaused {
+		return s.ResumeAt, true
+	}
+
+	return schedule.NextOpening(now, s.Location())
+}
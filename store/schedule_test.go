@@ -0,0 +1,321 @@
+package store_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"coffee-chain-api/store"
+)
+
+func TestParseTimeOfDay(t *testing.T) {
+	testCases := []struct {
+		input  string
+		expect store.TimeOfDay
+		ok     bool
+	}{
+		{input: "00:00", expect: 0, ok: true},
+		{input: "07:30", expect: 450, ok: true},
+		{input: "23:59", expect: 1439, ok: true},
+		{input: "24:00", expect: 1440, ok: true},
+		{input: "24:01", ok: false},
+		{input: "12:60", ok: false},
+		{input: "7:30", ok: false},
+		{input: "07.30", ok: false},
+		{input: "ab:cd", ok: false},
+		{input: "", ok: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok := store.ParseTimeOfDay(tt.input)
+			if ok != tt.ok || (ok && got != tt.expect) {
+				t.Errorf("expecting %s (%t), got %s (%t) instead", tt.expect, tt.ok, got, ok)
+			}
+			if ok && got.String() != tt.input {
+				t.Errorf("expecting %s to format back into itself, got %s instead", tt.input, got)
+			}
+		})
+	}
+}
+
+func hours(weekday time.Weekday, opens string, closes string) store.OpeningHours {
+	o, _ := store.ParseTimeOfDay(opens)
+	c, _ := store.ParseTimeOfDay(closes)
+	return store.OpeningHours{Weekday: weekday, TimeRange: store.TimeRange{Opens: o, Closes: c}}
+}
+
+func timeRange(opens string, closes string) store.TimeRange {
+	return hours(time.Sunday, opens, closes).TimeRange
+}
+
+func TestValidateOpeningHours(t *testing.T) {
+	testCases := []struct {
+		name  string
+		hours []store.OpeningHours
+		valid bool
+	}{
+		{name: "empty", hours: nil, valid: true},
+		{name: "regular", hours: []store.OpeningHours{hours(time.Monday, "07:00", "22:00"), hours(time.Tuesday, "07:00", "22:00")}, valid: true},
+		{name: "split shift", hours: []store.OpeningHours{hours(time.Monday, "07:00", "12:00"), hours(time.Monday, "13:00", "22:00")}, valid: true},
+		{name: "back to back", hours: []store.OpeningHours{hours(time.Monday, "07:00", "12:00"), hours(time.Monday, "12:00", "22:00")}, valid: true},
+		{name: "until midnight", hours: []store.OpeningHours{hours(time.Monday, "07:00", "24:00")}, valid: true},
+		{name: "overnight", hours: []store.OpeningHours{hours(time.Friday, "18:00", "02:00"), hours(time.Saturday, "07:00", "22:00")}, valid: true},
+		{name: "same day overlap", hours: []store.OpeningHours{hours(time.Monday, "07:00", "13:00"), hours(time.Monday, "12:00", "22:00")}, valid: false},
+		{name: "overnight overlap", hours: []store.OpeningHours{hours(time.Friday, "18:00", "08:00"), hours(time.Saturday, "07:00", "22:00")}, valid: false},
+		{name: "saturday overnight overlaps sunday", hours: []store.OpeningHours{hours(time.Saturday, "18:00", "08:00"), hours(time.Sunday, "07:00", "22:00")}, valid: false},
+		{name: "empty range", hours: []store.OpeningHours{hours(time.Monday, "07:00", "07:00")}, valid: false},
+		{name: "invalid weekday", hours: []store.OpeningHours{hours(7, "07:00", "22:00")}, valid: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := store.ValidateOpeningHours(tt.hours)
+			if tt.valid && err != nil {
+				t.Errorf("expecting no error, got %v instead", err)
+			}
+			if !tt.valid && !errors.Is(err, store.ErrInvalidStore) {
+				t.Errorf("expecting a validation error, got %v instead", err)
+			}
+		})
+	}
+}
+
+func TestScheduleException_Validate(t *testing.T) {
+	date := time.Date(2024, 4, 10, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name      string
+		exception store.ScheduleException
+		field     string
+	}{
+		{name: "closed all day", exception: store.ScheduleException{Date: date, Note: "Eid al-Fitr"}},
+		{name: "special hours", exception: store.ScheduleException{Date: date, Hours: []store.TimeRange{timeRange("10:00", "14:00")}}},
+		{name: "missing date", exception: store.ScheduleException{}, field: "date"},
+		{name: "overlapping hours", exception: store.ScheduleException{Date: date, Hours: []store.TimeRange{timeRange("10:00", "14:00"), timeRange("13:00", "15:00")}}, field: "hours"},
+		{name: "invalid hours", exception: store.ScheduleException{Date: date, Hours: []store.TimeRange{{Opens: 1440, Closes: 60}}}, field: "hours"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.exception.Validate()
+			if tt.field == "" {
+				if err != nil {
+					t.Errorf("expecting no error, got %v instead", err)
+				}
+				return
+			}
+
+			var validationError *store.ValidationError
+			if !errors.As(err, &validationError) || validationError.Field != tt.field {
+				t.Errorf("expecting invalid %s, got %v instead", tt.field, err)
+			}
+		})
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(day int, hour int, minute int) time.Time {
+		// March 2024, the 18th is a Monday.
+		return time.Date(2024, 3, day, hour, minute, 0, 0, jakarta)
+	}
+
+	schedule := store.Schedule{
+		Weekly: []store.OpeningHours{
+			hours(time.Monday, "07:00", "22:00"),
+			hours(time.Tuesday, "07:00", "22:00"),
+			hours(time.Wednesday, "07:00", "22:00"),
+			hours(time.Friday, "18:00", "24:00"),
+			hours(time.Saturday, "00:00", "02:00"),
+		},
+		Exceptions: []store.ScheduleException{
+			// Closed all day on the Tuesday.
+			{Date: time.Date(2024, 3, 19, 0, 0, 0, 0, time.UTC), Note: "Holiday"},
+			// Shorter hours on the Wednesday.
+			{Date: time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC), Hours: []store.TimeRange{timeRange("10:00", "14:00")}},
+		},
+	}
+
+	t.Run("IsOpenAt", func(t *testing.T) {
+		testCases := []struct {
+			name   string
+			t      time.Time
+			expect bool
+		}{
+			{name: "before opening", t: at(18, 6, 59), expect: false},
+			{name: "at opening", t: at(18, 7, 0), expect: true},
+			{name: "at closing", t: at(18, 22, 0), expect: false},
+			{name: "holiday", t: at(19, 12, 0), expect: false},
+			{name: "special hours", t: at(20, 12, 0), expect: true},
+			{name: "after special hours", t: at(20, 15, 0), expect: false},
+			{name: "past midnight", t: at(23, 1, 0), expect: true},
+			{name: "same time in another timezone", t: at(18, 7, 0).In(time.UTC), expect: true},
+		}
+
+		for _, tt := range testCases {
+			t.Run(tt.name, func(t *testing.T) {
+				if got := schedule.IsOpenAt(tt.t, jakarta); got != tt.expect {
+					t.Errorf("expecting %t, got %t instead", tt.expect, got)
+				}
+			})
+		}
+	})
+
+	t.Run("NextOpening", func(t *testing.T) {
+		testCases := []struct {
+			name   string
+			t      time.Time
+			expect time.Time
+		}{
+			{name: "later today", t: at(18, 5, 0), expect: at(18, 7, 0)},
+			{name: "skips the holiday", t: at(18, 23, 0), expect: at(20, 10, 0)},
+			{name: "evening opening", t: at(22, 12, 0), expect: at(22, 18, 0)},
+			{name: "back to back ranges are one opening", t: at(22, 19, 0), expect: at(25, 7, 0)},
+			{name: "next week", t: at(23, 3, 0), expect: at(25, 7, 0)},
+		}
+
+		for _, tt := range testCases {
+			t.Run(tt.name, func(t *testing.T) {
+				got, ok := schedule.NextOpening(tt.t, jakarta)
+				if !ok || !got.Equal(tt.expect) {
+					t.Errorf("expecting %s, got %s (%t) instead", tt.expect, got, ok)
+				}
+			})
+		}
+
+		_, ok := store.Schedule{}.NextOpening(at(18, 5, 0), jakarta)
+		if ok {
+			t.Errorf("expecting no opening for an empty schedule")
+		}
+	})
+
+	t.Run("StateAt", func(t *testing.T) {
+		testCases := []struct {
+			name        string
+			t           time.Time
+			expectOpen  bool
+			expectSince time.Time
+		}{
+			{name: "open", t: at(18, 12, 0), expectOpen: true, expectSince: at(18, 7, 0)},
+			{name: "closed", t: at(18, 23, 0), expectOpen: false, expectSince: at(18, 22, 0)},
+			{name: "closed through the holiday", t: at(19, 12, 0), expectOpen: false, expectSince: at(18, 22, 0)},
+			{name: "past midnight", t: at(23, 1, 0), expectOpen: true, expectSince: at(22, 18, 0)},
+		}
+
+		for _, tt := range testCases {
+			t.Run(tt.name, func(t *testing.T) {
+				open, since, ok := schedule.StateAt(tt.t, jakarta)
+				if !ok || open != tt.expectOpen || !since.Equal(tt.expectSince) {
+					t.Errorf("expecting %t since %s, got %t since %s (%t) instead", tt.expectOpen, tt.expectSince, open, since, ok)
+				}
+			})
+		}
+
+		_, _, ok := store.Schedule{}.StateAt(at(18, 12, 0), jakarta)
+		if ok {
+			t.Errorf("expecting no state for an empty schedule")
+		}
+	})
+}
+
+func TestStore_ScheduledStateChange(t *testing.T) {
+	schedule := store.Schedule{Weekly: []store.OpeningHours{hours(time.Monday, "07:00", "22:00")}}
+	at := func(hour int, minute int) time.Time {
+		return time.Date(2024, 3, 18, hour, minute, 0, 0, time.UTC)
+	}
+
+	testCases := []struct {
+		name   string
+		store  store.Store
+		now    time.Time
+		expect store.State
+		ok     bool
+	}{
+		{
+			name:   "opens at the scheduled opening",
+			store:  store.Store{Status: store.StatusActive, Timezone: "UTC", State: store.StateClosed, StateUpdatedAt: at(0, 0)},
+			now:    at(7, 1),
+			expect: store.StateOpen,
+			ok:     true,
+		},
+		{
+			name:   "never set before",
+			store:  store.Store{Status: store.StatusActive, Timezone: "UTC", State: store.StateClosed},
+			now:    at(7, 1),
+			expect: store.StateOpen,
+			ok:     true,
+		},
+		{
+			name:  "closed by the cashier after the opening",
+			store: store.Store{Status: store.StatusActive, Timezone: "UTC", State: store.StateClosed, StateUpdatedAt: at(9, 0)},
+			now:   at(10, 0),
+			ok:    false,
+		},
+		{
+			name:   "closes at the scheduled closing",
+			store:  store.Store{Status: store.StatusActive, Timezone: "UTC", State: store.StateOpen, StateUpdatedAt: at(7, 0)},
+			now:    at(22, 1),
+			expect: store.StateClosed,
+			ok:     true,
+		},
+		{
+			name:   "paused store closes at the scheduled closing",
+			store:  store.Store{Status: store.StatusActive, Timezone: "UTC", State: store.StatePaused, ResumeAt: at(23, 0), StateUpdatedAt: at(21, 0)},
+			now:    at(22, 1),
+			expect: store.StateClosed,
+			ok:     true,
+		},
+		{
+			name:  "paused store stays paused while open",
+			store: store.Store{Status: store.StatusActive, Timezone: "UTC", State: store.StatePaused, ResumeAt: at(11, 0), StateUpdatedAt: at(6, 0)},
+			now:   at(10, 0),
+			ok:    false,
+		},
+		{
+			name:  "inactive store",
+			store: store.Store{Status: store.StatusInactive, Timezone: "UTC", State: store.StateClosed},
+			now:   at(7, 1),
+			ok:    false,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			change, ok := tt.store.ScheduledStateChange(schedule, tt.now)
+			if ok != tt.ok || (ok && change.State != tt.expect) {
+				t.Errorf("expecting %s (%t), got %s (%t) instead", tt.expect, tt.ok, change.State, ok)
+			}
+		})
+	}
+}
+
+func TestStore_NextOpening(t *testing.T) {
+	schedule := store.Schedule{Weekly: []store.OpeningHours{hours(time.Monday, "07:00", "22:00")}}
+	now := time.Date(2024, 3, 18, 5, 0, 0, 0, time.UTC)
+	resumeAt := now.Add(time.Minute * 10)
+
+	testCases := []struct {
+		name   string
+		store  store.Store
+		expect time.Time
+		ok     bool
+	}{
+		{name: "closed", store: store.Store{Status: store.StatusActive, Timezone: "UTC", State: store.StateClosed}, expect: time.Date(2024, 3, 18, 7, 0, 0, 0, time.UTC), ok: true},
+		{name: "paused", store: store.Store{Status: store.StatusActive, Timezone: "UTC", State: store.StatePaused, ResumeAt: resumeAt}, expect: resumeAt, ok: true},
+		{name: "open", store: store.Store{Status: store.StatusActive, Timezone: "UTC", State: store.StateOpen}, ok: false},
+		{name: "inactive", store: store.Store{Status: store.StatusInactive, Timezone: "UTC", State: store.StateClosed}, ok: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.store.NextOpening(schedule, now)
+			if ok != tt.ok || !got.Equal(tt.expect) {
+				t.Errorf("expecting %s (%t), got %s (%t) instead", tt.expect, tt.ok, got, ok)
+			}
+		})
+	}
+}
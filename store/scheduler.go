@@ -0,0 +1,88 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// RunScheduler opens and closes the active stores according to their schedules, once every interval,
+// until ctx is done. See Store.ScheduledStateChange for how it goes along with the cashiers.
+//
+// Nothing else changes the state on schedule, so the server command must start it in its own goroutine
+// along with the server from server.NewServer, for as long as the server runs.
+func RunScheduler(ctx context.Context, storeRepository StoreRepository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		changed, err := applySchedules(ctx, storeRepository, time.Now())
+		if err != nil {
+			log.Error().Err(err).Msg("applying store schedules")
+		} else if changed > 0 {
+			log.Info().Int("stores", changed).Msg("applied store schedules")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// applySchedules applies the schedules of every active store, and returns the number of stores that have
+// been opened or closed.
+func applySchedules(ctx context.Context, storeRepository StoreRepository, now time.Time) (int, error) {
+	changed := 0
+
+	filter := ListFilter{Status: StatusActive, Limit: MaximumListLimit}
+	for {
+		result, err := storeRepository.List(ctx, filter)
+		if err != nil {
+			return changed, fmt.Errorf("listing stores: %w", err)
+		}
+
+		storeIds := make([]int64, 0, len(result.Stores))
+		for _, s := range result.Stores {
+			storeIds = append(storeIds, s.ID)
+		}
+
+		schedules, err := storeRepository.GetSchedules(ctx, storeIds, ScheduleFrom(now))
+		if err != nil {
+			return changed, fmt.Errorf("acquiring schedules: %w", err)
+		}
+
+		for _, s := range result.Stores {
+			change, ok := s.ScheduledStateChange(schedules[s.ID], now)
+			if !ok {
+				continue
+			}
+
+			// The change is decided on the listed store, so it's only applied if nobody has changed the
+			// state since.
+			stateUpdatedAt := s.StateUpdatedAt
+			change.IfUnchangedSince = &stateUpdatedAt
+
+			_, err = storeRepository.SetState(ctx, s.ID, change)
+			if err != nil {
+				// The store may have been deleted, or changed by its cashier, in the meantime.
+				if errors.Is(err, ErrNotFound) || errors.Is(err, ErrStateChanged) || errors.Is(err, ErrInvalidStateChange) {
+					continue
+				}
+
+				return changed, fmt.Errorf("setting state of store %d: %w", s.ID, err)
+			}
+
+			changed++
+		}
+
+		if result.NextCursor == 0 {
+			return changed, nil
+		}
+		filter.Cursor = result.NextCursor
+	}
+}
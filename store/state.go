@@ -0,0 +1,142 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrStoreNotOpen indicates that the store does not take orders right now.
+var ErrStoreNotOpen = errors.New("store is not open")
+
+// ErrInvalidStateChange indicates that the store can not go from its current state into the requested one,
+// such as pausing a closed store.
+var ErrInvalidStateChange = errors.New("invalid store state change")
+
+// ErrStateChanged indicates that a conditional state change was not applied, because the state has been
+// changed since it was decided on. See StateChange.IfUnchangedSince.
+var ErrStateChanged = errors.New("store state has changed in the meantime")
+
+// State is whether the store is serving right now, as set by its cashiers.
+type State uint8
+
+const (
+	// StateClosed is the state of new stores, until a cashier opens it.
+	StateClosed State = iota
+	StateOpen
+	// StatePaused stores are open but not taking orders for a while, such as when the espresso machine
+	// breaks down. They resume automatically at their resume time.
+	StatePaused
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StatePaused:
+		return "paused"
+	default:
+		return ""
+	}
+}
+
+// ParseState parses the state from its name, e.g. "paused".
+func ParseState(s string) (State, bool) {
+	switch s {
+	case "closed":
+		return StateClosed, true
+	case "open":
+		return StateOpen, true
+	case "paused":
+		return StatePaused, true
+	default:
+		return 0, false
+	}
+}
+
+const (
+	// MaximumPauseDuration is how far ahead a pause can be set to resume. Anything longer should close
+	// the store instead.
+	MaximumPauseDuration = time.Hour * 12
+	maximumReasonLength  = 255
+)
+
+// StateChange is a request to move the store into another state.
+type StateChange struct {
+	State State
+	// PauseReason is shown to the customers, it's required when pausing and ignored otherwise.
+	PauseReason string
+	// ResumeAt is when a paused store opens again, it's required when pausing and ignored otherwise.
+	ResumeAt time.Time
+	// IfUnchangedSince makes the change conditional on the state having been last set at this time, the
+	// zero time being a state that has never been set. It keeps a change decided on an earlier read of the
+	// store from overriding one made in the meantime. Nil applies the change regardless.
+	IfUnchangedSince *time.Time
+}
+
+// StateAt returns the state of the store at the given time, with expired pauses resumed.
+func (s Store) StateAt(now time.Time) State {
+	if s.State == StatePaused && !s.ResumeAt.After(now) {
+		return StateOpen
+	}
+
+	return s.State
+}
+
+// CheckOpen returns ErrStoreNotOpen if the store can not take orders at the given time.
+func (s Store) CheckOpen(now time.Time) error {
+	if s.Status != StatusActive || s.StateAt(now) != StateOpen {
+		return ErrStoreNotOpen
+	}
+
+	return nil
+}
+
+// ApplyStateChange returns the store moved into the requested state. A closed store can only be opened, an
+// open store can be closed or paused, and a paused store can be opened early, closed, or paused again with
+// another reason or resume time. Opening an open store or closing a closed one is allowed, but it's not a
+// change.
+func (s Store) ApplyStateChange(change StateChange, now time.Time) (updated Store, changed bool, err error) {
+	if change.IfUnchangedSince != nil && !s.StateUpdatedAt.Equal(*change.IfUnchangedSince) {
+		return Store{}, false, ErrStateChanged
+	}
+
+	current := s.StateAt(now)
+
+	switch change.State {
+	case StateOpen, StateClosed:
+		if current == change.State {
+			return s, false, nil
+		}
+
+		s.State = change.State
+		s.PauseReason = ""
+		s.ResumeAt = time.Time{}
+	case StatePaused:
+		if current == StateClosed {
+			return Store{}, false, fmt.Errorf("%w: a closed store can not be paused", ErrInvalidStateChange)
+		}
+
+		reason := strings.TrimSpace(change.PauseReason)
+		if reason == "" || utf8.RuneCountInString(reason) > maximumReasonLength {
+			return Store{}, false, &ValidationError{Field: "pause_reason", Message: fmt.Sprintf("must be between 1 and %d characters", maximumReasonLength)}
+		}
+		if !change.ResumeAt.After(now) || change.ResumeAt.Sub(now) > MaximumPauseDuration {
+			return Store{}, false, &ValidationError{Field: "resume_at", Message: fmt.Sprintf("must be within the next %d hours", int(MaximumPauseDuration.Hours()))}
+		}
+
+		s.State = StatePaused
+		s.PauseReason = reason
+		s.ResumeAt = change.ResumeAt
+	default:
+		return Store{}, false, &ValidationError{Field: "state", Message: "must be one of open, closed, or paused"}
+	}
+
+	s.StateUpdatedAt = now
+
+	return s, true, nil
+}
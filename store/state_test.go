@@ -0,0 +1,211 @@
+package store_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"coffee-chain-api/store"
+)
+
+func TestParseState(t *testing.T) {
+	testCases := []struct {
+		input  string
+		expect store.State
+		ok     bool
+	}{
+		{input: "open", expect: store.StateOpen, ok: true},
+		{input: "closed", expect: store.StateClosed, ok: true},
+		{input: "paused", expect: store.StatePaused, ok: true},
+		{input: "Open", ok: false},
+		{input: "", ok: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok := store.ParseState(tt.input)
+			if ok != tt.ok || (ok && got != tt.expect) {
+				t.Errorf("expecting %s (%t), got %s (%t) instead", tt.expect, tt.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestStore_StateAt(t *testing.T) {
+	now := time.Date(2024, 3, 18, 10, 0, 0, 0, time.UTC)
+	paused := store.Store{State: store.StatePaused, PauseReason: "Restocking", ResumeAt: now.Add(time.Minute * 15)}
+
+	if got := paused.StateAt(now); got != store.StatePaused {
+		t.Errorf("expecting paused, got %s instead", got)
+	}
+	if got := paused.StateAt(now.Add(time.Minute * 15)); got != store.StateOpen {
+		t.Errorf("expecting open at the resume time, got %s instead", got)
+	}
+}
+
+func TestStore_CheckOpen(t *testing.T) {
+	now := time.Date(2024, 3, 18, 10, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name   string
+		store  store.Store
+		isOpen bool
+	}{
+		{name: "open", store: store.Store{Status: store.StatusActive, State: store.StateOpen}, isOpen: true},
+		{name: "closed", store: store.Store{Status: store.StatusActive, State: store.StateClosed}, isOpen: false},
+		{name: "paused", store: store.Store{Status: store.StatusActive, State: store.StatePaused, ResumeAt: now.Add(time.Minute)}, isOpen: false},
+		{name: "pause expired", store: store.Store{Status: store.StatusActive, State: store.StatePaused, ResumeAt: now.Add(-time.Minute)}, isOpen: true},
+		{name: "inactive", store: store.Store{Status: store.StatusInactive, State: store.StateOpen}, isOpen: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.store.CheckOpen(now)
+			if tt.isOpen && err != nil {
+				t.Errorf("expecting no error, got %v instead", err)
+			}
+			if !tt.isOpen && !errors.Is(err, store.ErrStoreNotOpen) {
+				t.Errorf("expecting ErrStoreNotOpen, got %v instead", err)
+			}
+		})
+	}
+}
+
+func TestStore_ApplyStateChange(t *testing.T) {
+	now := time.Date(2024, 3, 18, 10, 0, 0, 0, time.UTC)
+	resumeAt := now.Add(time.Minute * 30)
+	hourAgo := now.Add(-time.Hour)
+	var neverSet time.Time
+
+	testCases := []struct {
+		name    string
+		current store.Store
+		change  store.StateChange
+		expect  store.State
+		changed bool
+		err     error
+		field   string
+	}{
+		{
+			name:    "open a closed store",
+			current: store.Store{State: store.StateClosed},
+			change:  store.StateChange{State: store.StateOpen},
+			expect:  store.StateOpen,
+			changed: true,
+		},
+		{
+			name:    "open an open store",
+			current: store.Store{State: store.StateOpen},
+			change:  store.StateChange{State: store.StateOpen},
+			expect:  store.StateOpen,
+			changed: false,
+		},
+		{
+			name:    "pause an open store",
+			current: store.Store{State: store.StateOpen},
+			change:  store.StateChange{State: store.StatePaused, PauseReason: " Machine maintenance ", ResumeAt: resumeAt},
+			expect:  store.StatePaused,
+			changed: true,
+		},
+		{
+			name:    "pause a closed store",
+			current: store.Store{State: store.StateClosed},
+			change:  store.StateChange{State: store.StatePaused, PauseReason: "Machine maintenance", ResumeAt: resumeAt},
+			err:     store.ErrInvalidStateChange,
+		},
+		{
+			name:    "pause without reason",
+			current: store.Store{State: store.StateOpen},
+			change:  store.StateChange{State: store.StatePaused, ResumeAt: resumeAt},
+			field:   "pause_reason",
+		},
+		{
+			name:    "pause resuming in the past",
+			current: store.Store{State: store.StateOpen},
+			change:  store.StateChange{State: store.StatePaused, PauseReason: "Machine maintenance", ResumeAt: now},
+			field:   "resume_at",
+		},
+		{
+			name:    "pause for too long",
+			current: store.Store{State: store.StateOpen},
+			change:  store.StateChange{State: store.StatePaused, PauseReason: "Machine maintenance", ResumeAt: now.Add(store.MaximumPauseDuration + time.Minute)},
+			field:   "resume_at",
+		},
+		{
+			name:    "resume a paused store early",
+			current: store.Store{State: store.StatePaused, PauseReason: "Machine maintenance", ResumeAt: resumeAt},
+			change:  store.StateChange{State: store.StateOpen},
+			expect:  store.StateOpen,
+			changed: true,
+		},
+		{
+			name:    "close a paused store",
+			current: store.Store{State: store.StatePaused, PauseReason: "Machine maintenance", ResumeAt: resumeAt},
+			change:  store.StateChange{State: store.StateClosed},
+			expect:  store.StateClosed,
+			changed: true,
+		},
+		{
+			name:    "conditional change on an unchanged store",
+			current: store.Store{State: store.StateOpen, StateUpdatedAt: hourAgo},
+			change:  store.StateChange{State: store.StateClosed, IfUnchangedSince: &hourAgo},
+			expect:  store.StateClosed,
+			changed: true,
+		},
+		{
+			name:    "conditional change on a store changed in the meantime",
+			current: store.Store{State: store.StateOpen, StateUpdatedAt: now.Add(-time.Minute)},
+			change:  store.StateChange{State: store.StateClosed, IfUnchangedSince: &hourAgo},
+			err:     store.ErrStateChanged,
+		},
+		{
+			name:    "conditional change on a store never changed",
+			current: store.Store{State: store.StateClosed},
+			change:  store.StateChange{State: store.StateOpen, IfUnchangedSince: &neverSet},
+			expect:  store.StateOpen,
+			changed: true,
+		},
+		{
+			name:    "unknown state",
+			current: store.Store{State: store.StateOpen},
+			change:  store.StateChange{State: 9},
+			field:   "state",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed, err := tt.current.ApplyStateChange(tt.change, now)
+			if tt.field != "" {
+				var validationError *store.ValidationError
+				if !errors.As(err, &validationError) || validationError.Field != tt.field {
+					t.Fatalf("expecting invalid %s, got %v instead", tt.field, err)
+				}
+				return
+			}
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expecting %v, got %v instead", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expecting no error, got %v instead", err)
+			}
+
+			if got.State != tt.expect {
+				t.Errorf("expecting %s, got %s instead", tt.expect, got.State)
+			}
+			if changed != tt.changed {
+				t.Errorf("expecting changed %t, got %t instead", tt.changed, changed)
+			}
+			if got.State == store.StatePaused {
+				if got.PauseReason != "Machine maintenance" || !got.ResumeAt.Equal(resumeAt) {
+					t.Errorf("expecting the pause reason and resume time to be set, got %q and %s instead", got.PauseReason, got.ResumeAt)
+				}
+			} else if got.PauseReason != "" || !got.ResumeAt.IsZero() {
+				t.Errorf("expecting the pause to be cleared, got %q and %s instead", got.PauseReason, got.ResumeAt)
+			}
+		})
+	}
+}
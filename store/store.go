@@ -0,0 +1,241 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	// Embed the timezone database, so store timezones can be loaded on hosts without one.
+	_ "time/tzdata"
+	"unicode/utf8"
+)
+
+var ErrNotFound = errors.New("store not found")
+
+// ErrInvalidStore is matched by every *ValidationError.
+var ErrInvalidStore = errors.New("invalid store")
+
+// DefaultTimezone is where most of the stores are.
+const DefaultTimezone = "Asia/Jakarta"
+
+// Status is the administrative status of a store, whether it's part of the chain at all. It's separate
+// from whether the store is serving right now.
+type Status uint8
+
+const (
+	StatusUnspecified Status = iota
+	// StatusActive stores are shown to the customers.
+	StatusActive
+	// StatusInactive stores are hidden from the customers, such as one being renovated or not opened yet.
+	StatusInactive
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusActive:
+		return "active"
+	case StatusInactive:
+		return "inactive"
+	default:
+		return ""
+	}
+}
+
+// ParseStatus parses the status from its name, e.g. "active".
+func ParseStatus(s string) (Status, bool) {
+	switch s {
+	case "active":
+		return StatusActive, true
+	case "inactive":
+		return StatusInactive, true
+	default:
+		return StatusUnspecified, false
+	}
+}
+
+type Store struct {
+	ID        int64
+	Name      string
+	Address   string
+	Latitude  float64
+	Longitude float64
+	// Phone is empty if the store has no phone number.
+	Phone string
+	// Timezone is the IANA name of the store's timezone, such as "Asia/Makassar".
+	Timezone string
+	Status   Status
+	// State is the state as last set, use StateAt for the effective state.
+	State State
+	// PauseReason and ResumeAt are only set while the store is paused.
+	PauseReason    string
+	ResumeAt       time.Time
+	StateUpdatedAt time.Time
+	StateUpdatedBy string
+	CreatedAt      time.Time
+	CreatedBy      string
+	UpdatedAt      time.Time
+	UpdatedBy      string
+}
+
+// Location returns the store's timezone. It falls back to UTC if the timezone can not be loaded, which
+// does not happen for stores that passed RawStore.Validate.
+func (s Store) Location() *time.Location {
+	location, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return location
+}
+
+// RawStore holds the values of a store to be inserted or to replace an existing one.
+type RawStore struct {
+	Name      string
+	Address   string
+	Latitude  float64
+	Longitude float64
+	Phone     string
+	// Timezone defaults to DefaultTimezone if empty.
+	Timezone string
+	// Status defaults to StatusActive if unspecified.
+	Status Status
+}
+
+// ValidationError describes the first invalid field of a RawStore or a StateChange.
+type ValidationError struct {
+	// Field is the snake_cased field name, such as "latitude".
+	Field   string
+	Message string
+}
+
+func (v *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", v.Field, v.Message)
+}
+
+func (v *ValidationError) Is(target error) bool {
+	return target == ErrInvalidStore
+}
+
+const (
+	maximumNameLength    = 255
+	maximumAddressLength = 1023
+	maximumPhoneLength   = 32
+)
+
+// Normalize trims the text fields and fills in the defaults.
+func (s RawStore) Normalize() RawStore {
+	s.Name = strings.TrimSpace(s.Name)
+	s.Address = strings.TrimSpace(s.Address)
+	s.Phone = strings.TrimSpace(s.Phone)
+	s.Timezone = strings.TrimSpace(s.Timezone)
+	if s.Timezone == "" {
+		s.Timezone = DefaultTimezone
+	}
+	if s.Status == StatusUnspecified {
+		s.Status = StatusActive
+	}
+
+	return s
+}
+
+// Validate checks a normalized RawStore, and returns a *ValidationError for the first invalid field.
+func (s RawStore) Validate() error {
+	if s.Name == "" || utf8.RuneCountInString(s.Name) > maximumNameLength {
+		return &ValidationError{Field: "name", Message: fmt.Sprintf("must be between 1 and %d characters", maximumNameLength)}
+	}
+	if s.Address == "" || utf8.RuneCountInString(s.Address) > maximumAddressLength {
+		return &ValidationError{Field: "address", Message: fmt.Sprintf("must be between 1 and %d characters", maximumAddressLength)}
+	}
+	if s.Latitude < -90 || s.Latitude > 90 {
+		return &ValidationError{Field: "latitude", Message: "must be between -90 and 90"}
+	}
+	if s.Longitude < -180 || s.Longitude > 180 {
+		return &ValidationError{Field: "longitude", Message: "must be between -180 and 180"}
+	}
+	// Null Island is far more likely to be a missing coordinate than a coffee shop.
+	if s.Latitude == 0 && s.Longitude == 0 {
+		return &ValidationError{Field: "latitude", Message: "coordinates are required"}
+	}
+	if !validPhone(s.Phone) {
+		return &ValidationError{Field: "phone", Message: fmt.Sprintf("must be at most %d digits, spaces, and +-() characters", maximumPhoneLength)}
+	}
+	// "Local" is accepted by time.LoadLocation, but means the timezone of the server.
+	if _, err := time.LoadLocation(s.Timezone); err != nil || s.Timezone == "Local" {
+		return &ValidationError{Field: "timezone", Message: "must be an IANA timezone name, such as Asia/Jakarta"}
+	}
+	if s.Status != StatusActive && s.Status != StatusInactive {
+		return &ValidationError{Field: "status", Message: "must be either active or inactive"}
+	}
+
+	return nil
+}
+
+func validPhone(phone string) bool {
+	if len(phone) > maximumPhoneLength {
+		return false
+	}
+
+	for _, r := range phone {
+		if (r < '0' || r > '9') && !strings.ContainsRune("+-() ", r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+const (
+	// DefaultListLimit is the page size of StoreRepository.List if ListFilter.Limit is not set.
+	DefaultListLimit = 50
+	// MaximumListLimit is the largest page size of StoreRepository.List.
+	MaximumListLimit = 200
+)
+
+// ListFilter narrows down the stores returned by StoreRepository.List. Zero values are not filtered on.
+type ListFilter struct {
+	Status Status
+	// Name is matched case-insensitively against any part of the store's name.
+	Name string
+	// Cursor is the NextCursor of the previous page, or zero for the first page.
+	Cursor int64
+	Limit  int
+}
+
+type ListResult struct {
+	Stores []Store
+	// NextCursor is zero if there is no next page.
+	NextCursor int64
+}
+
+type StoreRepository interface {
+	GetByID(ctx context.Context, id int64) (Store, error)
+	// GetByIDForShare acquires the store in tx and locks it FOR SHARE, so that its state can not change
+	// until tx ends.
+	GetByIDForShare(ctx context.Context, tx *sql.Tx, id int64) (Store, error)
+	// List returns stores ordered by their ID, paginated with the ID as the cursor.
+	List(ctx context.Context, filter ListFilter) (ListResult, error)
+	// ListNearby returns stores within the radius of the coordinates ordered by their distance, nearest
+	// first, paginated with the distance and ID as the cursor.
+	ListNearby(ctx context.Context, filter NearbyFilter) (NearbyResult, error)
+	// Insert validates and inserts a new store, and returns it.
+	Insert(ctx context.Context, rawStore RawStore) (Store, error)
+	// Update validates and replaces every field of the store, and returns the updated store.
+	Update(ctx context.Context, id int64, rawStore RawStore) (Store, error)
+	// Delete soft-deletes the store, it can no longer be acquired nor listed.
+	Delete(ctx context.Context, id int64) error
+	// SetState moves the store into another state, see Store.ApplyStateChange, and returns the updated
+	// store. It returns ErrInvalidStateChange or a *ValidationError if the change is not allowed, and
+	// ErrStateChanged if a conditional change no longer applies to the locked store.
+	SetState(ctx context.Context, id int64, change StateChange) (Store, error)
+	// GetSchedules returns the schedules of the stores, with the exceptions from the date of from onwards.
+	// Stores without any opening hours nor exceptions have an empty schedule.
+	GetSchedules(ctx context.Context, storeIds []int64, from time.Time) (map[int64]Schedule, error)
+	// SetOpeningHours validates and replaces the weekly opening hours of the store.
+	SetOpeningHours(ctx context.Context, storeId int64, hours []OpeningHours) error
+	// SetScheduleException validates and replaces the exception of the date.
+	SetScheduleException(ctx context.Context, storeId int64, exception ScheduleException) error
+	// DeleteScheduleException removes the exception of the date, it returns ErrNotFound if there is none.
+	DeleteScheduleException(ctx context.Context, storeId int64, date time.Time) error
+}
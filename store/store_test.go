@@ -0,0 +1,103 @@
+package store_test
+
+import (
+	"errors"
+	"testing"
+
+	"coffee-chain-api/store"
+)
+
+func TestParseStatus(t *testing.T) {
+	testCases := []struct {
+		input  string
+		expect store.Status
+		ok     bool
+	}{
+		{input: "active", expect: store.StatusActive, ok: true},
+		{input: "inactive", expect: store.StatusInactive, ok: true},
+		{input: "Active", expect: store.StatusUnspecified, ok: false},
+		{input: "", expect: store.StatusUnspecified, ok: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok := store.ParseStatus(tt.input)
+			if got != tt.expect || ok != tt.ok {
+				t.Errorf("expecting %s (%t), got %s (%t) instead", tt.expect, tt.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestRawStore_Validate(t *testing.T) {
+	valid := store.RawStore{
+		Name:      "  Kopi Sudirman ",
+		Address:   "Jl. Jend. Sudirman No. 1, Jakarta",
+		Latitude:  -6.2088,
+		Longitude: 106.8456,
+		Phone:     "+62 21 555-0100",
+	}
+
+	testCases := []struct {
+		name   string
+		modify func(s *store.RawStore)
+		field  string
+	}{
+		{name: "valid", modify: func(s *store.RawStore) {}, field: ""},
+		{name: "empty name", modify: func(s *store.RawStore) { s.Name = "   " }, field: "name"},
+		{name: "empty address", modify: func(s *store.RawStore) { s.Address = "" }, field: "address"},
+		{name: "latitude out of range", modify: func(s *store.RawStore) { s.Latitude = 91 }, field: "latitude"},
+		{name: "longitude out of range", modify: func(s *store.RawStore) { s.Longitude = -181 }, field: "longitude"},
+		{name: "missing coordinates", modify: func(s *store.RawStore) { s.Latitude, s.Longitude = 0, 0 }, field: "latitude"},
+		{name: "empty phone", modify: func(s *store.RawStore) { s.Phone = "" }, field: ""},
+		{name: "phone with letters", modify: func(s *store.RawStore) { s.Phone = "call us" }, field: "phone"},
+		{name: "other timezone", modify: func(s *store.RawStore) { s.Timezone = "Asia/Makassar" }, field: ""},
+		{name: "unknown timezone", modify: func(s *store.RawStore) { s.Timezone = "Asia/Bandung" }, field: "timezone"},
+		{name: "local timezone", modify: func(s *store.RawStore) { s.Timezone = "Local" }, field: "timezone"},
+		{name: "inactive", modify: func(s *store.RawStore) { s.Status = store.StatusInactive }, field: ""},
+		{name: "unknown status", modify: func(s *store.RawStore) { s.Status = 9 }, field: "status"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			rawStore := valid
+			tt.modify(&rawStore)
+
+			err := rawStore.Normalize().Validate()
+			if tt.field == "" {
+				if err != nil {
+					t.Errorf("expecting no error, got %v instead", err)
+				}
+				return
+			}
+
+			var validationError *store.ValidationError
+			if !errors.As(err, &validationError) {
+				t.Fatalf("expecting a validation error, got %v instead", err)
+			}
+			if validationError.Field != tt.field {
+				t.Errorf("expecting invalid %s, got invalid %s instead", tt.field, validationError.Field)
+			}
+			if !errors.Is(err, store.ErrInvalidStore) {
+				t.Errorf("expecting error to match ErrInvalidStore")
+			}
+		})
+	}
+}
+
+func TestRawStore_Normalize(t *testing.T) {
+	got := store.RawStore{Name: " Kopi ", Phone: " 021 "}.Normalize()
+
+	if got.Name != "Kopi" {
+		t.Errorf("expecting name Kopi, got %q instead", got.Name)
+	}
+	if got.Phone != "021" {
+		t.Errorf("expecting phone 021, got %q instead", got.Phone)
+	}
+	if got.Timezone != store.DefaultTimezone {
+		t.Errorf("expecting timezone %s, got %s instead", store.DefaultTimezone, got.Timezone)
+	}
+	if got.Status != store.StatusActive {
+		t.Errorf("expecting status %s, got %s instead", store.StatusActive, got.Status)
+	}
+}